@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// auditEntry is the line 'check -audit-log' appends for each run.
+type auditEntry struct {
+	Time          time.Time     `json:"time"`
+	Checked       int           `json:"checked"`
+	Updates       int           `json:"updates"`
+	UpToDate      int           `json:"up_to_date"`
+	Discrepancies int           `json:"discrepancies"`
+	Skipped       int           `json:"skipped"`
+	Errors        int           `json:"errors"`
+	Changed       []auditChange `json:"changed"` // Apps whose status differs from their previous check.
+}
+
+// auditChange is an app whose status changed since it was last checked. Statuses use the
+// ndjson identifiers, e.g. "update_available".
+type auditChange struct {
+	App  string `json:"app"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// newAuditEntry summarizes a run's results for the audit log.
+func newAuditEntry(results []checkResult, now time.Time) auditEntry {
+	summary := summarizeResults(results)
+	entry := auditEntry{
+		Time:          now.UTC().Truncate(time.Second),
+		Checked:       len(results),
+		Updates:       summary.updates,
+		UpToDate:      summary.upToDate,
+		Discrepancies: summary.discrepancies,
+		Skipped:       summary.skipped,
+		Errors:        summary.errors,
+		Changed:       []auditChange{},
+	}
+	for _, result := range results {
+		if result.previousStatus != "" {
+			entry.Changed = append(entry.Changed, auditChange{App: result.appName, From: result.previousStatus, To: ndjsonStatuses[result.status]})
+		}
+	}
+	return entry
+}
+
+// appendAuditLog appends a line summarizing results to the audit log at path, creating it if
+// needed. The line is written with a single O_APPEND write, so concurrent runs never interleave.
+func appendAuditLog(path string, results []checkResult) error {
+	data, err := json.Marshal(newAuditEntry(results, time.Now()))
+	if err != nil {
+		return fmt.Errorf("could not format audit log entry: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, configFilePerm)
+	if err != nil {
+		return fmt.Errorf("could not open audit log: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not write audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHandleCheckAuditLog(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	latest := "1.0.0"
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return latest, nil
+	}
+	if err := saveConfig(Config{"owner/a": "1.0.0", "owner/b": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	handleCheckCmd("", checkOptions{plainStatus: true, auditLog: auditPath})
+	latest = "1.1.0"
+	handleCheckCmd("", checkOptions{plainStatus: true, auditLog: auditPath})
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("Expected an audit log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one line per run, got %d:\n%s", len(lines), data)
+	}
+
+	var first, second auditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("Could not parse first line %q: %v", lines[0], err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("Could not parse second line %q: %v", lines[1], err)
+	}
+	if first.Time.IsZero() || first.Checked != 2 || first.UpToDate != 2 || first.Updates != 0 || len(first.Changed) != 0 {
+		t.Errorf("Unexpected first entry: %+v", first)
+	}
+	expectedChanges := []auditChange{
+		{App: "owner/a", From: "up_to_date", To: "update_available"},
+		{App: "owner/b", From: "up_to_date", To: "update_available"},
+	}
+	if second.Updates != 2 || second.Errors != 0 || !reflect.DeepEqual(second.Changed, expectedChanges) {
+		t.Errorf("Unexpected second entry: %+v", second)
+	}
+	if !strings.Contains(lines[0], `"changed":[]`) {
+		t.Errorf("Expected an empty changed list rather than null, got %s", lines[0])
+	}
+}
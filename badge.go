@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// shieldsBadge is the JSON read by a shields.io endpoint badge; see https://shields.io/badges/endpoint-badge.
+type shieldsBadge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// badgeFor returns the badge summarizing a run: green when everything is current, orange when
+// updates are available and red when any app failed to check.
+func badgeFor(summary checkSummary) shieldsBadge {
+	badge := shieldsBadge{SchemaVersion: 1, Label: "updates", Message: "up to date", Color: "green"}
+	if summary.updates > 0 {
+		badge.Message, badge.Color = fmt.Sprintf("%d available", summary.updates), "orange"
+	}
+	if summary.errors > 0 {
+		failed := fmt.Sprintf("%d failed", summary.errors)
+		if summary.updates > 0 {
+			failed = badge.Message + ", " + failed
+		}
+		badge.Message, badge.Color = failed, "red"
+	}
+	return badge
+}
+
+// writeBadgeFile writes the badge for results to path atomically, so a web server never serves
+// a partial file.
+func writeBadgeFile(path string, results []checkResult) error {
+	data, _ := json.Marshal(badgeFor(summarizeResults(results))) // Only strings and ints, which always marshal.
+	if err := writeFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write badge file: %w", err)
+	}
+	return nil
+}
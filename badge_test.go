@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBadgeFor(t *testing.T) {
+	tests := []struct {
+		summary  checkSummary
+		expected shieldsBadge
+	}{
+		{checkSummary{upToDate: 3}, shieldsBadge{1, "updates", "up to date", "green"}},
+		{checkSummary{upToDate: 1, updates: 2}, shieldsBadge{1, "updates", "2 available", "orange"}},
+		{checkSummary{upToDate: 1, errors: 1}, shieldsBadge{1, "updates", "1 failed", "red"}},
+		{checkSummary{updates: 2, errors: 1}, shieldsBadge{1, "updates", "2 available, 1 failed", "red"}},
+	}
+	for _, tt := range tests {
+		if got := badgeFor(tt.summary); got != tt.expected {
+			t.Errorf("badgeFor(%+v): expected %+v, got %+v", tt.summary, tt.expected, got)
+		}
+	}
+}
+
+func TestHandleCheckBadgeFile(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	latest := "1.0.0"
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		if appIdentifier == "owner/broken" {
+			return "", errors.New("connection reset")
+		}
+		return latest, nil
+	}
+	if err := saveConfig(Config{"owner/a": "1.0.0", "owner/b": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	dir := t.TempDir()
+	badgePath := filepath.Join(dir, "badge.json")
+
+	readBadge := func() string {
+		t.Helper()
+		data, err := os.ReadFile(badgePath)
+		if err != nil {
+			t.Fatalf("Expected a badge file: %v", err)
+		}
+		return string(data)
+	}
+
+	handleCheckCmd("", checkOptions{plainStatus: true, badgeFile: badgePath})
+	if got := readBadge(); got != `{"schemaVersion":1,"label":"updates","message":"up to date","color":"green"}`+"\n" {
+		t.Errorf("Unexpected badge when current: %s", got)
+	}
+
+	latest = "1.1.0"
+	handleCheckCmd("", checkOptions{plainStatus: true, badgeFile: badgePath})
+	if got := readBadge(); got != `{"schemaVersion":1,"label":"updates","message":"2 available","color":"orange"}`+"\n" {
+		t.Errorf("Unexpected badge when outdated: %s", got)
+	}
+
+	if err := saveConfig(Config{"owner/a": "1.1.0", "owner/broken": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	handleCheckCmd("", checkOptions{plainStatus: true, badgeFile: badgePath})
+	if got := readBadge(); got != `{"schemaVersion":1,"label":"updates","message":"1 failed","color":"red"}`+"\n" {
+		t.Errorf("Unexpected badge on errors: %s", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, got %v", entries)
+	}
+}
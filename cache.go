@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores string values for a limited time. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, if there is one that hasn't expired.
+	Get(key string) (string, bool)
+	// GetStale returns the value stored under key even if it has expired.
+	GetStale(key string) (string, bool)
+	// Set stores value under key until ttl has passed.
+	Set(key, value string, ttl time.Duration)
+}
+
+// cacheEntry is a cached value and when it stops being valid.
+type cacheEntry struct {
+	Value   string    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// latestCache caches the latest version of each app, by app name, between lookups. It is nil,
+// disabling caching, unless 'check -cache-ttl' is given. Tests can set a memoryCache.
+var latestCache Cache
+
+// latestCacheTTL is how long versions stored in latestCache stay valid.
+var latestCacheTTL time.Duration
+
+// offlineMode makes 'check' report the latest versions in latestCache, however old, or else the
+// ones recorded by the last check, instead of looking them up. It is set by 'check -offline'.
+var offlineMode bool
+
+// cachedLatestVersion returns appName's latest version from latestCache, if it is cached.
+func cachedLatestVersion(appName string) (string, bool) {
+	if latestCache == nil {
+		return "", false
+	}
+	return latestCache.Get(appName)
+}
+
+// cacheLatestVersion stores appName's latest version in latestCache, if caching is enabled.
+func cacheLatestVersion(appName, version string) {
+	if latestCache != nil && latestCacheTTL > 0 {
+		latestCache.Set(appName, version, latestCacheTTL)
+	}
+}
+
+// memoryCache is a Cache held in memory, for tests and single runs.
+type memoryCache struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// newMemoryCache returns an empty in-memory cache using the real clock.
+func newMemoryCache() *memoryCache {
+	return &memoryCache{now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+func (c *memoryCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.Expires) {
+		return "", false
+	}
+	return entry.Value, true
+}
+
+func (c *memoryCache) GetStale(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return entry.Value, ok
+}
+
+func (c *memoryCache) Set(key, value string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry{Value: value, Expires: c.now().Add(ttl)}
+}
+
+// fileCache is a Cache kept in a JSON file, so cached values survive between runs.
+// Each Set rewrites the file atomically; expired entries are dropped when it does.
+type fileCache struct {
+	path string
+	now  func() time.Time
+
+	mu sync.Mutex
+}
+
+// newFileCache returns a cache stored at path using the real clock.
+func newFileCache(path string) *fileCache {
+	return &fileCache{path: path, now: time.Now}
+}
+
+// cacheFile returns the path of the cache file kept next to the config file, e.g.
+// versions.cache.json for versions.toml.
+func cacheFile() string {
+	return strings.TrimSuffix(configFile, filepath.Ext(configFile)) + ".cache.json"
+}
+
+func (c *fileCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.read()[key]
+	if !ok || !c.now().Before(entry.Expires) {
+		return "", false
+	}
+	return entry.Value, true
+}
+
+func (c *fileCache) GetStale(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.read()[key]
+	return entry.Value, ok
+}
+
+func (c *fileCache) Set(key, value string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	entries := c.read()
+	for k, entry := range entries {
+		if !now.Before(entry.Expires) {
+			delete(entries, k)
+		}
+	}
+	entries[key] = cacheEntry{Value: value, Expires: now.Add(ttl)}
+	if err := c.write(entries); err != nil {
+		log.Printf("Warning: Could not write cache file '%s': %v", c.path, err)
+	}
+}
+
+// read returns the entries in the cache file. A missing or unreadable file is an empty cache.
+func (c *fileCache) read() map[string]cacheEntry {
+	entries := make(map[string]cacheEntry)
+	data, err := os.ReadFile(c.path)
+	if err != nil || json.Unmarshal(data, &entries) != nil {
+		return make(map[string]cacheEntry)
+	}
+	return entries
+}
+
+// write replaces the cache file with entries.
+func (c *fileCache) write(entries map[string]cacheEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not format cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), configDirPerm); err != nil {
+		return fmt.Errorf("could not create cache directory: %w", err)
+	}
+	return writeFileAtomic(c.path, append(data, '\n'), configFilePerm)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := newMemoryCache()
+	cache.now = func() time.Time { return now }
+
+	if _, ok := cache.Get("owner/repo"); ok {
+		t.Fatal("Expected an empty cache to miss")
+	}
+	cache.Set("owner/repo", "1.2.0", time.Hour)
+	if value, ok := cache.Get("owner/repo"); !ok || value != "1.2.0" {
+		t.Errorf("Expected a fresh entry to hit, got %q, %v", value, ok)
+	}
+
+	now = now.Add(59 * time.Minute)
+	if _, ok := cache.Get("owner/repo"); !ok {
+		t.Error("Expected the entry to be valid just before its TTL")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := cache.Get("owner/repo"); ok {
+		t.Error("Expected the entry to expire once its TTL has passed")
+	}
+
+	cache.Set("owner/repo", "1.3.0", time.Hour)
+	if value, ok := cache.Get("owner/repo"); !ok || value != "1.3.0" {
+		t.Errorf("Expected Set to replace an expired entry, got %q, %v", value, ok)
+	}
+}
+
+func TestCheckAppUsesLatestCache(t *testing.T) {
+	originalGetLatestVersion := getLatestVersion
+	defer func() {
+		getLatestVersion = originalGetLatestVersion
+		latestCache, latestCacheTTL = nil, 0
+	}()
+	lookups := 0
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		lookups++
+		return "1.1.0", nil
+	}
+	now := time.Now()
+	cache := newMemoryCache()
+	cache.now = func() time.Time { return now }
+	latestCache, latestCacheTTL = cache, time.Hour
+
+	for i := 0; i < 2; i++ {
+		if result := checkApp("owner/repo", "1.0.0"); result.latestVersion != "1.1.0" || result.status != statusUpdateAvailable {
+			t.Fatalf("Expected an update to 1.1.0, got %+v", result)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("Expected the second check to be served from the cache, got %d lookups", lookups)
+	}
+
+	now = now.Add(2 * time.Hour)
+	checkApp("owner/repo", "1.0.0")
+	if lookups != 2 {
+		t.Errorf("Expected an expired entry to be looked up again, got %d lookups", lookups)
+	}
+}
+
+func TestFileCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "versions.cache.json")
+	now := time.Now()
+	cache := newFileCache(path)
+	cache.now = func() time.Time { return now }
+	cache.Set("owner/stale", "0.9.0", time.Minute)
+	cache.Set("owner/repo", "1.2.0", time.Hour)
+
+	reopened := newFileCache(path)
+	reopened.now = func() time.Time { return now.Add(30 * time.Minute) }
+	if value, ok := reopened.Get("owner/repo"); !ok || value != "1.2.0" {
+		t.Errorf("Expected the entry to survive reopening the cache, got %q, %v", value, ok)
+	}
+	if _, ok := reopened.Get("owner/stale"); ok {
+		t.Error("Expected the expired entry to miss")
+	}
+	reopened.Set("owner/other", "2.0.0", time.Hour)
+	if _, ok := reopened.read()["owner/stale"]; ok {
+		t.Error("Expected expired entries to be dropped when the file is rewritten")
+	}
+}
+
+func TestCheckOffline(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() {
+		getLatestVersion = originalGetLatestVersion
+		latestCache, offlineMode = nil, false
+	}()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		t.Errorf("Expected no lookups offline, got one for %s", appIdentifier)
+		return "", nil
+	}
+	if err := saveConfig(Config{"owner/a": "1.0.0", "owner/b": "1.0.0", "owner/c": "2.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	// Seed the cache as an earlier run would have, with one entry long expired.
+	seed := newFileCache(cacheFile())
+	seed.Set("owner/c", "2.0.0", time.Hour)
+	seed.now = func() time.Time { return time.Now().Add(-48 * time.Hour) }
+	seed.Set("owner/a", "1.1.0", time.Hour)
+
+	offlineMode, latestCache = true, newFileCache(cacheFile())
+	var exitCode int
+	output := stripAnsiCodes(captureOutput(func() { exitCode = handleCheckCmd("", checkOptions{}) }))
+	for _, expected := range []string{
+		"Checking owner/a...  Current: 1.0.0, Latest: 1.1.0 (cached) (Update Available!)",
+		"Skipping owner/b: Latest version unknown offline",
+		"Checking owner/c...  Current: 2.0.0, Latest: 2.0.0 (cached) (Up to date)",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+	if exitCode != exitOK {
+		t.Errorf("Expected exit code %d offline, got %d", exitOK, exitCode)
+	}
+	if _, err := os.Stat(stateFile()); !os.IsNotExist(err) {
+		t.Errorf("Expected an offline run not to record checks, got err %v", err)
+	}
+
+	output = captureOutput(func() { handleCheckCmd("owner/a", checkOptions{format: formatNDJSON}) })
+	if !strings.Contains(output, `"cached":true`) {
+		t.Errorf("Expected ndjson to mark the result as cached, got: %s", output)
+	}
+}
+
+func TestCheckOfflineAfterPlainCheck(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() {
+		getLatestVersion = originalGetLatestVersion
+		latestCache, offlineMode = nil, false
+	}()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.2.0", nil
+	}
+	if err := saveConfig(Config{"owner/a": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	// A plain check, without -cache-ttl, writes no cache file.
+	captureOutput(func() { handleCheckCmd("", checkOptions{}) })
+
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		t.Errorf("Expected no lookups offline, got one for %s", appIdentifier)
+		return "", nil
+	}
+	offlineMode, latestCache = true, newFileCache(cacheFile())
+	output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{}) }))
+	if !strings.Contains(output, "Checking owner/a...  Current: 1.0.0, Latest: 1.2.0 (cached) (Update Available!)") {
+		t.Errorf("Expected the latest version seen by the plain check, got:\n%s", output)
+	}
+}
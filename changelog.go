@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// changelogConcurrency caps the number of applications 'changelog' checks at once.
+const changelogConcurrency = 8
+
+// changelogEntry is the release notes for one application with an update available.
+type changelogEntry struct {
+	result checkResult
+	notes  string
+	err    error // Set if the release notes couldn't be fetched.
+}
+
+// handleChangelogCmd prints the release notes of every managed application that has an update available.
+func handleChangelogCmd() {
+	config, err := loadConfig()
+	if err != nil {
+		PrintError("Could not load configuration: %v", err)
+		return
+	}
+	if len(config) == 0 {
+		PrintInfo("No applications currently managed. Use the 'add' command to add some.")
+		return
+	}
+
+	appNames := sortedAppNames(config)
+	entries := make([]*changelogEntry, len(appNames))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, changelogConcurrency)
+	for i, appName := range appNames {
+		wg.Add(1)
+		go func(i int, appName string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			entries[i] = fetchChangelogEntry(appName, config[appName])
+		}(i, appName)
+	}
+	wg.Wait()
+
+	printed := 0
+	for _, entry := range entries {
+		switch {
+		case entry.result.status == statusError:
+			PrintError("Failed to check %s: %v", Colorize(entry.result.appName, colorMagentaFg), entry.result.err)
+		case entry.result.status != statusUpdateAvailable:
+			continue
+		default:
+			printChangelogEntry(entry)
+			printed++
+		}
+	}
+	if printed == 0 {
+		PrintInfo("No updates available.")
+	}
+}
+
+// fetchChangelogEntry checks appName and, if an update is available, fetches the release notes
+// of the reported latest version from the source that reported it. Release notes are only
+// available for GitHub releases.
+func fetchChangelogEntry(appName, currentVersion string) *changelogEntry {
+	entry := &changelogEntry{result: checkApp(appName, currentVersion)}
+	if entry.result.status != statusUpdateAvailable {
+		return entry
+	}
+
+	source, identifier, _ := resolveSource(entry.result.sourceRef())
+	if entry.result.movedTo != "" {
+		identifier = entry.result.movedTo
+	}
+	if _, isGitHub := source.(githubSource); !isGitHub {
+		entry.err = fmt.Errorf("release notes are only available for GitHub releases")
+		return entry
+	}
+	tag := channelTag(appOptions[appName].Channel, entry.result.latestVersion)
+	release, err := getReleaseByTag(withBodyRateLimit(requestContext), identifier, tag, githubAPIBase)
+	if err != nil {
+		entry.err = err
+		return entry
+	}
+	entry.notes = strings.TrimSpace(release.Body)
+	return entry
+}
+
+// printChangelogEntry prints an application's version bump as a header followed by its release notes.
+func printChangelogEntry(entry *changelogEntry) {
+	PrintHeader("%s %s -> %s", entry.result.appName, entry.result.currentVersion, entry.result.latestVersion)
+	switch {
+	case entry.err != nil:
+		PrintError("Could not fetch release notes for %s: %v", Colorize(entry.result.appName, colorMagentaFg), entry.err)
+	case entry.notes == "":
+		PrintInfo("No release notes.")
+	default:
+		PrintMessage("%s", entry.notes)
+	}
+	PrintMessage("")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestHandleChangelogCommand(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion, originalGetReleaseByTag := getLatestVersion, getReleaseByTag
+	defer func() { getLatestVersion, getReleaseByTag = originalGetLatestVersion, originalGetReleaseByTag }()
+
+	releases := map[string]*GitHubReleaseInfo{
+		"owner/alpha":   {TagName: "v2.0.0", Body: "Alpha notes: new parser."},
+		"owner/beta":    {TagName: "v1.5.0", Body: "Beta notes: bug fixes."},
+		"owner/current": {TagName: "v1.0.0", Body: "Current notes should not appear."},
+		"owner/quiet":   {TagName: "v3.0.0"},
+	}
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return strings.TrimPrefix(releases[appIdentifier].TagName, "v"), nil
+	}
+	getReleaseByTag = func(ctx context.Context, appIdentifier, tag, apiBaseURL string) (*GitHubReleaseInfo, error) {
+		if release, ok := releases[appIdentifier]; ok && (release.TagName == tag || release.TagName == "v"+tag) {
+			return release, nil
+		}
+		return nil, tagError(ErrNotFound, "no release %s of %s", tag, appIdentifier)
+	}
+
+	t.Run("AggregatesNotesForUpdates", func(t *testing.T) {
+		config := Config{"owner/alpha": "1.0.0", "owner/beta": "1.4.0", "owner/current": "1.0.0", "owner/quiet": "2.0.0"}
+		if err := saveConfig(config); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(handleChangelogCmd))
+
+		for _, expected := range []string{
+			"== owner/alpha 1.0.0 -> 2.0.0 ==\nAlpha notes: new parser.\n",
+			"== owner/beta 1.4.0 -> 1.5.0 ==\nBeta notes: bug fixes.\n",
+			"== owner/quiet 2.0.0 -> 3.0.0 ==\nInfo: No release notes.\n",
+		} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected output to contain %q. Got:\n%s", expected, output)
+			}
+		}
+		if strings.Contains(output, "owner/current") || strings.Contains(output, "Current notes") {
+			t.Errorf("Up-to-date apps should not be included. Got:\n%s", output)
+		}
+		if strings.Index(output, "owner/alpha") > strings.Index(output, "owner/beta") {
+			t.Errorf("Expected entries in sorted order. Got:\n%s", output)
+		}
+	})
+
+	t.Run("NotesOfReportedRelease", func(t *testing.T) {
+		defer func() { appOptions = make(map[string]AppOptions) }()
+		// The mirror in the sources list answers; its channel's latest isn't the repo's latest.
+		releases["owner/mirror"] = &GitHubReleaseInfo{TagName: "lts-1.8.0", Body: "Mirror LTS notes."}
+		defer delete(releases, "owner/mirror")
+		appOptions["owner/tool"] = AppOptions{Sources: []string{"owner/mirror"}, Channel: "lts-"}
+		originalGetLatestMatchingVersion := getLatestMatchingVersion
+		defer func() { getLatestMatchingVersion = originalGetLatestMatchingVersion }()
+		getLatestMatchingVersion = func(ctx context.Context, appIdentifier, channel string, ignore []string, field, apiBaseURL string) (string, error) {
+			return strings.TrimPrefix(releases[appIdentifier].TagName, channel), nil
+		}
+		if err := saveConfig(Config{"owner/tool": "1.7.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+
+		output := stripAnsiCodes(captureOutput(handleChangelogCmd))
+		if !strings.Contains(output, "== owner/tool 1.7.0 -> 1.8.0 ==\nMirror LTS notes.\n") {
+			t.Errorf("Expected the notes of the reported release from the answering source. Got:\n%s", output)
+		}
+	})
+
+	t.Run("NoUpdates", func(t *testing.T) {
+		if err := saveConfig(Config{"owner/current": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(handleChangelogCmd))
+		if !strings.Contains(output, "Info: No updates available.") {
+			t.Errorf("Expected a no-updates message. Got:\n%s", output)
+		}
+	})
+}
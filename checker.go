@@ -1,51 +1,497 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	neturl "net/url"
 	"strings"
+	"time"
 )
 
 // GitHubReleaseInfo struct to unmarshal the relevant parts of the GitHub API JSON response.
 type GitHubReleaseInfo struct {
-	TagName string `json:"tag_name"`
-	Name    string `json:"name"`        // For more descriptive release name
-	Body    string `json:"body"`        // For release notes/changelog
-	HTMLURL string `json:"html_url"`    // Link to the release page
+	TagName string               `json:"tag_name"`
+	Name    string               `json:"name"`     // For more descriptive release name
+	Body    string               `json:"body"`     // For release notes/changelog
+	HTMLURL string               `json:"html_url"` // Link to the release page
+	Assets  []GitHubReleaseAsset `json:"assets"`   // Files attached to the release
+
+	PublishedAt time.Time `json:"published_at"` // Zero for drafts, which aren't published.
+
+	Draft      bool `json:"draft"`      // Unpublished; only returned by the list endpoint.
+	Prerelease bool `json:"prerelease"` // Marked as a pre-release on GitHub.
+}
+
+// GitHubReleaseAsset is a downloadable file attached to a release.
+type GitHubReleaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// RepoMovedError is returned when GitHub redirects a repository to a different owner/repo,
+// which happens after a repository is renamed or transferred.
+type RepoMovedError struct {
+	From string // The identifier that was requested.
+	To   string // The owner/repo the request was redirected to.
+}
+
+func (e *RepoMovedError) Error() string {
+	return fmt.Sprintf("%s now redirects to %s", e.From, e.To)
+}
+
+// Sentinel errors returned by the checker, so callers can tell failures apart with errors.Is.
+// The errors actually returned carry a more detailed message; see taggedError.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrInvalidIdentifier = errors.New("invalid application identifier")
+)
+
+// taggedError keeps a detailed, user-facing message while matching one of the sentinel errors.
+type taggedError struct {
+	sentinel error
+	message  string
+}
+
+func (e *taggedError) Error() string { return e.message }
+func (e *taggedError) Unwrap() error { return e.sentinel }
+
+// tagError returns an error with the formatted message that matches sentinel under errors.Is.
+func tagError(sentinel error, format string, args ...interface{}) error {
+	return &taggedError{sentinel: sentinel, message: fmt.Sprintf(format, args...)}
+}
+
+// NetworkError is returned when a request couldn't be completed at all, e.g. because
+// the host was unreachable. Err is the underlying transport error.
+type NetworkError struct {
+	Identifier string // The application being looked up.
+	URL        string // The URL that was requested.
+	Err        error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("network error fetching release info for %s from %s: %v", e.Identifier, e.URL, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+// statusSentinel maps an unsuccessful HTTP response to the matching sentinel error, if any.
+// GitHub reports an exhausted rate limit as a 403 with X-RateLimit-Remaining set to 0.
+func statusSentinel(resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// retryAfterHint returns a note on how long a rate-limited response asks to wait before trying
+// again, for appending to its error message, or "" if it doesn't say.
+func retryAfterHint(resp *http.Response) string {
+	wait, ok := retryAfter(resp, time.Now())
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("; retry after %s", wait.Round(time.Second))
+}
+
+// repoFromAPIPath extracts "owner/repo" from an API path like /repos/owner/repo/releases/latest.
+// It returns "" if the path doesn't name a repository (e.g. /repositories/123/releases/latest).
+func repoFromAPIPath(path string) string {
+	i := strings.Index(path, "/repos/")
+	if i < 0 {
+		return ""
+	}
+	parts := strings.SplitN(path[i+len("/repos/"):], "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// repoIDFromAPIPath splits an API path like /api/v3/repositories/123/releases/latest, which GitHub
+// redirects a renamed repository's requests to, into the path of the repository itself
+// (/api/v3/repositories/123). It returns "" if the path doesn't refer to a repository by id.
+func repoIDFromAPIPath(path string) string {
+	i := strings.Index(path, "/repositories/")
+	if i < 0 {
+		return ""
+	}
+	id := strings.SplitN(path[i+len("/repositories/"):], "/", 2)[0]
+	if id == "" || strings.Trim(id, "0123456789") != "" {
+		return ""
+	}
+	return path[:i] + "/repositories/" + id
+}
+
+// defaultGitHubAPIVersion is the REST API version sent in the X-GitHub-Api-Version header.
+const defaultGitHubAPIVersion = "2022-11-28"
+
+// githubAPIVersion is the X-GitHub-Api-Version sent with each request.
+// It can be overridden for GitHub Enterprise servers that need a different value.
+var githubAPIVersion = defaultGitHubAPIVersion
+
+// defaultGitHubAPIBase is the API base URL of github.com.
+const defaultGitHubAPIBase = "https://api.github.com"
+
+// githubAPIBase is the GitHub API base URL used at runtime, set by the global -api-base flag.
+// When empty, defaultGitHubAPIBase is used.
+var githubAPIBase string
+
+// validateAPIBase checks that raw is an absolute http(s) URL suitable for -api-base.
+func validateAPIBase(raw string) error {
+	u, err := neturl.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid -api-base '%s': %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid -api-base '%s': expected an absolute http or https URL", raw)
+	}
+	return nil
 }
 
 // getLatestVersionGitHub fetches the latest release tag name for a given appIdentifier (owner/repo).
 // For testability, apiBaseURL can be provided to point to a mock server.
-// If apiBaseURL is empty, it defaults to "https://api.github.com".
+// If apiBaseURL is empty, it defaults to "https://api.github.com". Cancelling ctx aborts the request.
 // This is the internal implementation.
-func getLatestVersionGitHubImpl(appIdentifier string, apiBaseURL string) (string, error) {
-	if !strings.Contains(appIdentifier, "/") {
-		return "", fmt.Errorf("invalid application identifier: expected 'owner/repo', got '%s'", appIdentifier)
+func getLatestVersionGitHubImpl(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+	releaseInfo, err := getLatestReleaseGitHubImpl(ctx, appIdentifier, apiBaseURL)
+	if err != nil {
+		return "", err
+	}
+
+	return releaseVersion(releaseInfo, versionFieldTagName), nil
+}
+
+// keepVPrefix disables stripping the "v" from release tags, for projects where it is part of
+// the version. It is set by the global -keep-v-prefix flag.
+var keepVPrefix bool
+
+// tagVersion returns the version named by a release tag: the tag without its "v" prefix,
+// or the tag unchanged if keepVPrefix is set.
+func tagVersion(tag string) string {
+	if keepVPrefix {
+		return tag
+	}
+	return strings.TrimPrefix(tag, "v")
+}
+
+// channelTag returns the release tag a version found in channel most likely came from, the
+// reverse of getLatestMatchingVersionGitHubImpl: the channel's prefix followed by the version.
+// A channel that is a single moving tag, e.g. "nightly", names the tag itself. A "v" the version
+// lost to tagVersion is left to getReleaseByTag to try.
+func channelTag(channel, version string) string {
+	prefix := strings.TrimSuffix(channel, "*")
+	if prefix != "" && version == tagVersion(prefix) {
+		return prefix
+	}
+	return prefix + version
+}
+
+// Release fields the version can be read from, chosen per app by its version_field option.
+const (
+	versionFieldTagName = "tag_name"
+	versionFieldName    = "name"
+)
+
+// releaseVersion returns the version release represents: its tag, or its name if field is
+// versionFieldName. Either is normalized like a tag, so a name of "v1.2.3" becomes "1.2.3".
+func releaseVersion(release *GitHubReleaseInfo, field string) string {
+	if field == versionFieldName {
+		return tagVersion(strings.TrimSpace(release.Name))
 	}
+	return tagVersion(release.TagName)
+}
+
+// getLatestReleaseGitHubImpl fetches the full latest release for appIdentifier (owner/repo).
+// apiBaseURL behaves as for getLatestVersionGitHubImpl.
+func getLatestReleaseGitHubImpl(ctx context.Context, appIdentifier string, apiBaseURL string) (*GitHubReleaseInfo, error) {
+	url, err := githubReleasesURL(appIdentifier, apiBaseURL, "/latest")
+	if err != nil {
+		return nil, err
+	}
+	var releaseInfo GitHubReleaseInfo
+	if err := githubGetJSON(ctx, appIdentifier, url, &releaseInfo); err != nil {
+		return nil, err
+	}
+
+	if releaseInfo.TagName == "" {
+		return nil, fmt.Errorf("no version tag (tag_name) found in the latest release for %s (URL: %s)", appIdentifier, url)
+	}
+	return &releaseInfo, nil
+}
+
+// getLatestReleaseIncludingDraftsGitHubImpl is like getLatestReleaseGitHubImpl, but also considers
+// draft releases, which /releases/latest never returns. It picks the highest version, read from
+// field as by releaseVersion, among the most recent releases (see listReleasesGitHub), skipping
+// pre-releases as /releases/latest does. Drafts are only visible to tokens with push access to
+// the repository.
+func getLatestReleaseIncludingDraftsGitHubImpl(ctx context.Context, appIdentifier, field, apiBaseURL string) (*GitHubReleaseInfo, error) {
+	releases, err := listReleasesGitHub(ctx, appIdentifier, apiBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *GitHubReleaseInfo
+	latestVersion := ""
+	for i, release := range releases {
+		version := releaseVersion(&release, field)
+		if release.Prerelease || version == "" {
+			continue
+		}
+		if latest == nil || compareVersions(version, latestVersion) > 0 {
+			latest, latestVersion = &releases[i], version
+		}
+	}
+	if latest == nil {
+		if field == "" {
+			field = versionFieldTagName
+		}
+		return nil, tagError(ErrNotFound, "no releases with a version (%s) found for %s", field, appIdentifier)
+	}
+	return latest, nil
+}
 
-	baseURL := "https://api.github.com"
+// getLatestMatchingVersionGitHubImpl returns the highest version among the most recent releases
+// (see listReleasesGitHub) whose tag starts with channel, a prefix such as "stable-", leaving out
+// the versions in ignore. A trailing "*" on channel is ignored, so "stable-*" works too. The prefix
+// is removed from the tag to get the version, unless field is versionFieldName, in which case the
+// version is read from the release's name instead and ignore applies to that. Drafts are skipped.
+// Pre-releases are skipped too without a channel, like GitHub's latest release, but not with one,
+// as a channel may consist of nothing else.
+func getLatestMatchingVersionGitHubImpl(ctx context.Context, appIdentifier, channel string, ignore []string, field, apiBaseURL string) (string, error) {
+	releases, err := listReleasesGitHub(ctx, appIdentifier, apiBaseURL)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := strings.TrimSuffix(channel, "*")
+	latest := ""
+	for _, release := range releases {
+		if release.Draft || !strings.HasPrefix(release.TagName, prefix) || (channel == "" && release.Prerelease) {
+			continue
+		}
+		version := strings.TrimPrefix(release.TagName, prefix)
+		if version == "" {
+			version = release.TagName // A channel that is a single moving tag, e.g. "nightly".
+		}
+		version = tagVersion(version)
+		if field == versionFieldName {
+			version = releaseVersion(&release, field)
+		}
+		if version == "" || isIgnoredVersion(version, ignore) {
+			continue
+		}
+		if latest == "" || compareVersions(version, latest) > 0 {
+			latest = version
+		}
+	}
+	switch {
+	case latest != "":
+		return latest, nil
+	case channel != "":
+		return "", tagError(ErrNotFound, "no releases in channel '%s' found for %s", channel, appIdentifier)
+	}
+	return "", tagError(ErrNotFound, "no releases other than ignored ones found for %s", appIdentifier)
+}
+
+// isIgnoredVersion reports whether version is one of ignore. Versions are compared like
+// compareVersions does, so "v1.2.0" in ignore matches a version "1.2.0".
+func isIgnoredVersion(version string, ignore []string) bool {
+	for _, ignored := range ignore {
+		if compareVersions(version, strings.TrimSpace(ignored)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// releasesPageSize is the number of releases listReleasesGitHub requests per page.
+const releasesPageSize = 30
+
+// defaultMaxReleasePages is how many pages of releases listReleasesGitHub fetches by default.
+const defaultMaxReleasePages = 3
+
+// maxReleasePages caps the pages of releases fetched by listReleasesGitHub, set by
+// 'check -max-release-pages'. Releases beyond the cap are not considered.
+var maxReleasePages = defaultMaxReleasePages
+
+// validateMaxReleasePages checks a -max-release-pages value: with no pages at all, every lookup
+// that lists releases would report that there are none.
+func validateMaxReleasePages(pages int) error {
+	if pages < 1 {
+		return fmt.Errorf("-max-release-pages must be at least 1, got %d", pages)
+	}
+	return nil
+}
+
+// listReleasesGitHub returns appIdentifier's releases, newest first. GitHub returns them in
+// pages, which are followed through their Link headers up to maxReleasePages.
+func listReleasesGitHub(ctx context.Context, appIdentifier, apiBaseURL string) ([]GitHubReleaseInfo, error) {
+	url, err := githubReleasesURL(appIdentifier, apiBaseURL, fmt.Sprintf("?per_page=%d", releasesPageSize))
+	if err != nil {
+		return nil, err
+	}
+	var releases []GitHubReleaseInfo
+	for page := 0; url != "" && page < maxReleasePages; page++ {
+		var pageReleases []GitHubReleaseInfo
+		next, err := githubGetJSONPage(ctx, appIdentifier, url, &pageReleases)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, pageReleases...)
+		url = next
+	}
+	return releases, nil
+}
+
+// nextPageURL returns the URL marked rel="next" in a Link header, or "" if there is none.
+// GitHub's Link headers look like: <https://...&page=2>; rel="next", <https://...&page=5>; rel="last"
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// getReleaseByTagGitHubImpl fetches the release for a specific tag of appIdentifier (owner/repo).
+// If tag has no "v" prefix and isn't found, the "v"-prefixed tag is tried too, as most
+// projects tag releases that way. A missing release matches ErrNotFound.
+func getReleaseByTagGitHubImpl(ctx context.Context, appIdentifier, tag, apiBaseURL string) (*GitHubReleaseInfo, error) {
+	candidates := []string{tag}
+	if !strings.HasPrefix(tag, "v") {
+		candidates = append(candidates, "v"+tag)
+	}
+
+	var err error
+	for _, candidate := range candidates {
+		var url string
+		url, err = githubReleasesURL(appIdentifier, apiBaseURL, "/tags/"+neturl.PathEscape(candidate))
+		if err != nil {
+			return nil, err
+		}
+		var releaseInfo GitHubReleaseInfo
+		if err = githubGetJSON(ctx, appIdentifier, url, &releaseInfo); err == nil {
+			return &releaseInfo, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// getCommitsBehindGitHubImpl counts the commits on appIdentifier's tag head that aren't on tag
+// base, using GitHub's compare API. Like getReleaseByTagGitHubImpl, if the tags have no "v"
+// prefix and aren't found, the "v"-prefixed tags are tried too.
+func getCommitsBehindGitHubImpl(ctx context.Context, appIdentifier, base, head, apiBaseURL string) (int, error) {
+	prefixes := []string{""}
+	if !strings.HasPrefix(base, "v") && !strings.HasPrefix(head, "v") {
+		prefixes = append(prefixes, "v")
+	}
+
+	var err error
+	for _, prefix := range prefixes {
+		var url string
+		url, err = githubRepoURL(appIdentifier, apiBaseURL, "/compare/"+neturl.PathEscape(prefix+base)+"..."+neturl.PathEscape(prefix+head))
+		if err != nil {
+			return 0, err
+		}
+		var comparison struct {
+			AheadBy  int `json:"ahead_by"`  // Commits on head that aren't on base.
+			BehindBy int `json:"behind_by"` // Commits on base that aren't on head.
+		}
+		if err = githubGetJSON(ctx, appIdentifier, url, &comparison); err == nil {
+			return comparison.AheadBy, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return 0, err
+		}
+	}
+	return 0, err
+}
+
+// githubReleasesURL returns the URL of appIdentifier's releases endpoint followed by suffix.
+func githubReleasesURL(appIdentifier, apiBaseURL, suffix string) (string, error) {
+	return githubRepoURL(appIdentifier, apiBaseURL, "/releases"+suffix)
+}
+
+// githubRepoURL returns the URL of appIdentifier's repository endpoint followed by suffix.
+func githubRepoURL(appIdentifier, apiBaseURL, suffix string) (string, error) {
+	if !strings.Contains(appIdentifier, "/") {
+		return "", tagError(ErrInvalidIdentifier, "invalid application identifier: expected 'owner/repo', got '%s'", appIdentifier)
+	}
+	baseURL := defaultGitHubAPIBase
 	if apiBaseURL != "" {
 		baseURL = apiBaseURL // Use mock server URL for testing
 	}
+	return fmt.Sprintf("%s/repos/%s%s", strings.TrimSuffix(baseURL, "/"), appIdentifier, suffix), nil
+}
 
-	url := fmt.Sprintf("%s/repos/%s/releases/latest", baseURL, appIdentifier)
+// githubGetJSON requests url from the GitHub API on behalf of appIdentifier and decodes
+// the JSON response into v. A redirect to a differently named repository is reported
+// as a RepoMovedError. Cancelling ctx, or its deadline passing, aborts the request.
+func githubGetJSON(ctx context.Context, appIdentifier, url string, v interface{}) error {
+	_, err := githubGetJSONPage(ctx, appIdentifier, url, v)
+	return err
+}
 
-	client := &http.Client{} // Consider setting a timeout: client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("GET", url, nil)
+// githubGetJSONPage is like githubGetJSON, but also returns the URL of the next page of a
+// paginated response, or "" if this was the last one.
+func githubGetJSONPage(ctx context.Context, appIdentifier, url string, v interface{}) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "", fmt.Errorf("internal error creating request for %s: %w", appIdentifier, err)
 	}
-	req.Header.Set("User-Agent", "ShouldUpdateApp/1.0")
+	req.Header.Set("User-Agent", envConfig().UserAgent)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("X-GitHub-Api-Version", githubAPIVersion)
+	if githubToken != "" {
+		req.Header.Set("Authorization", "Bearer "+githubToken)
+	}
 
-	resp, err := client.Do(req)
+	resp, err := doRequest(req)
 	if err != nil {
-		return "", fmt.Errorf("network error fetching release info for %s from %s: %w", appIdentifier, url, err)
+		return "", &NetworkError{Identifier: appIdentifier, URL: url, Err: err}
 	}
 	defer resp.Body.Close()
 
+	if location, err := resp.Location(); err == nil && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if movedTo := repoFromAPIPath(location.Path); movedTo != "" {
+			return "", &RepoMovedError{From: appIdentifier, To: movedTo}
+		}
+		if repoPath := repoIDFromAPIPath(location.Path); repoPath != "" {
+			// GitHub redirects a renamed repository's requests to its id; look up the new name.
+			repoURL := *location
+			repoURL.Path, repoURL.RawPath, repoURL.RawQuery = repoPath, "", ""
+			var repo struct {
+				FullName string `json:"full_name"`
+			}
+			if err := githubGetJSON(ctx, appIdentifier, repoURL.String(), &repo); err != nil {
+				return "", err
+			}
+			if repo.FullName != "" && !strings.EqualFold(repo.FullName, appIdentifier) {
+				return "", &RepoMovedError{From: appIdentifier, To: repo.FullName}
+			}
+			return githubGetJSONPage(ctx, appIdentifier, location.String(), v)
+		}
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		var errorMsg strings.Builder
 		errorMsg.WriteString(fmt.Sprintf("GitHub API error for %s (status %d)", appIdentifier, resp.StatusCode))
@@ -63,23 +509,38 @@ func getLatestVersionGitHubImpl(appIdentifier string, apiBaseURL string) (string
 			// Fallback if parsing GitHub's specific error fails
 			errorMsg.WriteString(fmt.Sprintf(" (URL: %s)", url))
 		}
+		if sentinel := statusSentinel(resp); sentinel != nil {
+			if sentinel == ErrRateLimited {
+				errorMsg.WriteString(retryAfterHint(resp))
+			}
+			return "", &taggedError{sentinel: sentinel, message: errorMsg.String()}
+		}
 		return "", errors.New(errorMsg.String())
 	}
 
-	var releaseInfo GitHubReleaseInfo
-	if err := json.NewDecoder(resp.Body).Decode(&releaseInfo); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
 		return "", fmt.Errorf("error decoding JSON response for %s from %s: %w", appIdentifier, url, err)
 	}
-
-	if releaseInfo.TagName == "" {
-		return "", fmt.Errorf("no version tag (tag_name) found in the latest release for %s (URL: %s)", appIdentifier, url)
-	}
-
-	// Clean "v" prefix, if any
-	tagName := strings.TrimPrefix(releaseInfo.TagName, "v")
-	return tagName, nil
+	return nextPageURL(resp.Header.Get("Link")), nil
 }
 
 // getLatestVersion is a package-level variable that points to the actual implementation.
 // Tests can override this variable to mock the GitHub API interaction.
 var getLatestVersion = getLatestVersionGitHubImpl
+
+// getLatestRelease fetches the full latest release, for features that need more than the version.
+// Like getLatestVersion, tests can override it.
+var getLatestRelease = getLatestReleaseGitHubImpl
+
+// getLatestReleaseIncludingDrafts is used by 'check -include-drafts'. Tests can override it.
+var getLatestReleaseIncludingDrafts = getLatestReleaseIncludingDraftsGitHubImpl
+
+// getLatestMatchingVersion is used for apps with a release channel or ignored versions.
+// Tests can override it.
+var getLatestMatchingVersion = getLatestMatchingVersionGitHubImpl
+
+// getReleaseByTag is used by 'check -target'. Tests can override it.
+var getReleaseByTag = getReleaseByTagGitHubImpl
+
+// getCommitsBehind is used by 'check -commits-behind'. Tests can override it.
+var getCommitsBehind = getCommitsBehindGitHubImpl
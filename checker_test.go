@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetLatestVersionGitHubImpl(t *testing.T) { // Renamed test function to match target
@@ -25,7 +30,7 @@ func TestGetLatestVersionGitHubImpl(t *testing.T) { // Renamed test function to
 		}))
 		defer server.Close()
 
-		version, err := getLatestVersionGitHubImpl("owner/repo", server.URL) // Corrected function call
+		version, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL) // Corrected function call
 		if err != nil {
 			t.Fatalf("Expected no error, got: %v", err)
 		}
@@ -41,7 +46,7 @@ func TestGetLatestVersionGitHubImpl(t *testing.T) { // Renamed test function to
 		}))
 		defer server.Close()
 
-		version, err := getLatestVersionGitHubImpl("owner/repo", server.URL) // Corrected function call
+		version, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL) // Corrected function call
 		if err != nil {
 			t.Fatalf("Expected no error, got: %v", err)
 		}
@@ -57,7 +62,7 @@ func TestGetLatestVersionGitHubImpl(t *testing.T) { // Renamed test function to
 		}))
 		defer server.Close()
 
-		_, err := getLatestVersionGitHubImpl("owner/nonexistent_repo", server.URL) // Corrected function call
+		_, err := getLatestVersionGitHubImpl(context.Background(), "owner/nonexistent_repo", server.URL) // Corrected function call
 		if err == nil {
 			t.Fatal("Expected an error, got nil")
 		}
@@ -73,7 +78,7 @@ func TestGetLatestVersionGitHubImpl(t *testing.T) { // Renamed test function to
 		}))
 		defer server.Close()
 
-		_, err := getLatestVersionGitHubImpl("owner/repo", server.URL) // Corrected function call
+		_, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL) // Corrected function call
 		if err == nil {
 			t.Fatal("Expected an error, got nil")
 		}
@@ -89,7 +94,7 @@ func TestGetLatestVersionGitHubImpl(t *testing.T) { // Renamed test function to
 		}))
 		defer server.Close()
 
-		_, err := getLatestVersionGitHubImpl("owner/repo", server.URL) // Corrected function call
+		_, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL) // Corrected function call
 		if err == nil {
 			t.Fatal("Expected an error, got nil")
 		}
@@ -101,7 +106,7 @@ func TestGetLatestVersionGitHubImpl(t *testing.T) { // Renamed test function to
 	// Test case 6: Invalid appIdentifier format
 	t.Run("InvalidAppIdentifier", func(t *testing.T) {
 		// No server needed as this should be caught before HTTP request
-		_, err := getLatestVersionGitHubImpl("ownerrepo", "") // Corrected function call; No slash
+		_, err := getLatestVersionGitHubImpl(context.Background(), "ownerrepo", "") // Corrected function call; No slash
 		if err == nil {
 			t.Fatal("Expected an error for invalid appIdentifier, got nil")
 		}
@@ -118,7 +123,7 @@ func TestGetLatestVersionGitHubImpl(t *testing.T) { // Renamed test function to
 		serverURL := server.URL
 		server.Close()
 
-		_, err := getLatestVersionGitHubImpl("owner/repo", serverURL) // Corrected function call
+		_, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", serverURL) // Corrected function call
 		if err == nil {
 			t.Fatal("Expected a network error, got nil")
 		}
@@ -127,3 +132,772 @@ func TestGetLatestVersionGitHubImpl(t *testing.T) { // Renamed test function to
 		}
 	})
 }
+
+func TestGitHubAPIVersionHeader(t *testing.T) {
+	var gotVersion, gotAccept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotVersion = r.Header.Get("X-GitHub-Api-Version")
+		gotAccept = r.Header.Get("Accept")
+		fmt.Fprintln(w, `{"tag_name": "v1.0.0"}`)
+	}))
+	defer server.Close()
+
+	t.Run("Default", func(t *testing.T) {
+		if _, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if gotVersion != "2022-11-28" {
+			t.Errorf("Expected X-GitHub-Api-Version '2022-11-28', got: '%s'", gotVersion)
+		}
+		if gotAccept != "application/vnd.github.v3+json" {
+			t.Errorf("Expected Accept header to remain, got: '%s'", gotAccept)
+		}
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		originalAPIVersion := githubAPIVersion
+		githubAPIVersion = "2026-03-10"
+		defer func() { githubAPIVersion = originalAPIVersion }()
+
+		if _, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if gotVersion != "2026-03-10" {
+			t.Errorf("Expected overridden X-GitHub-Api-Version '2026-03-10', got: '%s'", gotVersion)
+		}
+	})
+}
+
+func TestGetLatestVersionRenamedRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		// GitHub redirects a renamed repository's requests to its id, not to its new name.
+		case "/repos/oldorg/repo/releases/latest":
+			http.Redirect(w, r, "/repositories/42/releases/latest", http.StatusMovedPermanently)
+		case "/repositories/42":
+			fmt.Fprintln(w, `{"id": 42, "full_name": "neworg/repo"}`)
+		case "/repositories/42/releases/latest", "/repos/neworg/repo/releases/latest":
+			fmt.Fprintln(w, `{"tag_name": "v2.0.0"}`)
+		default:
+			http.Error(w, "Not Found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	_, err := getLatestVersionGitHubImpl(context.Background(), "oldorg/repo", server.URL)
+	var moved *RepoMovedError
+	if !errors.As(err, &moved) {
+		t.Fatalf("Expected a RepoMovedError, got: %v", err)
+	}
+	if moved.From != "oldorg/repo" || moved.To != "neworg/repo" {
+		t.Errorf("Expected move from oldorg/repo to neworg/repo, got %s -> %s", moved.From, moved.To)
+	}
+	if err.Error() != "oldorg/repo now redirects to neworg/repo" {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	// The new name resolves normally.
+	version, err := getLatestVersionGitHubImpl(context.Background(), moved.To, server.URL)
+	if err != nil || version != "2.0.0" {
+		t.Errorf("Expected 2.0.0 for the new name, got '%s' (err: %v)", version, err)
+	}
+}
+
+func TestGetLatestVersionRenamedRepositoryByName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/oldorg/repo/releases/latest" {
+			http.Redirect(w, r, "/repos/neworg/repo/releases/latest", http.StatusMovedPermanently)
+			return
+		}
+		fmt.Fprintln(w, `{"tag_name": "v2.0.0"}`)
+	}))
+	defer server.Close()
+
+	_, err := getLatestVersionGitHubImpl(context.Background(), "oldorg/repo", server.URL)
+	var moved *RepoMovedError
+	if !errors.As(err, &moved) || moved.To != "neworg/repo" {
+		t.Fatalf("Expected a RepoMovedError to neworg/repo, got: %v", err)
+	}
+}
+
+func TestGetLatestVersionFollowsSameRepoRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/releases/latest":
+			http.Redirect(w, r, "/repositories/42/releases/latest", http.StatusMovedPermanently)
+		case "/repositories/42":
+			fmt.Fprintln(w, `{"id": 42, "full_name": "Owner/Repo"}`)
+		default:
+			fmt.Fprintln(w, `{"tag_name": "v1.5.0"}`)
+		}
+	}))
+	defer server.Close()
+
+	version, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL)
+	if err != nil || version != "1.5.0" {
+		t.Errorf("Expected redirect to the same repository to be followed, got '%s' (err: %v)", version, err)
+	}
+}
+
+func TestChannelTag(t *testing.T) {
+	tests := []struct {
+		channel, version, expected string
+	}{
+		{"", "1.2.0", "1.2.0"},
+		{"stable-", "1.3.0", "stable-1.3.0"},
+		{"stable-*", "1.3.0", "stable-1.3.0"},
+		{"nightly", "nightly", "nightly"},
+	}
+	for _, tt := range tests {
+		if got := channelTag(tt.channel, tt.version); got != tt.expected {
+			t.Errorf("channelTag(%q, %q) = %q, expected %q", tt.channel, tt.version, got, tt.expected)
+		}
+	}
+}
+
+func TestGetLatestReleaseGitHubImplAssets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"tag_name": "v1.1.0", "assets": [
+			{"name": "tool-linux-amd64.tar.gz", "browser_download_url": "https://example.com/tool-linux-amd64.tar.gz"},
+			{"name": "tool-darwin-arm64.tar.gz", "browser_download_url": "https://example.com/tool-darwin-arm64.tar.gz"}
+		]}`)
+	}))
+	defer server.Close()
+
+	release, err := getLatestReleaseGitHubImpl(context.Background(), "owner/repo", server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(release.Assets) != 2 {
+		t.Fatalf("Expected 2 assets, got %d", len(release.Assets))
+	}
+	if release.Assets[1].Name != "tool-darwin-arm64.tar.gz" || release.Assets[1].BrowserDownloadURL != "https://example.com/tool-darwin-arm64.tar.gz" {
+		t.Errorf("Unexpected second asset: %+v", release.Assets[1])
+	}
+}
+
+func TestGetLatestVersionErrorTypes(t *testing.T) {
+	newServer := func(status int, header map[string]string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for name, value := range header {
+				w.Header().Set(name, value)
+			}
+			w.WriteHeader(status)
+			fmt.Fprintln(w, `{"message": "nope"}`)
+		}))
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		server := newServer(http.StatusNotFound, nil)
+		defer server.Close()
+		_, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got: %v", err)
+		}
+		if errors.Is(err, ErrRateLimited) {
+			t.Errorf("A 404 must not match ErrRateLimited")
+		}
+		if err.Error() != "GitHub API error for owner/repo (status 404): nope" {
+			t.Errorf("Expected the message to be unchanged, got: %v", err)
+		}
+	})
+
+	t.Run("RateLimited", func(t *testing.T) {
+		for _, server := range []*httptest.Server{
+			newServer(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}),
+			newServer(http.StatusTooManyRequests, nil),
+		} {
+			_, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL)
+			server.Close()
+			if !errors.Is(err, ErrRateLimited) {
+				t.Errorf("Expected ErrRateLimited, got: %v", err)
+			}
+		}
+	})
+
+	t.Run("ForbiddenIsNotRateLimited", func(t *testing.T) {
+		server := newServer(http.StatusForbidden, nil)
+		defer server.Close()
+		_, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL)
+		if err == nil || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected a plain API error, got: %v", err)
+		}
+	})
+
+	t.Run("InvalidIdentifier", func(t *testing.T) {
+		_, err := getLatestVersionGitHubImpl(context.Background(), "ownerrepo", "")
+		if !errors.Is(err, ErrInvalidIdentifier) {
+			t.Errorf("Expected ErrInvalidIdentifier, got: %v", err)
+		}
+	})
+
+	t.Run("NetworkError", func(t *testing.T) {
+		server := newServer(http.StatusOK, nil)
+		serverURL := server.URL
+		server.Close()
+		_, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", serverURL)
+		var netErr *NetworkError
+		if !errors.As(err, &netErr) {
+			t.Fatalf("Expected a NetworkError, got: %v", err)
+		}
+		if netErr.Identifier != "owner/repo" || netErr.Err == nil {
+			t.Errorf("Expected the NetworkError to carry the identifier and cause, got: %+v", netErr)
+		}
+	})
+}
+
+func TestAPIBaseFlag(t *testing.T) {
+	useTestConfigFile(t)
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		fmt.Fprintln(w, `{"tag_name": "v3.0.0"}`)
+	}))
+	defer server.Close()
+
+	originalAPIBase := githubAPIBase
+	defer func() { githubAPIBase = originalAPIBase }()
+	githubAPIBase = server.URL + "/api/v3/"
+
+	result := checkApp("owner/repo", "2.0.0")
+	if result.status != statusUpdateAvailable || result.latestVersion != "3.0.0" {
+		t.Errorf("Expected the mock server's release, got %+v", result)
+	}
+	if gotPath != "/api/v3/repos/owner/repo/releases/latest" {
+		t.Errorf("Expected the request under the -api-base path, got %q", gotPath)
+	}
+
+	for raw, valid := range map[string]bool{
+		"https://github.example.com/api/v3": true,
+		"http://localhost:8080":             true,
+		"github.example.com/api/v3":         false,
+		"ftp://github.example.com":          false,
+		"/api/v3":                           false,
+		"https://":                          false,
+	} {
+		if err := validateAPIBase(raw); (err == nil) != valid {
+			t.Errorf("validateAPIBase(%q): expected valid=%v, got err=%v", raw, valid, err)
+		}
+	}
+}
+
+func TestGetLatestReleaseIncludingDrafts(t *testing.T) {
+	var gotPath, gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		fmt.Fprintln(w, `[
+			{"tag_name": "v2.1.0-rc1", "prerelease": true},
+			{"tag_name": "v2.0.0", "draft": true},
+			{"tag_name": "v1.9.0"},
+			{"tag_name": "v1.8.0"}
+		]`)
+	}))
+	defer server.Close()
+
+	release, err := getLatestReleaseIncludingDraftsGitHubImpl(context.Background(), "owner/repo", "", server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if release.TagName != "v2.0.0" || !release.Draft {
+		t.Errorf("Expected the v2.0.0 draft, got %+v", release)
+	}
+	if gotPath != "/repos/owner/repo/releases" || gotQuery != "per_page=30" {
+		t.Errorf("Expected the releases list endpoint, got %s?%s", gotPath, gotQuery)
+	}
+}
+
+func TestGetLatestReleaseIncludingDraftsNormalizesVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Tags and names disagree on which release is newest.
+		fmt.Fprintln(w, `[
+			{"tag_name": "v1.10.0", "name": "Release 2024.1"},
+			{"tag_name": "1.9.0", "name": "v2024.10", "draft": true},
+			{"tag_name": "build-7", "name": ""}
+		]`)
+	}))
+	defer server.Close()
+
+	release, err := getLatestReleaseIncludingDraftsGitHubImpl(context.Background(), "owner/repo", versionFieldTagName, server.URL)
+	if err != nil || release.TagName != "v1.10.0" {
+		t.Errorf("Expected v1.10.0 by normalized tag, got %+v (err: %v)", release, err)
+	}
+	release, err = getLatestReleaseIncludingDraftsGitHubImpl(context.Background(), "owner/repo", versionFieldName, server.URL)
+	if err != nil || release.TagName != "1.9.0" {
+		t.Errorf("Expected the draft named v2024.10 by version_field name, got %+v (err: %v)", release, err)
+	}
+
+	defer func() { keepVPrefix = false }()
+	keepVPrefix = true
+	release, err = getLatestReleaseIncludingDraftsGitHubImpl(context.Background(), "owner/repo", versionFieldTagName, server.URL)
+	if err != nil || release.TagName != "v1.10.0" {
+		t.Errorf("Expected v1.10.0 with -keep-v-prefix too, got %+v (err: %v)", release, err)
+	}
+}
+
+func TestCheckIncludeDrafts(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion, originalGetDrafts := getLatestVersion, getLatestReleaseIncludingDrafts
+	defer func() {
+		getLatestVersion, getLatestReleaseIncludingDrafts = originalGetLatestVersion, originalGetDrafts
+	}()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.9.0", nil
+	}
+	getLatestReleaseIncludingDrafts = func(ctx context.Context, appIdentifier, field, apiBaseURL string) (*GitHubReleaseInfo, error) {
+		return &GitHubReleaseInfo{TagName: "v2.0.0", Draft: true}, nil
+	}
+	if err := saveConfig(Config{"owner/repo": "1.9.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("owner/repo", checkOptions{}) }))
+	if !strings.Contains(output, "Current: 1.9.0, Latest: 1.9.0 (Up to date)") {
+		t.Errorf("Expected drafts to be ignored by default. Got:\n%s", output)
+	}
+
+	output = stripAnsiCodes(captureOutput(func() { handleCheckCmd("owner/repo", checkOptions{includeDrafts: true}) }))
+	if !strings.Contains(output, "Current: 1.9.0, Latest: 2.0.0 [draft] (Update Available!)") {
+		t.Errorf("Expected the draft to be reported and labeled. Got:\n%s", output)
+	}
+
+	output = captureOutput(func() { handleCheckCmd("owner/repo", checkOptions{includeDrafts: true, format: formatGitHubActions}) })
+	if output != "::warning ::owner/repo has an update: 1.9.0 -> 2.0.0 (draft release)\n" {
+		t.Errorf("Expected the annotation to mention the draft, got: %q", output)
+	}
+}
+
+func TestKeepVPrefix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"tag_name": "v1.2.3"}`)
+	}))
+	defer server.Close()
+	defer func() { keepVPrefix = false }()
+
+	for _, tt := range []struct {
+		keep     bool
+		expected string
+	}{
+		{keep: false, expected: "1.2.3"},
+		{keep: true, expected: "v1.2.3"},
+	} {
+		keepVPrefix = tt.keep
+		version, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL)
+		if err != nil {
+			t.Fatalf("keepVPrefix=%v: expected no error, got: %v", tt.keep, err)
+		}
+		if version != tt.expected {
+			t.Errorf("keepVPrefix=%v: expected version %q, got %q", tt.keep, tt.expected, version)
+		}
+	}
+}
+
+func TestListReleasesFollowsPages(t *testing.T) {
+	defer func() { maxReleasePages = defaultMaxReleasePages }()
+	requests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/releases?per_page=30&page=2>; rel="next", <%s/repos/owner/repo/releases?per_page=30&page=2>; rel="last"`, server.URL, server.URL))
+			fmt.Fprintln(w, `[{"tag_name": "v1.1.0"}, {"tag_name": "v1.0.0"}]`)
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/releases?per_page=30&page=1>; rel="prev"`, server.URL))
+			fmt.Fprintln(w, `[{"tag_name": "v0.9.0"}]`)
+		default:
+			t.Errorf("Unexpected page request: %s", r.URL)
+		}
+	}))
+	defer server.Close()
+
+	releases, err := listReleasesGitHub(context.Background(), "owner/repo", server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	var tags []string
+	for _, release := range releases {
+		tags = append(tags, release.TagName)
+	}
+	if strings.Join(tags, " ") != "v1.1.0 v1.0.0 v0.9.0" || requests != 2 {
+		t.Errorf("Expected the releases of both pages in 2 requests, got %v in %d", tags, requests)
+	}
+
+	requests = 0
+	maxReleasePages = 1
+	releases, err = listReleasesGitHub(context.Background(), "owner/repo", server.URL)
+	if err != nil || len(releases) != 2 || requests != 1 {
+		t.Errorf("Expected only the first page with a cap of 1, got %d releases in %d requests (%v)", len(releases), requests, err)
+	}
+}
+
+func TestValidateMaxReleasePages(t *testing.T) {
+	for pages, valid := range map[int]bool{1: true, defaultMaxReleasePages: true, 100: true, 0: false, -1: false} {
+		if err := validateMaxReleasePages(pages); (err == nil) != valid {
+			t.Errorf("validateMaxReleasePages(%d): expected valid=%v, got err=%v", pages, valid, err)
+		}
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link     string
+		expected string
+	}{
+		{`<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, "https://api.github.com/x?page=2"},
+		{`<https://api.github.com/x?page=1>; rel="first", <https://api.github.com/x?page=3>; rel="next"`, "https://api.github.com/x?page=3"},
+		{`<https://api.github.com/x?page=1>; rel="prev"`, ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := nextPageURL(tt.link); got != tt.expected {
+			t.Errorf("nextPageURL(%q) = %q, expected %q", tt.link, got, tt.expected)
+		}
+	}
+}
+
+func TestReleaseChannel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `[
+			{"tag_name": "nightly", "prerelease": true},
+			{"tag_name": "nightly-20240601", "prerelease": true},
+			{"tag_name": "stable-1.4.0", "draft": true},
+			{"tag_name": "stable-1.3.0"},
+			{"tag_name": "beta-2.0.0-rc1", "prerelease": true},
+			{"tag_name": "stable-1.2.10"},
+			{"tag_name": "v9.9.9"}
+		]`)
+	}))
+	defer server.Close()
+	originalAPIBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() {
+		githubAPIBase = originalAPIBase
+		appOptions = make(map[string]AppOptions)
+	}()
+
+	tests := []struct {
+		channel, expected string
+	}{
+		{"stable-", "1.3.0"},
+		{"stable-*", "1.3.0"},
+		{"beta-", "2.0.0-rc1"},
+		{"nightly-", "20240601"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			useTestConfigFile(t)
+			appOptions["owner/repo"] = AppOptions{Channel: tt.channel}
+			result := checkApp("owner/repo", "1.2.10")
+			if result.status == statusError || result.latestVersion != tt.expected {
+				t.Errorf("Expected latest %s in channel %s, got %+v", tt.expected, tt.channel, result)
+			}
+		})
+	}
+
+	t.Run("NoMatchingTags", func(t *testing.T) {
+		useTestConfigFile(t)
+		appOptions["owner/repo"] = AppOptions{Channel: "lts-"}
+		result := checkApp("owner/repo", "1.0.0")
+		if result.status != statusError || !errors.Is(result.err, ErrNotFound) {
+			t.Errorf("Expected a not-found error for an empty channel, got %+v", result)
+		}
+	})
+
+	t.Run("OnlyGitHub", func(t *testing.T) {
+		useTestConfigFile(t)
+		appOptions["gitlab:group/project"] = AppOptions{Channel: "stable-"}
+		result := checkApp("gitlab:group/project", "1.0.0")
+		if result.status != statusError || !strings.Contains(result.err.Error(), "only supported for GitHub") {
+			t.Errorf("Expected channels to be rejected for GitLab, got %+v", result)
+		}
+	})
+}
+
+func TestIgnoredVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `[
+			{"tag_name": "v1.4.0", "draft": true},
+			{"tag_name": "v1.3.1-rc1", "prerelease": true},
+			{"tag_name": "v1.3.0"},
+			{"tag_name": "v1.2.1"},
+			{"tag_name": "v1.2.0"}
+		]`)
+	}))
+	defer server.Close()
+	originalAPIBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() {
+		githubAPIBase = originalAPIBase
+		appOptions = make(map[string]AppOptions)
+	}()
+
+	path := useTestConfigFile(t)
+	content := "[\"owner/repo\"]\nversion = \"1.2.0\"\nignore = [\"v1.3.0\"]\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if ignore := appOptions["owner/repo"].Ignore; len(ignore) != 1 || ignore[0] != "v1.3.0" {
+		t.Fatalf("Expected the ignore list to be loaded, got %v", ignore)
+	}
+	result := checkApp("owner/repo", config["owner/repo"])
+	if result.status != statusUpdateAvailable || result.latestVersion != "1.2.1" {
+		t.Errorf("Expected the next-newest release 1.2.1 with 1.3.0 ignored, got %+v", result)
+	}
+
+	appOptions["owner/repo"] = AppOptions{Ignore: []string{"1.3.0", "1.2.1", "1.2.0"}}
+	result = checkApp("owner/repo", "1.2.0")
+	if result.status != statusError || !errors.Is(result.err, ErrNotFound) {
+		t.Errorf("Expected a not-found error with every release ignored, got %+v", result)
+	}
+}
+
+func TestChannelWithVersionField(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `[
+			{"tag_name": "stable-build-812", "name": "v2024.10"},
+			{"tag_name": "stable-build-799", "name": "v2024.9"},
+			{"tag_name": "beta-build-820", "name": "v2024.11", "prerelease": true},
+			{"tag_name": "stable-build-770", "name": ""}
+		]`)
+	}))
+	defer server.Close()
+	originalAPIBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() {
+		githubAPIBase = originalAPIBase
+		appOptions = make(map[string]AppOptions)
+	}()
+
+	useTestConfigFile(t)
+	appOptions["owner/repo"] = AppOptions{Channel: "stable-", VersionField: versionFieldName}
+	result := checkApp("owner/repo", "2024.9")
+	if result.status != statusUpdateAvailable || result.latestVersion != "2024.10" {
+		t.Errorf("Expected the name 2024.10 of the newest stable release, got %+v", result)
+	}
+
+	appOptions["owner/repo"] = AppOptions{Channel: "stable-", Ignore: []string{"v2024.10"}, VersionField: versionFieldName}
+	result = checkApp("owner/repo", "2024.9")
+	if result.status != statusUpToDate || result.latestVersion != "2024.9" {
+		t.Errorf("Expected the ignore list to match release names, got %+v", result)
+	}
+}
+
+func TestGetLatestVersionCancelled(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	start := time.Now()
+	_, err := getLatestVersionGitHubImpl(ctx, "owner/repo", server.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a context-cancelled error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("Expected the request to be aborted right away rather than retried, took %v", elapsed)
+	}
+}
+
+func TestPerAppTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/owner/slow/releases/latest" {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+		fmt.Fprintln(w, `{"tag_name": "v1.1.0"}`)
+	}))
+	defer server.Close()
+	originalAPIBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() {
+		githubAPIBase = originalAPIBase
+		appOptions = make(map[string]AppOptions)
+	}()
+
+	path := useTestConfigFile(t)
+	content := "\"owner/fast\" = \"1.0.0\"\n\n[\"owner/slow\"]\nversion = \"1.0.0\"\ntimeout = \"20ms\"\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	start := time.Now()
+	var errOutput string
+	output := stripAnsiCodes(captureOutput(func() {
+		errOutput = stripAnsiCodes(captureStderr(t, func() { handleCheckCmd("", checkOptions{}) }))
+	}))
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Errorf("Expected the slow app to be given up on after its timeout, took %v", elapsed)
+	}
+	if !strings.Contains(output, "Checking owner/fast...  Current: 1.0.0, Latest: 1.1.0") {
+		t.Errorf("Expected the fast app to be checked as usual, got:\n%s", output)
+	}
+	if !strings.Contains(errOutput, "Failed to check owner/slow: lookup timed out after 20ms (the app's timeout)") {
+		t.Errorf("Expected the slow app to time out, got:\n%s", errOutput)
+	}
+
+	// A timeout longer than the client's own replaces it rather than being cut short by it.
+	originalClient := httpClient
+	defer func() { httpClient = originalClient }()
+	httpClient = newHTTPClient(newTransport(nil))
+	httpClient.Timeout = 20 * time.Millisecond
+	appOptions["owner/slow"] = AppOptions{Timeout: "5s"}
+	if result := checkApp("owner/slow", "1.0.0"); result.status != statusUpdateAvailable {
+		t.Errorf("Expected the app's longer timeout to apply, got %+v", result)
+	}
+}
+
+func TestGetCommitsBehindGitHubImpl(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path != "/repos/owner/repo/compare/v1.0.0...v1.2.0" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"message": "Not Found"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status": "ahead", "ahead_by": 42, "behind_by": 0}`)
+	}))
+	defer server.Close()
+
+	behind, err := getCommitsBehindGitHubImpl(context.Background(), "owner/repo", "1.0.0", "1.2.0", server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if behind != 42 {
+		t.Errorf("Expected 42 commits behind, got %d", behind)
+	}
+	expected := []string{"/repos/owner/repo/compare/1.0.0...1.2.0", "/repos/owner/repo/compare/v1.0.0...v1.2.0"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected the bare tags and then the v-prefixed ones, got %v", paths)
+	}
+
+	if _, err := getCommitsBehindGitHubImpl(context.Background(), "owner/repo", "0.9.0", "1.2.0", server.URL); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an untagged version, got %v", err)
+	}
+}
+
+func TestCheckCommitsBehind(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion, originalGetCommitsBehind := getLatestVersion, getCommitsBehind
+	defer func() { getLatestVersion, getCommitsBehind = originalGetLatestVersion, originalGetCommitsBehind }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.2.0", nil
+	}
+	var compared []string
+	getCommitsBehind = func(ctx context.Context, appIdentifier, base, head, apiBaseURL string) (int, error) {
+		compared = append(compared, appIdentifier+" "+base+"..."+head)
+		if appIdentifier == "owner/untagged" {
+			return 0, tagError(ErrNotFound, "GitHub API error for %s (status 404): Not Found", appIdentifier)
+		}
+		return 7, nil
+	}
+	if err := saveConfig(Config{"owner/repo": "1.0.0", "owner/current": "1.2.0", "owner/untagged": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{}) }))
+	if len(compared) != 0 || strings.Contains(output, "behind") {
+		t.Errorf("Expected no comparison without -commits-behind, got %v and:\n%s", compared, output)
+	}
+
+	var logs string
+	output = stripAnsiCodes(captureOutput(func() {
+		logs = captureLog(func() { handleCheckCmd("", checkOptions{commitsBehind: true}) })
+	}))
+	if !reflect.DeepEqual(compared, []string{"owner/repo 1.0.0...1.2.0", "owner/untagged 1.0.0...1.2.0"}) {
+		t.Errorf("Expected only apps with an update to be compared, got %v", compared)
+	}
+	if !strings.Contains(output, "Current: 1.0.0, Latest: 1.2.0 (Update Available!)\n    7 commits behind\n") {
+		t.Errorf("Expected the commit count under the update, got:\n%s", output)
+	}
+	if strings.Count(output, "behind") != 1 {
+		t.Errorf("Expected no count for a failed comparison, got:\n%s", output)
+	}
+	if !strings.Contains(logs, "Warning: Could not count commits behind for owner/untagged") {
+		t.Errorf("Expected a warning for the failed comparison, got: %q", logs)
+	}
+
+	output = captureOutput(func() { handleCheckCmd("owner/repo", checkOptions{commitsBehind: true, format: formatNDJSON}) })
+	if !strings.Contains(output, `"commits_behind":7`) {
+		t.Errorf("Expected the count in ndjson output, got: %s", output)
+	}
+}
+
+func TestVersionField(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/dated/releases/latest", "/repos/owner/named/releases/latest":
+			fmt.Fprintln(w, `{"tag_name": "2024.05.01", "name": "v1.4.0"}`)
+		case "/repos/owner/unnamed/releases/latest":
+			fmt.Fprintln(w, `{"tag_name": "2024.05.01", "name": ""}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	originalAPIBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() {
+		githubAPIBase = originalAPIBase
+		appOptions = make(map[string]AppOptions)
+	}()
+
+	path := useTestConfigFile(t)
+	content := `"owner/dated" = "2024.04.01"
+
+["owner/named"]
+version = "1.3.0"
+version_field = "name"
+
+["owner/unnamed"]
+version = "1.3.0"
+version_field = "name"
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if field := appOptions["owner/named"].VersionField; field != versionFieldName {
+		t.Fatalf("Expected version_field to be read from the config, got %q", field)
+	}
+
+	if result := checkApp("owner/dated", config["owner/dated"]); result.latestVersion != "2024.05.01" {
+		t.Errorf("Expected the tag to be used by default, got %+v", result)
+	}
+	result := checkApp("owner/named", config["owner/named"])
+	if result.latestVersion != "1.4.0" || result.status != statusUpdateAvailable {
+		t.Errorf("Expected the release name, without its v prefix, to be the latest version, got %+v", result)
+	}
+	result = checkApp("owner/unnamed", config["owner/unnamed"])
+	if result.status != statusError || result.err == nil || !strings.Contains(result.err.Error(), "has no name") {
+		t.Errorf("Expected a release without a name to fail, got %+v", result)
+	}
+
+	// The option is written back when the config is saved.
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), `version_field = "name"`) {
+		t.Errorf("Expected version_field to be saved, got %q (%v)", data, err)
+	}
+}
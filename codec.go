@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configCodec encodes and decodes the config file in one format. Whatever the format,
+// decode yields the same generic document decodeApps understands: apps as strings or
+// tables, plus the [_meta] table.
+type configCodec struct {
+	name      string
+	encode    func(w io.Writer, v interface{}) error
+	unmarshal func(data []byte) (map[string]interface{}, error)
+}
+
+// tomlCodec is the default format, used for any extension other than YAML's.
+var tomlCodec = configCodec{
+	name: "TOML",
+	encode: func(w io.Writer, v interface{}) error {
+		return toml.NewEncoder(w).Encode(v)
+	},
+	unmarshal: func(data []byte) (map[string]interface{}, error) {
+		var raw map[string]interface{}
+		err := toml.Unmarshal(data, &raw)
+		return raw, err
+	},
+}
+
+// yamlCodec is used for .yaml and .yml files, e.g. to check the tracked set into a repository.
+var yamlCodec = configCodec{
+	name: "YAML",
+	encode: func(w io.Writer, v interface{}) error {
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	},
+	unmarshal: func(data []byte) (map[string]interface{}, error) {
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		normalizeYAML(raw)
+		return raw, nil
+	},
+}
+
+// codecFor returns the codec for path, chosen by its extension.
+func codecFor(path string) configCodec {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yamlCodec
+	}
+	return tomlCodec
+}
+
+// normalizeYAML converts the integers YAML decodes as int to the int64 TOML produces, in place,
+// so both formats decode to the same document.
+func normalizeYAML(table map[string]interface{}) {
+	for key, value := range table {
+		switch value := value.(type) {
+		case int:
+			table[key] = int64(value)
+		case map[string]interface{}:
+			normalizeYAML(value)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigCodecsRoundTrip(t *testing.T) {
+	defer func() { appOptions = make(map[string]AppOptions) }()
+	config := Config{"owner/plain": "1.0.0", "owner/tool": "2.1.0", "gitlab:group/project": "0.3.0"}
+	options := map[string]AppOptions{"owner/tool": {VersionCommand: "tool --version", CompareMode: compareExact, CheckEvery: "7d"}}
+
+	loaded := make(map[string]Config)
+	for _, name := range []string{"versions.toml", "versions.yaml", "versions.yml"} {
+		t.Run(name, func(t *testing.T) {
+			originalConfigFile := configFile
+			defer func() { configFile = originalConfigFile }()
+			configFile = filepath.Join(t.TempDir(), name)
+
+			appOptions = options
+			if err := saveConfig(config); err != nil {
+				t.Fatalf("Failed to save config: %v", err)
+			}
+			var got Config
+			logs := captureLog(func() {
+				var err error
+				if got, err = loadConfig(); err != nil {
+					t.Fatalf("Failed to load config: %v", err)
+				}
+			})
+			if !reflect.DeepEqual(got, config) {
+				t.Errorf("Expected %v after a round trip, got %v", config, got)
+			}
+			if !reflect.DeepEqual(appOptions, options) {
+				t.Errorf("Expected options %v after a round trip, got %v", options, appOptions)
+			}
+			if strings.Contains(logs, "edited by hand") {
+				t.Errorf("Expected the checksum to match after a round trip, got log: %q", logs)
+			}
+			loaded[name] = got
+		})
+	}
+	if !reflect.DeepEqual(loaded["versions.toml"], loaded["versions.yaml"]) {
+		t.Errorf("Expected both codecs to load the same config, got %v and %v", loaded["versions.toml"], loaded["versions.yaml"])
+	}
+}
+
+func TestYAMLConfigWrittenByHand(t *testing.T) {
+	defer func() { appOptions = make(map[string]AppOptions) }()
+	originalConfigFile := configFile
+	defer func() { configFile = originalConfigFile }()
+	configFile = filepath.Join(t.TempDir(), "versions.yml")
+	content := `owner/plain: 1.0.0
+owner/tool:
+  version: 2.1.0
+  check_every: 12h
+_meta:
+  schema_version: 99
+`
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	var config Config
+	logs := captureLog(func() {
+		var err error
+		if config, err = loadConfig(); err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+	})
+	if config["owner/plain"] != "1.0.0" || config["owner/tool"] != "2.1.0" || appOptions["owner/tool"].CheckEvery != "12h" {
+		t.Errorf("Expected the hand-written YAML to load, got %v with options %v", config, appOptions)
+	}
+	if !strings.Contains(logs, "uses schema version 99") {
+		t.Errorf("Expected the YAML meta table to be read, got log: %q", logs)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+// bumpDowngrade labels a version change in 'compare' that goes to an older version.
+const bumpDowngrade = "downgrade"
+
+// versionChange is an app whose version differs between the two files 'compare' is given.
+type versionChange struct {
+	appName  string
+	from, to string
+	bump     string // The bumpSize of the change, or bumpDowngrade.
+}
+
+// configDiff is what changed in the tracked applications between two configs.
+type configDiff struct {
+	added   []string // Apps only in the new config, sorted.
+	removed []string // Apps only in the old config, sorted.
+	changed []versionChange
+}
+
+// diffConfigs compares the old and new configs. Changes are in app name order; a version change
+// is sized by semver like 'check -min-bump' does, or labeled a downgrade if the version went back.
+func diffConfigs(oldConfig, newConfig Config) configDiff {
+	var diff configDiff
+	for _, appName := range sortedAppNames(oldConfig) {
+		if _, ok := newConfig[appName]; !ok {
+			diff.removed = append(diff.removed, appName)
+		}
+	}
+	for _, appName := range sortedAppNames(newConfig) {
+		oldVersion, ok := oldConfig[appName]
+		newVersion := newConfig[appName]
+		switch {
+		case !ok:
+			diff.added = append(diff.added, appName)
+		case oldVersion != newVersion:
+			change := versionChange{appName: appName, from: oldVersion, to: newVersion, bump: bumpSize(oldVersion, newVersion)}
+			if compareVersions(newVersion, oldVersion) < 0 {
+				change.bump = bumpDowngrade
+			}
+			diff.changed = append(diff.changed, change)
+		}
+	}
+	return diff
+}
+
+// handleCompareCmd prints which applications were added, removed or changed version between two
+// config files, such as exports before and after a change. Nothing is looked up. It returns the
+// process exit code.
+func handleCompareCmd(oldPath, newPath string) int {
+	oldConfig, err := readAppsFile(oldPath)
+	if err != nil {
+		PrintError("%v", err)
+		return 1
+	}
+	newConfig, err := readAppsFile(newPath)
+	if err != nil {
+		PrintError("%v", err)
+		return 1
+	}
+
+	diff := diffConfigs(oldConfig, newConfig)
+	if len(diff.added)+len(diff.removed)+len(diff.changed) == 0 {
+		PrintInfo("No differences; both files track the same %s.", pluralize(len(newConfig), "application", "applications"))
+		return 0
+	}
+	if len(diff.added) > 0 {
+		PrintHeader("Added (%d)", len(diff.added))
+		for _, appName := range diff.added {
+			PrintMessage("  + %s %s", Colorize(appName, colorGreenFg), Colorize(newConfig[appName], colorCyanFg))
+		}
+	}
+	if len(diff.removed) > 0 {
+		PrintHeader("Removed (%d)", len(diff.removed))
+		for _, appName := range diff.removed {
+			PrintMessage("  - %s %s", Colorize(appName, colorRedFg), Colorize(oldConfig[appName], colorCyanFg))
+		}
+	}
+	if len(diff.changed) > 0 {
+		PrintHeader("Changed (%d)", len(diff.changed))
+		for _, change := range diff.changed {
+			from, to := versionDiff(change.from, change.to, colorMagentaFg, colorCyanFg)
+			PrintMessage("  ~ %s %s -> %s (%s)", Colorize(change.appName, colorYellowFg), from, to, change.bump)
+		}
+	}
+	return 0
+}
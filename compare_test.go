@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHandleCompareCmd(t *testing.T) {
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "old.toml"), filepath.Join(dir, "new.toml")
+	writeTestFile(t, oldPath, `"owner/kept" = "1.0.0"
+"owner/patch" = "1.2.3"
+"owner/minor" = "1.2.3"
+"owner/major" = "1.2.3"
+"owner/back" = "2.0.0"
+"owner/gone" = "0.9.0"
+`)
+	writeTestFile(t, newPath, `"owner/kept" = "1.0.0"
+"owner/patch" = "1.2.4"
+"owner/minor" = "1.3.0"
+"owner/back" = "1.9.0"
+"owner/new" = "0.1.0"
+
+["owner/major"]
+version = "2.0.0"
+note = "tables are read too"
+`)
+
+	oldConfig, _ := readAppsFile(oldPath)
+	newConfig, _ := readAppsFile(newPath)
+	diff := diffConfigs(oldConfig, newConfig)
+	if !reflect.DeepEqual(diff.added, []string{"owner/new"}) || !reflect.DeepEqual(diff.removed, []string{"owner/gone"}) {
+		t.Errorf("Expected owner/new added and owner/gone removed, got %+v", diff)
+	}
+	expectedChanged := []versionChange{
+		{appName: "owner/back", from: "2.0.0", to: "1.9.0", bump: bumpDowngrade},
+		{appName: "owner/major", from: "1.2.3", to: "2.0.0", bump: bumpMajor},
+		{appName: "owner/minor", from: "1.2.3", to: "1.3.0", bump: bumpMinor},
+		{appName: "owner/patch", from: "1.2.3", to: "1.2.4", bump: bumpPatch},
+	}
+	if !reflect.DeepEqual(diff.changed, expectedChanged) {
+		t.Errorf("Expected changes %+v, got %+v", expectedChanged, diff.changed)
+	}
+
+	var exitCode int
+	output := stripAnsiCodes(captureOutput(func() { exitCode = handleCompareCmd(oldPath, newPath) }))
+	expected := `== Added (1) ==
+  + owner/new 0.1.0
+== Removed (1) ==
+  - owner/gone 0.9.0
+== Changed (4) ==
+  ~ owner/back 2.0.0 -> 1.9.0 (downgrade)
+  ~ owner/major 1.2.3 -> 2.0.0 (major)
+  ~ owner/minor 1.2.3 -> 1.3.0 (minor)
+  ~ owner/patch 1.2.3 -> 1.2.4 (patch)
+`
+	if output != expected || exitCode != 0 {
+		t.Errorf("Expected exit code 0 and:\n%s\ngot %d and:\n%s", expected, exitCode, output)
+	}
+
+	output = stripAnsiCodes(captureOutput(func() { handleCompareCmd(oldPath, oldPath) }))
+	if !strings.Contains(output, "No differences; both files track the same 6 applications.") {
+		t.Errorf("Expected no differences comparing a file with itself, got:\n%s", output)
+	}
+
+	errOutput := stripAnsiCodes(captureStderr(t, func() { exitCode = handleCompareCmd(oldPath, filepath.Join(dir, "missing.toml")) }))
+	if exitCode != 1 || !strings.Contains(errOutput, "could not read") {
+		t.Errorf("Expected exit code 1 and a read error for a missing file, got %d and %q", exitCode, errOutput)
+	}
+}
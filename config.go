@@ -1,17 +1,134 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
-
-	"github.com/BurntSushi/toml"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Config stores application names as keys and their versions as values.
 type Config map[string]string
 
+// canonicalKey returns the form of appName used to decide whether two entries refer to the same
+// repository: a prefix naming the default source (github: unless configured otherwise) is dropped
+// and, as repository names are case-insensitive, the result is lowercased.
+func canonicalKey(appName string) string {
+	if prefix, identifier, ok := strings.Cut(appName, ":"); ok && strings.ToLower(prefix) == defaultSourceName() {
+		appName = identifier
+	}
+	return strings.ToLower(appName)
+}
+
+// findDuplicates groups application names that share a canonical key.
+// Only groups with more than one member are returned; each group is sorted.
+func findDuplicates(config Config) [][]string {
+	groups := make(map[string][]string, len(config))
+	for appName := range config {
+		key := canonicalKey(appName)
+		groups[key] = append(groups[key], appName)
+	}
+
+	var duplicates [][]string
+	for _, names := range groups {
+		if len(names) > 1 {
+			sort.Strings(names)
+			duplicates = append(duplicates, names)
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i][0] < duplicates[j][0] })
+	return duplicates
+}
+
+const (
+	// configSchemaVersion is the config format version written by this binary.
+	configSchemaVersion = 1
+	// metaTableKey is the reserved table holding format metadata. It is never treated as an application.
+	metaTableKey = "_meta"
+)
+
+// The config may list private repositories, so it is only readable by its owner.
+const (
+	configFilePerm os.FileMode = 0600
+	configDirPerm  os.FileMode = 0700
+)
+
+// configMeta is the content of the [_meta] table.
+type configMeta struct {
+	SchemaVersion int       `toml:"schema_version" yaml:"schema_version"`
+	SavedAt       time.Time `toml:"saved_at" yaml:"saved_at"`
+	Checksum      string    `toml:"checksum" yaml:"checksum"`
+	DefaultSource string    `toml:"default_source,omitempty" yaml:"default_source,omitempty"`
+}
+
+// AppOptions holds optional per-application settings. An application with options is
+// written as a table instead of a plain version string:
+//
+//	["owner/tool"]
+//	version = "1.2.0"
+//	version_command = "tool --version"
+type AppOptions struct {
+	// VersionCommand is run by 'check -run-version-commands' to find the installed version.
+	VersionCommand string `toml:"version_command,omitempty" yaml:"version_command,omitempty"`
+	// VersionRegex extracts the version from VersionCommand's output. If it has a capture
+	// group, the first group is used; otherwise the whole match. Defaults to defaultVersionRegex.
+	VersionRegex string `toml:"version_regex,omitempty" yaml:"version_regex,omitempty"`
+	// CompareMode overrides 'check -compare-mode' for this app: semver or exact.
+	CompareMode string `toml:"compare_mode,omitempty" yaml:"compare_mode,omitempty"`
+	// CheckEvery is how often 'check' (of all apps) looks at this app, e.g. "12h" or "7d".
+	// Apps checked more recently are skipped; checking the app by name always runs.
+	CheckEvery string `toml:"check_every,omitempty" yaml:"check_every,omitempty"`
+	// Alias is a friendlier name shown in output, which commands also accept in place of the app name.
+	Alias string `toml:"alias,omitempty" yaml:"alias,omitempty"`
+	// Sources lists where to look up the latest version, in order, e.g. ["github:owner/tool",
+	// "brew:tool"]. The first that answers is used. Without it, the app name is the only source.
+	Sources []string `toml:"sources,omitempty" yaml:"sources,omitempty"`
+	// Channel restricts GitHub releases to tags starting with this prefix, e.g. "stable-" to
+	// follow stable-1.2.3 and ignore nightly tags. The prefix is removed to get the version.
+	Channel string `toml:"channel,omitempty" yaml:"channel,omitempty"`
+	// Ignore lists versions never to report as the latest, e.g. a broken release; the newest
+	// version not listed is used instead. Only supported for GitHub releases.
+	Ignore []string `toml:"ignore,omitempty" yaml:"ignore,omitempty"`
+	// Note is free text on why the app is tracked, set with 'add -note' and shown by 'list -notes'.
+	Note string `toml:"note,omitempty" yaml:"note,omitempty"`
+	// Timeout bounds looking up this app's latest version, e.g. "5s" for a slow mirror that
+	// shouldn't hold up the rest, or "2m" for one that needs longer than requests usually get.
+	Timeout string `toml:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// VersionField is the GitHub release field the version is read from: "tag_name" (the default)
+	// or "name", for projects whose tags are e.g. dates while their release names are versions.
+	VersionField string `toml:"version_field,omitempty" yaml:"version_field,omitempty"`
+	// Monotonic makes 'add' refuse to record a version lower than the recorded one, unless
+	// forced, so a shared config isn't rolled back by accident. 'add -monotonic' does so for every app.
+	Monotonic bool `toml:"monotonic,omitempty" yaml:"monotonic,omitempty"`
+}
+
+// isZero reports whether no option is set, so the app can be written as a plain version string.
+func (o AppOptions) isZero() bool {
+	return o.VersionCommand == "" && o.VersionRegex == "" && o.CompareMode == "" &&
+		o.CheckEvery == "" && o.Alias == "" && len(o.Sources) == 0 && o.Channel == "" && len(o.Ignore) == 0 && o.Note == "" &&
+		o.Timeout == "" && o.VersionField == "" && !o.Monotonic
+}
+
+// appTable is how an application with options is stored in the config file.
+type appTable struct {
+	Version    string `toml:"version" yaml:"version"`
+	AppOptions `yaml:",inline"`
+}
+
+// appOptions holds the options of the applications in the last loaded config, by app name.
+// Applications without options have no entry. saveConfig writes them back out.
+var appOptions = make(map[string]AppOptions)
+
 var configFile string
 
 func init() {
@@ -24,55 +141,380 @@ func init() {
 	}
 }
 
-// loadConfig loads the configuration from the configFile.
-// If the file doesn't exist, it returns an empty Config.
+// loadConfig loads the configuration from the configFile, merged with any overlayConfigFiles.
+// If the file doesn't exist, it returns an empty Config, or just the overlays.
 func loadConfig() (Config, error) {
-	config := make(Config)
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
+	configDefaultSource = ""
+	configSettings = nil
+	appOptions = make(map[string]AppOptions)
+	unexpandedValues = make(map[string]unexpandedValue)
+	info, err := os.Stat(configFile)
+	if os.IsNotExist(err) && firstRunWizardPending {
+		maybeRunFirstRunWizard()
+		info, err = os.Stat(configFile)
+	}
+	if os.IsNotExist(err) {
 		// This log message is for debug/startup; user feedback is handled by command handlers.
 		log.Printf("Info: Config file '%s' not found. A new one will be created upon adding an application.", configFile)
-		return config, nil // Return empty config, it will be saved on first 'add'
+		config := make(Config) // Return empty config, it will be saved on first 'add'
+		if err := mergeOverlayConfigs(config); err != nil {
+			return nil, err
+		}
+		expandConfigValues(config)
+		return config, nil
+	}
+	if err == nil {
+		checkConfigPermissions(info)
 	}
 
-	// Read the file content
-	data, err := os.ReadFile(configFile)
+	raw, err := readConfigDocument(configFile)
 	if err != nil {
-		// Log the error for debugging, but return a user-friendly one.
-		log.Printf("Debug: Error reading config file %s: %v", configFile, err)
-		return nil, fmt.Errorf("could not read config file '%s': %w", configFile, err)
+		return nil, err
 	}
-
-	// Decode the TOML data
-	if err := toml.Unmarshal(data, &config); err != nil {
-		// Log the error for debugging.
-		log.Printf("Debug: Error unmarshalling TOML from %s: %v", configFile, err)
-		return nil, fmt.Errorf("could not parse config file '%s' (TOML format error): %w", configFile, err)
+	config, options, meta, err := decodeApps(raw, configFile)
+	if err != nil {
+		return nil, err
+	}
+	configSettings, _ = raw[settingsTableKey].(map[string]interface{})
+	appOptions = options
+	checkConfigMeta(meta, config, options)
+	if meta != nil {
+		configDefaultSource = meta.DefaultSource
 	}
+	if err := mergeOverlayConfigs(config); err != nil {
+		return nil, err
+	}
+	expandConfigValues(config)
+	checkEmptyEntries(config)
 
 	// log.Printf("%sConfig loaded successfully from %s%s\n", colorGreen, configFile, colorReset) // Less verbose, success is implicit.
 	return config, nil
 }
 
-// saveConfig saves the configuration to the configFile.
-func saveConfig(config Config) error {
-	// Marshal the config map to TOML []byte
-	data, err := toml.Marshal(config)
+// readConfigDocument reads and decodes the config file at path, in the format its extension selects.
+func readConfigDocument(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Log the error for debugging, but return a user-friendly one.
+		log.Printf("Debug: Error reading config file %s: %v", path, err)
+		return nil, fmt.Errorf("could not read config file '%s': %w", path, err)
+	}
+	codec := codecFor(path)
+	raw, err := codec.unmarshal(data)
 	if err != nil {
-		log.Printf("Debug: Error marshalling config to TOML: %v", err)
+		// Log the error for debugging.
+		log.Printf("Debug: Error unmarshalling %s from %s: %v", codec.name, path, err)
+		return nil, fmt.Errorf("could not parse config file '%s' (%s format error): %w", path, codec.name, err)
+	}
+	return raw, nil
+}
+
+// overlayConfigFiles are the config files given after the first of several -config flags.
+// loadConfig merges them over configFile in order. While there are any, the config can't be
+// saved, as there'd be no telling which file the changes belong in.
+var overlayConfigFiles []string
+
+// configPaths is the value of the global -config flag, which may be repeated.
+type configPaths []string
+
+func (p *configPaths) String() string { return strings.Join(*p, ", ") }
+
+func (p *configPaths) Set(path string) error {
+	*p = append(*p, path)
+	return nil
+}
+
+// errSeveralConfigFiles is returned by commands that would change the config while several
+// files are merged.
+var errSeveralConfigFiles = errors.New("several config files are merged; give exactly one -config to choose the file to change")
+
+// requireSingleConfig returns errSeveralConfigFiles if overlayConfigFiles are merged into the config.
+func requireSingleConfig() error {
+	if len(overlayConfigFiles) > 0 {
+		return errSeveralConfigFiles
+	}
+	return nil
+}
+
+// mergeOverlayConfigs adds the apps of each of overlayConfigFiles to config, in order, so a
+// later file's version and options of an app replace an earlier one's. Only the first file's
+// [settings] and [_meta] apply.
+func mergeOverlayConfigs(config Config) error {
+	for _, path := range overlayConfigFiles {
+		raw, err := readConfigDocument(path)
+		if err != nil {
+			return err
+		}
+		overlay, options, _, err := decodeApps(raw, path)
+		if err != nil {
+			return err
+		}
+		for appName, version := range overlay {
+			config[appName] = version
+			if opts, ok := options[appName]; ok {
+				appOptions[appName] = opts
+			} else {
+				delete(appOptions, appName)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeApps splits a decoded TOML document into its applications, their options and the
+// [_meta] table. path is only used in error messages.
+func decodeApps(raw map[string]interface{}, path string) (Config, map[string]AppOptions, *configMeta, error) {
+	config := make(Config, len(raw))
+	options := make(map[string]AppOptions)
+	var meta *configMeta
+	for key, value := range raw {
+		if key == metaTableKey {
+			meta = decodeConfigMeta(value)
+			continue
+		}
+		if key == settingsTableKey {
+			continue // Read by readSettingsFile and loadConfig.
+		}
+		switch value := value.(type) {
+		case string:
+			config[key] = value
+		case map[string]interface{}:
+			version, ok := value["version"].(string)
+			if !ok {
+				return nil, nil, nil, fmt.Errorf("could not parse config file '%s': table for '%s' needs a string 'version'", path, key)
+			}
+			config[key] = version
+			if opts := decodeAppOptions(value); !opts.isZero() {
+				options[key] = opts
+			}
+		default:
+			return nil, nil, nil, fmt.Errorf("could not parse config file '%s': version for '%s' must be a string", path, key)
+		}
+	}
+	return config, options, meta, nil
+}
+
+// decodeAppOptions reads the options from an application's table. Like the meta table,
+// unknown or malformed fields are ignored.
+func decodeAppOptions(table map[string]interface{}) AppOptions {
+	var opts AppOptions
+	if v, ok := table["version_command"].(string); ok {
+		opts.VersionCommand = v
+	}
+	if v, ok := table["version_regex"].(string); ok {
+		opts.VersionRegex = v
+	}
+	if v, ok := table["compare_mode"].(string); ok {
+		opts.CompareMode = v
+	}
+	if v, ok := table["check_every"].(string); ok {
+		opts.CheckEvery = v
+	}
+	if v, ok := table["alias"].(string); ok {
+		opts.Alias = v
+	}
+	if v, ok := table["channel"].(string); ok {
+		opts.Channel = v
+	}
+	if v, ok := table["note"].(string); ok {
+		opts.Note = v
+	}
+	if v, ok := table["timeout"].(string); ok {
+		opts.Timeout = v
+	}
+	if v, ok := table["version_field"].(string); ok {
+		opts.VersionField = v
+	}
+	if v, ok := table["monotonic"].(bool); ok {
+		opts.Monotonic = v
+	}
+	if v, ok := table["sources"].([]interface{}); ok {
+		for _, item := range v {
+			if source, ok := item.(string); ok {
+				opts.Sources = append(opts.Sources, source)
+			}
+		}
+	}
+	if v, ok := table["ignore"].([]interface{}); ok {
+		for _, item := range v {
+			if version, ok := item.(string); ok {
+				opts.Ignore = append(opts.Ignore, version)
+			}
+		}
+	}
+	return opts
+}
+
+// resolveAppName returns the tracked app that name refers to: name itself if it is tracked,
+// otherwise the app whose alias it is. If neither matches, name is returned unchanged.
+func resolveAppName(config Config, name string) string {
+	if _, exists := config[name]; exists {
+		return name
+	}
+	for appName := range config {
+		if alias := appOptions[appName].Alias; alias != "" && alias == name {
+			return appName
+		}
+	}
+	return name
+}
+
+// displayName returns how appName is shown to people: its alias followed by the app name,
+// e.g. "bat (sharkdp/bat)", or just the app name if it has no alias.
+func displayName(appName string) string {
+	if alias := appOptions[appName].Alias; alias != "" {
+		return fmt.Sprintf("%s (%s)", alias, appName)
+	}
+	return appName
+}
+
+// readAppsFile reads the applications from a file in the config format, such as one
+// written by hand for 'import'. Like the config, it may be TOML or YAML. Its [_meta] table,
+// if any, is ignored.
+func readAppsFile(path string) (Config, error) {
+	config, _, err := readAppsFileWithOptions(path)
+	return config, err
+}
+
+// readAppsFileWithOptions is like readAppsFile, but also returns the options of the apps
+// given as tables.
+func readAppsFileWithOptions(path string) (Config, map[string]AppOptions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read '%s': %w", path, err)
+	}
+	raw, err := codecFor(path).unmarshal(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not parse '%s': %w", path, err)
+	}
+	config, options, _, err := decodeApps(raw, path)
+	return config, options, err
+}
+
+// backupConfig copies the config file to a new timestamped backup next to it (see
+// configBackupFile), so a destructive change can be undone with 'rollback'. It returns the
+// backup's path, or "" if there was no config file to back up.
+func backupConfig() (string, error) {
+	if err := requireSingleConfig(); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not read config file '%s' for backup: %w", configFile, err)
+	}
+	backupPath := configBackupFile(time.Now())
+	if err := writeFileAtomic(backupPath, data, configFilePerm); err != nil {
+		return "", fmt.Errorf("could not write backup '%s': %w", backupPath, err)
+	}
+	return backupPath, nil
+}
+
+// backupTimeLayout timestamps backup file names; it sorts in time order as a string.
+const backupTimeLayout = "20060102-150405.000"
+
+// configBackupFile is the backup backupConfig makes at t, e.g. versions.toml.20240102-150405.000.bak.
+func configBackupFile(t time.Time) string {
+	return configFile + "." + t.Format(backupTimeLayout) + ".bak"
+}
+
+// listConfigBackups returns the paths of the config's backups, newest first. A plain .bak file
+// left by older versions comes last.
+func listConfigBackups() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(configFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(configFile) + "."
+	var names []string
+	legacy := false
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == prefix+"bak" {
+			legacy = true
+			continue
+		}
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if ok {
+			stamp, ok = strings.CutSuffix(stamp, ".bak")
+		}
+		if !ok {
+			continue
+		}
+		if _, err := time.Parse(backupTimeLayout, stamp); err == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	if legacy {
+		names = append(names, prefix+"bak")
+	}
+	backups := make([]string, len(names))
+	for i, name := range names {
+		backups[i] = filepath.Join(filepath.Dir(configFile), name)
+	}
+	return backups, nil
+}
+
+// saveBuffers holds the buffers saveConfig encodes into, so saving again reuses one that has
+// already grown to the config's size.
+var saveBuffers = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// saveConfig saves the configuration to the configFile. It fails with errSeveralConfigFiles
+// if overlayConfigFiles are merged into the config.
+func saveConfig(config Config) error {
+	if err := requireSingleConfig(); err != nil {
+		return err
+	}
+	// Build the document with the apps at the top level and the [_meta] table after them
+	doc := make(map[string]interface{}, len(config)+1)
+	written := unexpandConfigValues(config)
+	for appName, version := range written {
+		if opts, ok := appOptions[appName]; ok && !opts.isZero() {
+			doc[appName] = appTable{Version: version, AppOptions: opts}
+			continue
+		}
+		doc[appName] = version
+	}
+	if configSettings != nil {
+		doc[settingsTableKey] = configSettings
+	}
+	doc[metaTableKey] = configMeta{
+		SchemaVersion: configSchemaVersion,
+		SavedAt:       time.Now().UTC().Truncate(time.Second),
+		Checksum:      configChecksum(written, appOptions),
+		DefaultSource: configDefaultSource,
+	}
+
+	// Marshal the document in the format matching the file's extension
+	codec := codecFor(configFile)
+	buf := saveBuffers.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		saveBuffers.Put(buf)
+	}()
+	if err := codec.encode(buf, doc); err != nil {
+		log.Printf("Debug: Error marshalling config to %s: %v", codec.name, err)
 		return fmt.Errorf("could not format configuration for saving: %w", err)
 	}
+	data := buf.Bytes()
 
 	// Ensure the directory structure exists
 	dirPath := filepath.Dir(configFile)
-	if err := os.MkdirAll(dirPath, 0755); err != nil {
+	if err := os.MkdirAll(dirPath, configDirPerm); err != nil {
 		log.Printf("Debug: Error creating directory structure %s: %v", dirPath, err)
 		return fmt.Errorf("could not create config directory '%s': %w", dirPath, err)
 	}
+	checkConfigDirPermissions(dirPath)
 
-	// Write the TOML data to the file
-	// os.WriteFile truncates the file if it exists, and creates it if it doesn't.
-	err = os.WriteFile(configFile, data, 0644) // 0644 are standard file permissions
-	if err != nil {
+	// Write the data atomically, so an interrupted save leaves the previous config intact.
+	// The new file is created with configFilePerm, which also tightens an existing file's permissions.
+	if err := writeFileAtomic(configFile, data, configFilePerm); err != nil {
 		log.Printf("Debug: Error writing config to file %s: %v", configFile, err)
 		return fmt.Errorf("could not write configuration to file '%s': %w", configFile, err)
 	}
@@ -80,3 +522,161 @@ func saveConfig(config Config) error {
 	// log.Printf("%sConfig saved successfully to %s%s\n", colorGreen, configFile, colorReset) // Less verbose, success is implicit.
 	return nil
 }
+
+// unexpandedValue remembers a config value as written, before environment variable expansion.
+type unexpandedValue struct {
+	raw      string
+	expanded string
+}
+
+// unexpandedValues holds the values of the last loaded config that referenced environment
+// variables, by app name, so saveConfig can write back the references instead of their values.
+var unexpandedValues = make(map[string]unexpandedValue)
+
+// expandEnv expands ${VAR} and $VAR references in value. "$$" stands for a literal "$".
+func expandEnv(value string) string {
+	return os.Expand(value, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		return os.Getenv(name)
+	})
+}
+
+// expandConfigValues expands environment variable references in config's values in place,
+// recording the original values in unexpandedValues.
+func expandConfigValues(config Config) {
+	for appName, value := range config {
+		if !strings.Contains(value, "$") {
+			continue
+		}
+		expanded := expandEnv(value)
+		unexpandedValues[appName] = unexpandedValue{raw: value, expanded: expanded}
+		config[appName] = expanded
+	}
+}
+
+// unexpandConfigValues returns a copy of config in which values that still equal their
+// expansion at load time are replaced by the original, unexpanded value.
+func unexpandConfigValues(config Config) Config {
+	written := make(Config, len(config))
+	for appName, value := range config {
+		if v, ok := unexpandedValues[appName]; ok && v.expanded == value {
+			value = v.raw
+		}
+		written[appName] = value
+	}
+	return written
+}
+
+// decodeConfigMeta converts the raw [_meta] table into a configMeta.
+// Unknown or malformed fields are ignored; the meta table is advisory only.
+func decodeConfigMeta(value interface{}) *configMeta {
+	table, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	meta := &configMeta{}
+	if v, ok := table["schema_version"].(int64); ok {
+		meta.SchemaVersion = int(v)
+	}
+	if v, ok := table["saved_at"].(time.Time); ok {
+		meta.SavedAt = v
+	}
+	if v, ok := table["checksum"].(string); ok {
+		meta.Checksum = v
+	}
+	if v, ok := table["default_source"].(string); ok {
+		meta.DefaultSource = v
+	}
+	return meta
+}
+
+// dropEmptyEntries makes loadConfig leave out entries with an empty name or version instead of
+// only warning about them, so the next save removes them. It is set by the global -drop-empty flag.
+var dropEmptyEntries bool
+
+// checkEmptyEntries warns about entries whose name or version is empty or only whitespace, such
+// as ones added by hand, and removes them from config if dropEmptyEntries is set.
+func checkEmptyEntries(config Config) {
+	for _, appName := range sortedAppNames(config) {
+		if strings.TrimSpace(appName) != "" && strings.TrimSpace(config[appName]) != "" {
+			continue
+		}
+		if dropEmptyEntries {
+			log.Printf("Warning: Ignoring entry '%s' = '%s' in config file '%s': its name or version is empty.", appName, config[appName], configFile)
+			delete(config, appName)
+			delete(appOptions, appName)
+			continue
+		}
+		log.Printf("Warning: Entry '%s' = '%s' in config file '%s' has an empty name or version. Fix it, or run with -drop-empty to remove it.", appName, config[appName], configFile)
+	}
+}
+
+// checkConfigMeta warns if the config was written by a newer binary or edited by hand since it was last saved.
+func checkConfigMeta(meta *configMeta, config Config, options map[string]AppOptions) {
+	if meta == nil {
+		return
+	}
+	if meta.SchemaVersion > configSchemaVersion {
+		log.Printf("Warning: Config file '%s' uses schema version %d, but this binary only understands up to version %d. Some settings may be ignored.",
+			configFile, meta.SchemaVersion, configSchemaVersion)
+	}
+	if meta.Checksum != "" && meta.Checksum != configChecksum(config, options) {
+		log.Printf("Info: Config file '%s' appears to have been edited by hand since it was last saved (at %s).",
+			configFile, meta.SavedAt.Format(time.RFC3339))
+	}
+}
+
+// configChecksum returns a stable SHA-256 over the tracked applications, their versions and
+// their options. An app without options hashes as it did before options were covered, so such
+// configs aren't reported as edited by hand after an upgrade.
+func configChecksum(config Config, options map[string]AppOptions) string {
+	appNames := make([]string, 0, len(config))
+	for appName := range config {
+		appNames = append(appNames, appName)
+	}
+	sort.Strings(appNames)
+
+	h := sha256.New()
+	var line []byte
+	for _, appName := range appNames {
+		// "name=version\n", built in a reused buffer rather than formatted. The options, if any,
+		// follow the version as JSON, whose field order is fixed.
+		line = append(append(append(line[:0], appName...), '='), config[appName]...)
+		if opts, ok := options[appName]; ok && !opts.isZero() {
+			encoded, _ := json.Marshal(opts) // Plain strings, bools and string slices, which always marshal.
+			line = append(append(line, ' '), encoded...)
+		}
+		h.Write(append(line, '\n'))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// checkConfigDirPermissions warns if the config's directory, which MkdirAll leaves as it is when
+// it already exists, is accessible by group or others. It isn't changed, as -config may point into
+// a directory shared with other files.
+func checkConfigDirPermissions(dirPath string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Stat(dirPath)
+	if err != nil {
+		return
+	}
+	if mode := info.Mode().Perm(); mode&0077 != 0 {
+		log.Printf("Warning: Config directory '%s' is accessible by other users (mode %04o). Restrict it with: chmod 700 %s",
+			dirPath, mode, dirPath)
+	}
+}
+
+// checkConfigPermissions warns if the config file is readable or writable by group or others.
+func checkConfigPermissions(info os.FileInfo) {
+	if runtime.GOOS == "windows" {
+		return // Unix permission bits are not meaningful on Windows.
+	}
+	if mode := info.Mode().Perm(); mode&0077 != 0 {
+		log.Printf("Warning: Config file '%s' is accessible by other users (mode %04o). Restrict it with: chmod 600 %s",
+			configFile, mode, configFile)
+	}
+}
@@ -0,0 +1,531 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// useTestConfigFile points configFile at a file in a temp dir for the duration of the test.
+func useTestConfigFile(t testing.TB) string {
+	t.Helper()
+	originalConfigFile := configFile
+	configFile = t.TempDir() + "/versions.toml"
+	t.Cleanup(func() { configFile = originalConfigFile })
+	return configFile
+}
+
+// captureLog captures output written through the standard logger.
+func captureLog(f func()) string {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	f()
+	return buf.String()
+}
+
+func TestConfigMetaTable(t *testing.T) {
+	t.Run("SaveWritesMetaTable", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read config: %v", err)
+		}
+		content := string(data)
+		if !strings.Contains(content, "[_meta]") || !strings.Contains(content, "schema_version = 1") {
+			t.Errorf("Expected [_meta] table with schema_version = 1, got:\n%s", content)
+		}
+		if !strings.Contains(content, "saved_at = ") {
+			t.Errorf("Expected saved_at timestamp in meta table, got:\n%s", content)
+		}
+	})
+
+	t.Run("MetaTableNotLoadedAsApp", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if _, exists := cfg[metaTableKey]; exists {
+			t.Errorf("Meta table should not be loaded as an application")
+		}
+		if len(cfg) != 1 || cfg["owner/repo"] != "1.0.0" {
+			t.Errorf("Expected only owner/repo 1.0.0, got %v", cfg)
+		}
+	})
+
+	t.Run("MetaTableNotListed", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{}) }))
+		if strings.Contains(output, metaTableKey) {
+			t.Errorf("List output should not mention the meta table. Got:\n%s", output)
+		}
+	})
+
+	t.Run("NewerSchemaWarns", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		content := "\"owner/repo\" = \"1.0.0\"\n\n[_meta]\nschema_version = 99\n"
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		var cfg Config
+		logOutput := captureLog(func() {
+			var err error
+			cfg, err = loadConfig()
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+		})
+		if !strings.Contains(logOutput, "schema version 99") {
+			t.Errorf("Expected forward-compat warning, got: %s", logOutput)
+		}
+		if cfg["owner/repo"] != "1.0.0" {
+			t.Errorf("Expected apps to still load, got %v", cfg)
+		}
+	})
+
+	t.Run("CurrentSchemaDoesNotWarn", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		logOutput := captureLog(func() { loadConfig() })
+		if strings.Contains(logOutput, "Warning") || strings.Contains(logOutput, "edited by hand") {
+			t.Errorf("Expected no warnings for a freshly saved config, got: %s", logOutput)
+		}
+	})
+
+	t.Run("HandEditDetected", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		edited := strings.Replace(string(data), `"1.0.0"`, `"2.0.0"`, 1)
+		if err := os.WriteFile(path, []byte(edited), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		logOutput := captureLog(func() { loadConfig() })
+		if !strings.Contains(logOutput, "edited by hand") {
+			t.Errorf("Expected hand-edit notice, got: %s", logOutput)
+		}
+	})
+
+	t.Run("OptionEditDetected", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		defer func() { appOptions = make(map[string]AppOptions) }()
+		appOptions["owner/repo"] = AppOptions{Note: "pinned"}
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		if logOutput := captureLog(func() { loadConfig() }); strings.Contains(logOutput, "edited by hand") {
+			t.Fatalf("Expected no hand-edit notice for a freshly saved config with options, got: %s", logOutput)
+		}
+		data, _ := os.ReadFile(path)
+		edited := strings.Replace(string(data), `"pinned"`, `"unpinned"`, 1)
+		if err := os.WriteFile(path, []byte(edited), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		logOutput := captureLog(func() { loadConfig() })
+		if !strings.Contains(logOutput, "edited by hand") {
+			t.Errorf("Expected hand-edit notice after changing an option, got: %s", logOutput)
+		}
+	})
+}
+
+func TestCanonicalKey(t *testing.T) {
+	tests := map[string]string{
+		"owner/repo":        "owner/repo",
+		"github:owner/repo": "owner/repo",
+		"Owner/Repo":        "owner/repo",
+		"github:Owner/Repo": "owner/repo",
+		"myapp":             "myapp",
+	}
+	for input, expected := range tests {
+		if got := canonicalKey(input); got != expected {
+			t.Errorf("canonicalKey(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	config := Config{
+		"owner/repo":        "1.0.0",
+		"github:owner/repo": "1.0.0",
+		"other/tool":        "2.0.0",
+		"github:Other/Tool": "2.1.0",
+		"unique/app":        "3.0.0",
+	}
+	duplicates := findDuplicates(config)
+	if len(duplicates) != 2 {
+		t.Fatalf("Expected 2 duplicate groups, got %v", duplicates)
+	}
+	if strings.Join(duplicates[0], ",") != "github:Other/Tool,other/tool" {
+		t.Errorf("Unexpected first group: %v", duplicates[0])
+	}
+	if strings.Join(duplicates[1], ",") != "github:owner/repo,owner/repo" {
+		t.Errorf("Unexpected second group: %v", duplicates[1])
+	}
+}
+
+func TestConfigPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions are not meaningful on Windows")
+	}
+
+	t.Run("SaveUsesOwnerOnlyModes", func(t *testing.T) {
+		originalConfigFile := configFile
+		dir := filepath.Join(t.TempDir(), "shepherd")
+		configFile = filepath.Join(dir, "versions.toml")
+		defer func() { configFile = originalConfigFile }()
+
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		fileInfo, err := os.Stat(configFile)
+		if err != nil {
+			t.Fatalf("Failed to stat config: %v", err)
+		}
+		if mode := fileInfo.Mode().Perm(); mode != 0600 {
+			t.Errorf("Expected config file mode 0600, got %04o", mode)
+		}
+		dirInfo, err := os.Stat(dir)
+		if err != nil {
+			t.Fatalf("Failed to stat config dir: %v", err)
+		}
+		if mode := dirInfo.Mode().Perm(); mode != 0700 {
+			t.Errorf("Expected config dir mode 0700, got %04o", mode)
+		}
+	})
+
+	t.Run("SaveWarnsOnPermissiveDir", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		dir := filepath.Dir(path)
+		if err := os.Chmod(dir, 0755); err != nil {
+			t.Fatalf("Failed to chmod config dir: %v", err)
+		}
+		logOutput := captureLog(func() { saveConfig(Config{"owner/repo": "1.0.0"}) })
+		if !strings.Contains(logOutput, "directory '"+dir+"' is accessible by other users (mode 0755)") || !strings.Contains(logOutput, "chmod 700 "+dir) {
+			t.Errorf("Expected a directory permissions warning with chmod hint, got: %s", logOutput)
+		}
+
+		if err := os.Chmod(dir, 0700); err != nil {
+			t.Fatalf("Failed to chmod config dir: %v", err)
+		}
+		if logOutput := captureLog(func() { saveConfig(Config{"owner/repo": "1.0.0"}) }); strings.Contains(logOutput, "accessible by other users") {
+			t.Errorf("Expected no warning for a private config dir, got: %s", logOutput)
+		}
+	})
+
+	t.Run("SaveTightensExistingFile", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		if err := os.WriteFile(path, []byte(""), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		fileInfo, _ := os.Stat(path)
+		if mode := fileInfo.Mode().Perm(); mode != 0600 {
+			t.Errorf("Expected existing config file to be tightened to 0600, got %04o", mode)
+		}
+	})
+
+	t.Run("LoadWarnsOnPermissiveFile", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		if err := os.Chmod(path, 0644); err != nil {
+			t.Fatalf("Failed to chmod config: %v", err)
+		}
+		logOutput := captureLog(func() { loadConfig() })
+		if !strings.Contains(logOutput, "accessible by other users (mode 0644)") || !strings.Contains(logOutput, "chmod 600 "+path) {
+			t.Errorf("Expected permissions warning with chmod hint, got: %s", logOutput)
+		}
+	})
+
+	t.Run("LoadQuietOnPrivateFile", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		logOutput := captureLog(func() { loadConfig() })
+		if strings.Contains(logOutput, "accessible by other users") {
+			t.Errorf("Expected no permissions warning, got: %s", logOutput)
+		}
+	})
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestConfigEnvExpansion(t *testing.T) {
+	path := useTestConfigFile(t)
+	t.Setenv("TOOL_VERSION", "2.3.4")
+	t.Setenv("TOOL_MAJOR", "5")
+	content := "\"owner/braced\" = \"${TOOL_VERSION}\"\n" +
+		"\"owner/bare\" = \"$TOOL_MAJOR.0.0\"\n" +
+		"\"owner/literal\" = \"price$$1\"\n" +
+		"\"owner/plain\" = \"1.0.0\"\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	expected := Config{"owner/braced": "2.3.4", "owner/bare": "5.0.0", "owner/literal": "price$1", "owner/plain": "1.0.0"}
+	for appName, version := range expected {
+		if cfg[appName] != version {
+			t.Errorf("Expected %s to load as %q, got %q", appName, version, cfg[appName])
+		}
+	}
+
+	// Saving writes the references back, not their values, unless a value was changed.
+	cfg["owner/plain"] = "1.1.0"
+	cfg["owner/bare"] = "6.0.0"
+	if err := saveConfig(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	saved := string(data)
+	for _, line := range []string{`"owner/braced" = "${TOOL_VERSION}"`, `"owner/literal" = "price$$1"`, `"owner/bare" = "6.0.0"`} {
+		if !strings.Contains(saved, line) {
+			t.Errorf("Expected saved config to contain %s, got:\n%s", line, saved)
+		}
+	}
+	if strings.Contains(saved, "2.3.4") {
+		t.Errorf("Expanded values must not be persisted, got:\n%s", saved)
+	}
+
+	logOutput := captureLog(func() { loadConfig() })
+	if strings.Contains(logOutput, "edited by hand") {
+		t.Errorf("Expected the checksum to match the saved references, got: %s", logOutput)
+	}
+}
+
+func TestLoadConfigEmptyEntries(t *testing.T) {
+	content := "\"\" = \"1.0.0\"\n\"owner/blank\" = \"  \"\n\"owner/empty\" = \"\"\n\"owner/ok\" = \"1.0.0\"\n"
+
+	t.Run("WarnsAndKeeps", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		writeTestFile(t, path, content)
+		var cfg Config
+		logOutput := captureLog(func() { cfg, _ = loadConfig() })
+		for _, appName := range []string{"", "owner/blank", "owner/empty"} {
+			if _, ok := cfg[appName]; !ok {
+				t.Errorf("Expected '%s' to be kept without -drop-empty", appName)
+			}
+			if !strings.Contains(logOutput, fmt.Sprintf("Entry '%s'", appName)) {
+				t.Errorf("Expected a warning about '%s', got: %s", appName, logOutput)
+			}
+		}
+		if strings.Contains(logOutput, "owner/ok") {
+			t.Errorf("Expected no warning about a valid entry, got: %s", logOutput)
+		}
+	})
+
+	t.Run("DropsWhenAsked", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		writeTestFile(t, path, content)
+		dropEmptyEntries = true
+		defer func() { dropEmptyEntries = false }()
+
+		var cfg Config
+		logOutput := captureLog(func() { cfg, _ = loadConfig() })
+		if len(cfg) != 1 || cfg["owner/ok"] != "1.0.0" {
+			t.Errorf("Expected only owner/ok to remain, got %v", cfg)
+		}
+		if !strings.Contains(logOutput, "Ignoring entry 'owner/empty'") {
+			t.Errorf("Expected a warning about the dropped entry, got: %s", logOutput)
+		}
+		if err := saveConfig(cfg); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), "owner/empty") {
+			t.Errorf("Expected dropped entries to be gone after saving, got:\n%s", data)
+		}
+	})
+}
+
+func TestMultipleConfigFiles(t *testing.T) {
+	base := useTestConfigFile(t)
+	dir := filepath.Dir(base)
+	defer func() {
+		overlayConfigFiles = nil
+		appOptions = make(map[string]AppOptions)
+	}()
+	overlay, local := filepath.Join(dir, "overlay.toml"), filepath.Join(dir, "local.yaml")
+	files := map[string]string{
+		base:    "\"owner/a\" = \"1.0.0\"\n\"owner/b\" = \"1.0.0\"\n\n[\"owner/c\"]\nversion = \"1.0.0\"\nnote = \"from base\"\n",
+		overlay: "\"owner/b\" = \"2.0.0\"\n\"owner/c\" = \"2.0.0\"\n\"owner/d\" = \"2.0.0\"\n",
+		local:   "owner/d:\n  version: 3.0.0\n  alias: dee\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	var paths configPaths
+	for _, path := range []string{base, overlay, local} {
+		paths.Set(path)
+	}
+	configFile, overlayConfigFiles = paths[0], paths[1:]
+
+	t.Run("LaterFilesWin", func(t *testing.T) {
+		config, err := loadConfig()
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		expected := Config{"owner/a": "1.0.0", "owner/b": "2.0.0", "owner/c": "2.0.0", "owner/d": "3.0.0"}
+		if fmt.Sprint(config) != fmt.Sprint(expected) {
+			t.Errorf("Expected %v, got %v", expected, config)
+		}
+		if _, ok := appOptions["owner/c"]; ok {
+			t.Errorf("Expected an overriding entry to replace the app's options, got %+v", appOptions["owner/c"])
+		}
+		if appOptions["owner/d"].Alias != "dee" {
+			t.Errorf("Expected the last file's options, got %+v", appOptions["owner/d"])
+		}
+	})
+
+	t.Run("WritesNeedOneFile", func(t *testing.T) {
+		before, _ := os.ReadFile(base)
+		if err := saveConfig(Config{"owner/a": "9.9.9"}); err != errSeveralConfigFiles {
+			t.Errorf("Expected saving to be refused, got %v", err)
+		}
+		if _, err := backupConfig(); err != errSeveralConfigFiles {
+			t.Errorf("Expected backing up to be refused, got %v", err)
+		}
+		errOutput := captureStderr(t, func() { captureOutput(func() { handleAddCmd("owner/a", "9.9.9", addOptions{}) }) })
+		if !strings.Contains(errOutput, "give exactly one -config") {
+			t.Errorf("Expected add to explain which -config to give, got: %q", errOutput)
+		}
+		if after, _ := os.ReadFile(base); string(after) != string(before) {
+			t.Errorf("Expected the base config to be unchanged, got:\n%s", after)
+		}
+
+		overlayConfigFiles = nil
+		if err := saveConfig(Config{"owner/a": "9.9.9"}); err != nil {
+			t.Errorf("Expected saving with a single config file to work, got %v", err)
+		}
+	})
+
+	t.Run("MissingOverlay", func(t *testing.T) {
+		overlayConfigFiles = []string{filepath.Join(dir, "missing.toml")}
+		if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "missing.toml") {
+			t.Errorf("Expected a missing overlay to be an error, got %v", err)
+		}
+	})
+}
+
+func TestSaveConfigOutput(t *testing.T) {
+	path := useTestConfigFile(t)
+	defer func() { appOptions = make(map[string]AppOptions) }()
+	appOptions["owner/b"] = AppOptions{Alias: "bee", CheckEvery: "12h", Sources: []string{"brew:b"}}
+	config := Config{"owner/a": "1.0.0", "owner/b": "2.0.0", "gitlab:group/c": "3.0.0"}
+
+	// Saving twice, e.g. reusing an encoding buffer, gives the same file every time.
+	for i := 0; i < 2; i++ {
+		if err := saveConfig(config); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read config: %v", err)
+		}
+		var lines []string
+		for _, line := range strings.Split(string(data), "\n") {
+			if !strings.HasPrefix(line, "  saved_at = ") {
+				lines = append(lines, line)
+			}
+		}
+		expected := `"gitlab:group/c" = "3.0.0"
+"owner/a" = "1.0.0"
+
+[_meta]
+  schema_version = 1
+  checksum = "` + configChecksum(config, appOptions) + `"
+
+["owner/b"]
+  version = "2.0.0"
+  check_every = "12h"
+  alias = "bee"
+  sources = ["brew:b"]
+`
+		if got := strings.Join(lines, "\n"); got != expected {
+			t.Errorf("Save %d: expected:\n%s\ngot:\n%s", i+1, expected, got)
+		}
+	}
+}
+
+func TestSaveConfigRecreatesRemovedDirectory(t *testing.T) {
+	path := useTestConfigFile(t)
+	originalConfigFile := configFile
+	defer func() { configFile = originalConfigFile }()
+	configFile = filepath.Join(filepath.Dir(path), "nested", "versions.toml")
+
+	if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Dir(configFile)); err != nil {
+		t.Fatalf("Failed to remove config directory: %v", err)
+	}
+	if err := saveConfig(Config{"owner/repo": "2.0.0"}); err != nil {
+		t.Fatalf("Expected saving again to recreate the removed directory, got: %v", err)
+	}
+	if config, err := loadConfig(); err != nil || config["owner/repo"] != "2.0.0" {
+		t.Errorf("Expected the second save to be written, got %v (%v)", config, err)
+	}
+}
+
+// BenchmarkSaveConfig saves a config of 1000 apps, a tenth of them with options, after changing
+// one version, as 'add' does. About three quarters of the time is spent encoding the TOML.
+//
+// Before reusing the encoding buffer and checksumming without fmt:
+//
+//	BenchmarkSaveConfig 	     412	   3008966 ns/op	  905342 B/op	   23717 allocs/op
+//
+// After; the time, dominated by encoding and the write, is unchanged within noise:
+//
+//	BenchmarkSaveConfig 	     428	   2806183 ns/op	  746271 B/op	   21712 allocs/op
+func BenchmarkSaveConfig(b *testing.B) {
+	useTestConfigFile(b)
+	defer func() { appOptions = make(map[string]AppOptions) }()
+	config := make(Config, 1000)
+	for i := 0; i < 1000; i++ {
+		appName := fmt.Sprintf("owner%d/app%d", i%50, i)
+		config[appName] = fmt.Sprintf("%d.%d.%d", i%7, i%13, i)
+		if i%10 == 0 {
+			appOptions[appName] = AppOptions{Alias: fmt.Sprintf("app%d", i), CheckEvery: "12h"}
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		config["owner0/app0"] = fmt.Sprintf("1.0.%d", i)
+		if err := saveConfig(config); err != nil {
+			b.Fatalf("Failed to save config: %v", err)
+		}
+	}
+}
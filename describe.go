@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// descriptionConcurrency caps the number of repository descriptions fetched at once.
+const descriptionConcurrency = 8
+
+// descriptionCacheTTL is how long fetched descriptions are reused; they rarely change.
+const descriptionCacheTTL = 7 * 24 * time.Hour
+
+// descriptionCachePrefix starts the keys descriptions are cached under, keeping them apart from
+// the latest versions sharing the cache file.
+const descriptionCachePrefix = "description:"
+
+// descriptionCache caches repository descriptions by identifier. It is set to the cache file by
+// 'list -descriptions' and 'check -descriptions'; tests can set a memoryCache.
+var descriptionCache Cache
+
+// getRepoDescriptionGitHubImpl fetches the description of the GitHub repository appIdentifier
+// (owner/repo), which may be empty. apiBaseURL behaves as for getLatestVersionGitHubImpl.
+func getRepoDescriptionGitHubImpl(ctx context.Context, appIdentifier, apiBaseURL string) (string, error) {
+	url, err := githubRepoURL(appIdentifier, apiBaseURL, "")
+	if err != nil {
+		return "", err
+	}
+	var repo struct {
+		Description string `json:"description"`
+	}
+	if err := githubGetJSON(ctx, appIdentifier, url, &repo); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(repo.Description), nil
+}
+
+// getRepoDescription is used by -descriptions. Tests can override it.
+var getRepoDescription = getRepoDescriptionGitHubImpl
+
+// fetchDescriptions returns the descriptions of the GitHub repositories of appNames, by app
+// name, looking up several at once. Apps on other sources, repositories without a description
+// and failed lookups are left out; a missing description isn't worth failing a command over.
+func fetchDescriptions(appNames []string) map[string]string {
+	descriptions := make(map[string]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, descriptionConcurrency)
+	for _, appName := range appNames {
+		source, identifier, err := resolveSource(appName)
+		if _, isGitHub := source.(githubSource); err != nil || !isGitHub || !source.ValidIdentifier(identifier) {
+			continue
+		}
+		wg.Add(1)
+		go func(appName, identifier string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if description := repoDescription(identifier); description != "" {
+				mu.Lock()
+				descriptions[appName] = description
+				mu.Unlock()
+			}
+		}(appName, identifier)
+	}
+	wg.Wait()
+	return descriptions
+}
+
+// repoDescription returns identifier's description from descriptionCache, or else fetches and
+// caches it. It returns "" if the lookup fails.
+func repoDescription(identifier string) string {
+	key := descriptionCachePrefix + identifier
+	if descriptionCache != nil {
+		if description, ok := descriptionCache.Get(key); ok {
+			return description
+		}
+	}
+	description, err := getRepoDescription(requestContext, identifier, githubAPIBase)
+	if err != nil {
+		log.Printf("Debug: Could not fetch the description of %s: %v", identifier, err)
+		return ""
+	}
+	if descriptionCache != nil {
+		descriptionCache.Set(key, description, descriptionCacheTTL)
+	}
+	return description
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRepoDescriptions(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/repos/sharkdp/bat":
+			fmt.Fprintln(w, `{"full_name": "sharkdp/bat", "description": "A cat(1) clone with wings."}`)
+		case "/repos/owner/bare":
+			fmt.Fprintln(w, `{"full_name": "owner/bare", "description": null}`)
+		case "/repos/sharkdp/bat/releases/latest", "/repos/owner/bare/releases/latest":
+			fmt.Fprintln(w, `{"tag_name": "v1.0.0"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	originalAPIBase, originalCache := githubAPIBase, descriptionCache
+	githubAPIBase, descriptionCache = server.URL, newMemoryCache()
+	defer func() { githubAPIBase, descriptionCache = originalAPIBase, originalCache }()
+
+	useTestConfigFile(t)
+	if err := saveConfig(Config{"sharkdp/bat": "1.0.0", "owner/bare": "1.0.0", "owner/gone": "1.0.0", "brew:ripgrep": "14.1.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	t.Run("Fetch", func(t *testing.T) {
+		descriptions := fetchDescriptions([]string{"sharkdp/bat", "owner/bare", "owner/gone", "brew:ripgrep"})
+		expected := map[string]string{"sharkdp/bat": "A cat(1) clone with wings."}
+		if fmt.Sprint(descriptions) != fmt.Sprint(expected) {
+			t.Errorf("Expected %v, got %v", expected, descriptions)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{descriptions: true}) }))
+		if !strings.Contains(output, "  - Application: sharkdp/bat, Version: 1.0.0\n      Description: A cat(1) clone with wings.\n") {
+			t.Errorf("Expected the description under its app, got:\n%s", output)
+		}
+		if strings.Count(output, "Description:") != 1 {
+			t.Errorf("Expected a single description, got:\n%s", output)
+		}
+		output = stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{}) }))
+		if strings.Contains(output, "Description:") {
+			t.Errorf("Expected no descriptions without -descriptions, got:\n%s", output)
+		}
+	})
+
+	t.Run("Check", func(t *testing.T) {
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("sharkdp/bat", checkOptions{descriptions: true}) }))
+		if !strings.Contains(output, "Checking sharkdp/bat...  Current: 1.0.0, Latest: 1.0.0 (Up to date)\n    A cat(1) clone with wings.\n") {
+			t.Errorf("Expected the description under the result, got:\n%s", output)
+		}
+		output = captureOutput(func() { handleCheckCmd("sharkdp/bat", checkOptions{descriptions: true, format: formatNDJSON}) })
+		if !strings.Contains(output, `"description":"A cat(1) clone with wings."`) {
+			t.Errorf("Expected the description in the JSON, got:\n%s", output)
+		}
+	})
+
+	// Every lookup above after the first was answered from the cache, even an empty description.
+	for _, path := range []string{"/repos/sharkdp/bat", "/repos/owner/bare"} {
+		if requests[path] != 1 {
+			t.Errorf("Expected %s to be fetched once, got %d requests", path, requests[path])
+		}
+	}
+}
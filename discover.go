@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pseudoVersionPattern matches Go pseudo-versions such as v0.0.0-20230101120000-abcdef123456,
+// which name a commit rather than a release and so can't be checked against releases.
+var pseudoVersionPattern = regexp.MustCompile(`-(?:0\.)?\d{14}-[0-9a-f]{12}$`)
+
+// parseRepoFile extracts the GitHub repositories a dependency file refers to, with the version
+// of each. A file named go.mod is read as a Go module file; anything else as a requirements-style
+// list with one "<path> <version>", "<path>==<version>" or "<path>@<version>" per line. Paths
+// may be github.com/owner/repo[/...] or plain owner/repo. Other dependencies, and ones pinned to
+// a Go pseudo-version, are left out. A repository listed more than once keeps its newest version.
+func parseRepoFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var requirements [][2]string
+	if filepath.Base(path) == "go.mod" {
+		requirements = parseGoModRequirements(string(data))
+	} else {
+		requirements = parseRequirementsList(string(data))
+	}
+
+	repos := make(Config)
+	for _, requirement := range requirements {
+		repo, ok := githubRepoFromPath(requirement[0])
+		version := strings.TrimSuffix(requirement[1], "+incompatible")
+		if !ok || version == "" || pseudoVersionPattern.MatchString(version) {
+			continue
+		}
+		version = tagVersion(version)
+		if existing, seen := repos[repo]; !seen || compareVersions(version, existing) > 0 {
+			repos[repo] = version
+		}
+	}
+	return repos, nil
+}
+
+// parseGoModRequirements returns the module path and version of every require directive in a
+// go.mod file, both single-line and in require blocks.
+func parseGoModRequirements(content string) [][2]string {
+	var requirements [][2]string
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inBlock && fields[0] == ")":
+			inBlock = false
+			continue
+		case !inBlock && fields[0] == "require":
+			if len(fields) == 2 && fields[1] == "(" {
+				inBlock = true
+				continue
+			}
+			fields = fields[1:]
+		case !inBlock:
+			continue
+		}
+		if len(fields) >= 2 {
+			requirements = append(requirements, [2]string{fields[0], fields[1]})
+		}
+	}
+	return requirements
+}
+
+// parseRequirementsList returns the path and version on each line of a requirements-style file,
+// ignoring blank lines, '#' comments and lines without a version.
+func parseRequirementsList(content string) [][2]string {
+	var requirements [][2]string
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		for _, separator := range []string{"==", "@"} {
+			line = strings.Replace(line, separator, " ", 1)
+		}
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			requirements = append(requirements, [2]string{fields[0], fields[1]})
+		}
+	}
+	return requirements
+}
+
+// githubRepoFromPath returns the owner/repo of a module path such as github.com/owner/repo/v2,
+// or of a plain owner/repo. It reports false for paths on other hosts.
+func githubRepoFromPath(path string) (string, bool) {
+	path = strings.TrimPrefix(path, "https://")
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "github.com":
+		parts = parts[1:3]
+	case len(parts) == 2 && !strings.Contains(parts[0], "."):
+	default:
+		return "", false
+	}
+	if parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0] + "/" + strings.TrimSuffix(parts[1], ".git"), true
+}
+
+// handleAddFromRepoFileCmd offers to start tracking the GitHub repositories listed in a go.mod
+// or requirements-style file at the versions the file uses. Apps already tracked are left alone.
+// Unless skipConfirm is set, the list is shown and confirmed before anything is saved.
+func handleAddFromRepoFileCmd(path string, skipConfirm bool) {
+	repos, err := parseRepoFile(path)
+	if err != nil {
+		PrintError("Could not read '%s': %v", path, err)
+		return
+	}
+	config, err := loadConfig()
+	if err != nil {
+		PrintError("Could not load configuration: %v", err)
+		return
+	}
+
+	var toAdd []string
+	for _, repo := range sortedAppNames(repos) {
+		if findByCanonicalKey(config, repo) != "" {
+			continue
+		}
+		toAdd = append(toAdd, repo)
+	}
+	if len(toAdd) == 0 {
+		PrintInfo("No new GitHub repositories found in '%s'.", path)
+		return
+	}
+
+	for _, repo := range toAdd {
+		PrintMessage("  + %s %s", Colorize(repo, colorYellowFg), Colorize(repos[repo], colorCyanFg))
+	}
+	if !skipConfirm && !Confirm("Track %s at these versions?", pluralize(len(toAdd), "repository", "repositories")) {
+		PrintInfo("Nothing added.")
+		return
+	}
+
+	for _, repo := range toAdd {
+		config[repo] = repos[repo]
+	}
+	if err := saveConfig(config); err != nil {
+		PrintError("Could not save configuration: %v", err)
+		return
+	}
+	PrintSuccess("Added %s from '%s'.", pluralize(len(toAdd), "repository", "repositories"), path)
+}
+
+// findByCanonicalKey returns the tracked app that refers to the same repository as appName,
+// or "" if there is none.
+func findByCanonicalKey(config Config, appName string) string {
+	key := canonicalKey(appName)
+	for existing := range config {
+		if canonicalKey(existing) == key {
+			return existing
+		}
+	}
+	return ""
+}
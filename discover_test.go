@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleGoMod = `module github.com/example/tool
+
+go 1.22
+
+require github.com/spf13/cobra v1.8.0
+
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/charmbracelet/lipgloss/v2 v2.0.0 // indirect
+	github.com/docker/docker v24.0.7+incompatible
+	github.com/google/go-cmp v0.6.0
+	github.com/google/go-cmp/cmp/internal v0.5.9
+	github.com/nopkg/pseudo v0.0.0-20230101120000-abcdef123456
+	golang.org/x/sys v0.15.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/spf13/cobra => ../cobra
+`
+
+func TestParseRepoFile(t *testing.T) {
+	t.Run("GoMod", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "go.mod")
+		writeTestFile(t, path, sampleGoMod)
+		repos, err := parseRepoFile(path)
+		if err != nil {
+			t.Fatalf("parseRepoFile failed: %v", err)
+		}
+		expected := Config{
+			"spf13/cobra":            "1.8.0",
+			"BurntSushi/toml":        "1.3.2",
+			"charmbracelet/lipgloss": "2.0.0",
+			"docker/docker":          "24.0.7",
+			"google/go-cmp":          "0.6.0",
+		}
+		if !reflect.DeepEqual(repos, expected) {
+			t.Errorf("Expected %v, got %v", expected, repos)
+		}
+	})
+
+	t.Run("RequirementsList", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tools.txt")
+		writeTestFile(t, path, "# tools\nsharkdp/bat==0.24.0\ngithub.com/junegunn/fzf@v0.44.1\ncli/cli 2.40.0  # gh\nexample.com/other 1.0.0\nno-version/here\n")
+		repos, err := parseRepoFile(path)
+		if err != nil {
+			t.Fatalf("parseRepoFile failed: %v", err)
+		}
+		expected := Config{"sharkdp/bat": "0.24.0", "junegunn/fzf": "0.44.1", "cli/cli": "2.40.0"}
+		if !reflect.DeepEqual(repos, expected) {
+			t.Errorf("Expected %v, got %v", expected, repos)
+		}
+	})
+}
+
+func TestHandleAddFromRepoFile(t *testing.T) {
+	path := useTestConfigFile(t)
+	if err := saveConfig(Config{"google/go-cmp": "0.5.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	goMod := filepath.Join(t.TempDir(), "go.mod")
+	writeTestFile(t, goMod, sampleGoMod)
+
+	out := stripAnsiCodes(captureOutput(func() { handleAddFromRepoFileCmd(goMod, true) }))
+	if !strings.Contains(out, "Added 4 repositories") {
+		t.Errorf("Expected 4 repositories to be added, got: %s", out)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config["google/go-cmp"] != "0.5.0" {
+		t.Errorf("Expected an already tracked app to be left alone, got '%s'", config["google/go-cmp"])
+	}
+	if config["spf13/cobra"] != "1.8.0" {
+		data, _ := os.ReadFile(path)
+		t.Errorf("Expected spf13/cobra at 1.8.0, got config:\n%s", data)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Outcomes of a single 'doctor' check.
+const (
+	doctorPass = "PASS"
+	doctorWarn = "WARN"
+	doctorFail = "FAIL"
+)
+
+// doctorLowRateLimit is the number of remaining API requests below which 'doctor' warns.
+const doctorLowRateLimit = 10
+
+// doctorCheck is the outcome of one 'doctor' check.
+type doctorCheck struct {
+	name    string
+	level   string // One of the doctor* outcomes.
+	message string
+}
+
+// runDoctorChecks diagnoses the setup: the config file, the GitHub token, connectivity to the
+// GitHub API and the remaining rate limit. It only reads; nothing is created or changed.
+func runDoctorChecks() []doctorCheck {
+	checks := doctorConfigChecks()
+	if githubToken != "" {
+		checks = append(checks, doctorCheck{"GitHub token", doctorPass, "A token is available and will be sent with GitHub requests."})
+	} else {
+		checks = append(checks, doctorCheck{"GitHub token", doctorWarn,
+			"No token found; unauthenticated requests are limited to 60 per hour. Set GITHUB_TOKEN, log in with the gh CLI or pass -token."})
+	}
+	return append(checks, doctorGitHubChecks()...)
+}
+
+// doctorConfigChecks checks that the config file, if any, can be read and is private.
+func doctorConfigChecks() []doctorCheck {
+	info, err := os.Stat(configFile)
+	if os.IsNotExist(err) {
+		return []doctorCheck{{"Config file", doctorWarn, fmt.Sprintf("%s does not exist yet; 'add' will create it.", configFile)}}
+	}
+	if err != nil {
+		return []doctorCheck{{"Config file", doctorFail, fmt.Sprintf("Could not access %s: %v", configFile, err)}}
+	}
+
+	var checks []doctorCheck
+	if mode := info.Mode().Perm(); runtime.GOOS != "windows" && mode&0077 != 0 {
+		checks = append(checks, doctorCheck{"Config permissions", doctorWarn,
+			fmt.Sprintf("%s is accessible by other users (mode %04o). Restrict it with: chmod 600 %s", configFile, mode, configFile)})
+	} else {
+		checks = append(checks, doctorCheck{"Config permissions", doctorPass, fmt.Sprintf("Mode %04o.", mode)})
+	}
+
+	// loadConfig logs its own warnings, which would only repeat this report.
+	previousLogOutput := log.Writer()
+	log.SetOutput(io.Discard)
+	config, err := loadConfig()
+	log.SetOutput(previousLogOutput)
+	if err != nil {
+		return append(checks, doctorCheck{"Config file", doctorFail, err.Error()})
+	}
+	return append(checks, doctorCheck{"Config file", doctorPass,
+		fmt.Sprintf("%s tracks %s.", configFile, pluralize(len(config), "application", "applications"))})
+}
+
+// doctorGitHubChecks makes a single HEAD request to the GitHub API's rate limit endpoint, which
+// doesn't count against the limit, and reports on connectivity and the remaining requests.
+func doctorGitHubChecks() []doctorCheck {
+	baseURL := defaultGitHubAPIBase
+	if githubAPIBase != "" {
+		baseURL = githubAPIBase
+	}
+	url := strings.TrimSuffix(baseURL, "/") + "/rate_limit"
+	req, err := http.NewRequestWithContext(requestContext, http.MethodHead, url, nil)
+	if err != nil {
+		return []doctorCheck{{"GitHub API", doctorFail, fmt.Sprintf("Invalid API URL %s: %v", url, err)}}
+	}
+	req.Header.Set("User-Agent", envConfig().UserAgent)
+	req.Header.Set("X-GitHub-Api-Version", githubAPIVersion)
+	if githubToken != "" {
+		req.Header.Set("Authorization", "Bearer "+githubToken)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return []doctorCheck{{"GitHub API", doctorFail, fmt.Sprintf("Could not reach %s: %v", baseURL, err)}}
+	}
+	resp.Body.Close()
+
+	var checks []doctorCheck
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		checks = append(checks, doctorCheck{"GitHub API", doctorFail, fmt.Sprintf("%s rejected the token (status %d).", baseURL, resp.StatusCode)})
+	case resp.StatusCode >= 500:
+		checks = append(checks, doctorCheck{"GitHub API", doctorFail, fmt.Sprintf("%s returned status %d.", baseURL, resp.StatusCode)})
+	default:
+		checks = append(checks, doctorCheck{"GitHub API", doctorPass, fmt.Sprintf("%s is reachable.", baseURL)})
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return append(checks, doctorCheck{"Rate limit", doctorWarn, "The API did not report a rate limit."})
+	}
+	limit := resp.Header.Get("X-RateLimit-Limit")
+	switch {
+	case remaining == 0:
+		message := fmt.Sprintf("No requests remaining of %s.", limit)
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			message += fmt.Sprintf(" The limit resets at %s.", time.Unix(reset, 0).Format(time.Kitchen))
+		}
+		checks = append(checks, doctorCheck{"Rate limit", doctorFail, message})
+	case remaining < doctorLowRateLimit:
+		checks = append(checks, doctorCheck{"Rate limit", doctorWarn, fmt.Sprintf("Only %d of %s requests remaining.", remaining, limit)})
+	default:
+		checks = append(checks, doctorCheck{"Rate limit", doctorPass, fmt.Sprintf("%d of %s requests remaining.", remaining, limit)})
+	}
+	return checks
+}
+
+// preflightFailure runs the GitHub checks of 'doctor' for 'check -preflight', and returns the
+// message of the first one that failed, or "" if the API is reachable with requests to spare.
+func preflightFailure() string {
+	for _, check := range doctorGitHubChecks() {
+		if check.level == doctorFail {
+			return check.message
+		}
+	}
+	return ""
+}
+
+// handleDoctorCmd prints a pass/warn/fail report on the setup. It returns exitCheckFailed if any
+// check failed and exitOK otherwise; warnings don't affect the exit code.
+func handleDoctorCmd() int {
+	PrintHeader("shepherd doctor")
+	exitCode := exitOK
+	for _, check := range runDoctorChecks() {
+		levelColor := colorGreenFg
+		switch check.level {
+		case doctorWarn:
+			levelColor = colorYellowFg
+		case doctorFail:
+			levelColor = colorRedFg
+			exitCode = exitCheckFailed
+		}
+		PrintMessage("[%s] %s: %s", Colorize(check.level, levelColor), check.name, check.message)
+	}
+	return exitCode
+}
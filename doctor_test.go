@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDoctor(t *testing.T) {
+	originalToken, originalAPIBase := githubToken, githubAPIBase
+	defer func() { githubToken, githubAPIBase = originalToken, originalAPIBase }()
+
+	var method, authorization string
+	remaining := "4999"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, authorization = r.Method, r.Header.Get("Authorization")
+		if r.URL.Path != "/rate_limit" {
+			t.Errorf("Expected a request to /rate_limit, got %s", r.URL.Path)
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", remaining)
+	}))
+	defer server.Close()
+
+	// levels returns the outcome of each check by name.
+	levels := func(checks []doctorCheck) map[string]string {
+		byName := make(map[string]string)
+		for _, check := range checks {
+			byName[check.name] = check.level
+		}
+		return byName
+	}
+
+	t.Run("AllGood", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		githubToken, githubAPIBase, remaining = "secret", server.URL, "4999"
+		var exitCode int
+		output := stripAnsiCodes(captureOutput(func() { exitCode = handleDoctorCmd() }))
+		if exitCode != exitOK || strings.Contains(output, doctorWarn) || strings.Contains(output, doctorFail) {
+			t.Errorf("Expected every check to pass, got exit %d:\n%s", exitCode, output)
+		}
+		if !strings.Contains(output, "[PASS] Rate limit: 4999 of 5000 requests remaining.") {
+			t.Errorf("Expected the remaining rate limit, got:\n%s", output)
+		}
+		if method != http.MethodHead || authorization != "Bearer secret" {
+			t.Errorf("Expected an authenticated HEAD request, got %s with Authorization %q", method, authorization)
+		}
+	})
+
+	t.Run("NoTokenOrConfig", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		githubToken, githubAPIBase, remaining = "", server.URL, "42"
+		got := levels(runDoctorChecks())
+		if got["GitHub token"] != doctorWarn || got["Config file"] != doctorWarn || got["Rate limit"] != doctorPass {
+			t.Errorf("Expected warnings for the missing token and config, got %v", got)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected doctor not to create a config file, got %v", err)
+		}
+	})
+
+	t.Run("RateLimitExhausted", func(t *testing.T) {
+		useTestConfigFile(t)
+		githubToken, githubAPIBase, remaining = "", server.URL, "0"
+		if got := levels(runDoctorChecks()); got["Rate limit"] != doctorFail {
+			t.Errorf("Expected an exhausted rate limit to fail, got %v", got)
+		}
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		useTestConfigFile(t)
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		githubToken, githubAPIBase = "secret", closed.URL
+		var exitCode int
+		output := stripAnsiCodes(captureOutput(func() { exitCode = handleDoctorCmd() }))
+		if exitCode != exitCheckFailed || !strings.Contains(output, "[FAIL] GitHub API: Could not reach") {
+			t.Errorf("Expected a connectivity failure, got exit %d:\n%s", exitCode, output)
+		}
+	})
+
+	t.Run("OpenPermissions", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		if err := os.Chmod(path, 0644); err != nil {
+			t.Fatalf("Failed to chmod config: %v", err)
+		}
+		githubToken, githubAPIBase, remaining = "secret", server.URL, "4999"
+		if got := levels(runDoctorChecks()); got["Config permissions"] != doctorWarn || got["Config file"] != doctorPass {
+			t.Errorf("Expected a permissions warning, got %v", got)
+		}
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+			t.Errorf("Expected doctor to leave the permissions alone, got %v, %v", info.Mode().Perm(), err)
+		}
+	})
+}
+
+func TestCheckPreflight(t *testing.T) {
+	useTestConfigFile(t)
+	originalAPIBase, originalGetLatestVersion := githubAPIBase, getLatestVersion
+	defer func() { githubAPIBase, getLatestVersion = originalAPIBase, originalGetLatestVersion }()
+	lookups := 0
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		lookups++
+		return "1.0.0", nil
+	}
+	if err := saveConfig(Config{"owner/a": "1.0.0", "owner/b": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	remaining := "0"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", remaining)
+	}))
+	defer server.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	tests := []struct {
+		name        string
+		apiBase     string
+		remaining   string
+		expectedErr string
+	}{
+		{"NoConnectivity", unreachable.URL, "", "Preflight failed, so no applications were checked: Could not reach " + unreachable.URL},
+		{"RateLimitExhausted", server.URL, "0", "Preflight failed, so no applications were checked: No requests remaining of 60."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			githubAPIBase, remaining, lookups = tt.apiBase, tt.remaining, 0
+			var exitCode int
+			errOutput := stripAnsiCodes(captureStderr(t, func() {
+				captureOutput(func() { exitCode = handleCheckCmd("", checkOptions{preflight: true}) })
+			}))
+			if !strings.Contains(errOutput, tt.expectedErr) {
+				t.Errorf("Expected error %q, got %q", tt.expectedErr, errOutput)
+			}
+			if exitCode != exitCheckFailed || lookups != 0 {
+				t.Errorf("Expected to stop before any lookup with exit code %d, got %d after %d lookups", exitCheckFailed, exitCode, lookups)
+			}
+		})
+	}
+
+	t.Run("Passes", func(t *testing.T) {
+		githubAPIBase, remaining, lookups = server.URL, "59", 0
+		var exitCode int
+		captureOutput(func() { exitCode = handleCheckCmd("", checkOptions{preflight: true}) })
+		if exitCode != exitOK || lookups != 2 {
+			t.Errorf("Expected both apps to be checked after a good preflight, got exit %d after %d lookups", exitCode, lookups)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// defaultEnvPrefix namespaces the environment variables shepherd reads, e.g. SHOULDUPDATE_TOKEN.
+const defaultEnvPrefix = "SHOULDUPDATE_"
+
+// envPrefix is the prefix of the environment variables read by envConfig. It is set by the
+// global -env-prefix flag, e.g. to keep two setups apart on one machine.
+var envPrefix = defaultEnvPrefix
+
+// defaultUserAgent is the User-Agent sent with API requests unless USER_AGENT overrides it.
+const defaultUserAgent = "ShouldUpdateApp/1.0"
+
+// envSettings is the configuration taken from the environment. Each field is read from the
+// prefixed variable named in its comment, falling back to the well-known unprefixed one if any.
+type envSettings struct {
+	GitHubToken   string // TOKEN, then GITHUB_TOKEN.
+	GitLabToken   string // GITLAB_TOKEN, then GITLAB_TOKEN.
+	GiteaToken    string // GITEA_TOKEN, then GITEA_TOKEN; also used for Forgejo.
+	APIBase       string // API_BASE; the global -api-base flag wins over it.
+	UserAgent     string // USER_AGENT; defaults to defaultUserAgent.
+	DefaultSource string // DEFAULT_SOURCE; wins over the config's default_source.
+	NoColor       bool   // NO_COLOR, then NO_COLOR: set to anything non-empty to disable color.
+	Pager         string // PAGER, then PAGER; the -pager flag wins over it.
+	ConfigFile    string // CONFIG; the global -config flag wins over it.
+}
+
+// envConfig reads every environment variable shepherd honors. It is the only place the
+// environment is consulted for settings; values referenced from the config file are separate.
+func envConfig() envSettings {
+	settings := envSettings{
+		GitHubToken:   lookupEnv("TOKEN", "GITHUB_TOKEN"),
+		GitLabToken:   lookupEnv("GITLAB_TOKEN", "GITLAB_TOKEN"),
+		GiteaToken:    lookupEnv("GITEA_TOKEN", "GITEA_TOKEN"),
+		APIBase:       lookupEnv("API_BASE", ""),
+		UserAgent:     lookupEnv("USER_AGENT", ""),
+		DefaultSource: lookupEnv("DEFAULT_SOURCE", ""),
+		NoColor:       lookupEnv("NO_COLOR", "NO_COLOR") != "",
+		Pager:         lookupEnv("PAGER", "PAGER"),
+		ConfigFile:    lookupEnv("CONFIG", ""),
+	}
+	if settings.UserAgent == "" {
+		settings.UserAgent = defaultUserAgent
+	}
+	return settings
+}
+
+// lookupEnv returns the trimmed value of envPrefix+name or, if that is empty, of fallback.
+// An empty fallback means the setting has no unprefixed equivalent.
+func lookupEnv(name, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(envPrefix + name)); value != "" {
+		return value
+	}
+	if fallback == "" {
+		return ""
+	}
+	return strings.TrimSpace(os.Getenv(fallback))
+}
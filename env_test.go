@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestEnvConfig(t *testing.T) {
+	for _, name := range []string{"TOKEN", "GITHUB_TOKEN", "GITLAB_TOKEN", "API_BASE", "USER_AGENT", "DEFAULT_SOURCE", "NO_COLOR", "PAGER", "CONFIG"} {
+		t.Setenv(defaultEnvPrefix+name, "")
+		t.Setenv("CUSTOM_"+name, "")
+	}
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITLAB_TOKEN", "")
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("PAGER", "")
+
+	t.Run("Defaults", func(t *testing.T) {
+		got := envConfig()
+		if got != (envSettings{UserAgent: defaultUserAgent}) {
+			t.Errorf("Expected only the default user agent, got %+v", got)
+		}
+	})
+
+	t.Run("WellKnownVariables", func(t *testing.T) {
+		t.Setenv("GITHUB_TOKEN", "github")
+		t.Setenv("GITLAB_TOKEN", "gitlab")
+		t.Setenv("NO_COLOR", "1")
+		got := envConfig()
+		if got.GitHubToken != "github" || got.GitLabToken != "gitlab" || !got.NoColor {
+			t.Errorf("Expected the unprefixed variables to be honored, got %+v", got)
+		}
+	})
+
+	t.Run("PrefixedWins", func(t *testing.T) {
+		t.Setenv("GITHUB_TOKEN", "github")
+		t.Setenv("SHOULDUPDATE_TOKEN", "prefixed")
+		t.Setenv("GITLAB_TOKEN", "gitlab")
+		t.Setenv("SHOULDUPDATE_GITLAB_TOKEN", "prefixed-gitlab")
+		t.Setenv("SHOULDUPDATE_API_BASE", "https://ghe.example.com/api/v3")
+		t.Setenv("SHOULDUPDATE_USER_AGENT", "custom/2.0")
+		t.Setenv("SHOULDUPDATE_DEFAULT_SOURCE", "gitlab")
+		t.Setenv("SHOULDUPDATE_CONFIG", "/tmp/versions.yaml")
+		got := envConfig()
+		expected := envSettings{
+			GitHubToken:   "prefixed",
+			GitLabToken:   "prefixed-gitlab",
+			APIBase:       "https://ghe.example.com/api/v3",
+			UserAgent:     "custom/2.0",
+			DefaultSource: "gitlab",
+			ConfigFile:    "/tmp/versions.yaml",
+		}
+		if got != expected {
+			t.Errorf("Expected %+v, got %+v", expected, got)
+		}
+	})
+
+	t.Run("CustomPrefix", func(t *testing.T) {
+		defer func() { envPrefix = defaultEnvPrefix }()
+		envPrefix = "CUSTOM_"
+		t.Setenv("SHOULDUPDATE_TOKEN", "ignored")
+		t.Setenv("CUSTOM_TOKEN", "custom")
+		t.Setenv("CUSTOM_NO_COLOR", "1")
+		got := envConfig()
+		if got.GitHubToken != "custom" || !got.NoColor {
+			t.Errorf("Expected the variables under the custom prefix, got %+v", got)
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// exitInterrupted is the exit code after SIGINT or SIGTERM, following the shell convention for Ctrl-C.
+const exitInterrupted = 130
+
+// errInterrupted is returned by writeFileAtomic once an interrupt has started cleaning up.
+var errInterrupted = errors.New("interrupted")
+
+// tempFiles tracks the temporary files of writes in progress, so an interrupt can remove them.
+// Renames into place happen under the same lock, so once an interrupt has cleaned up, no write
+// completes: each file is left either entirely old or entirely new.
+var tempFiles = struct {
+	sync.Mutex
+	paths       map[string]bool
+	interrupted bool
+}{paths: make(map[string]bool)}
+
+// writeFileAtomic writes data to path via a temporary file in the same directory that is
+// renamed into place, so readers never see a partially written file. If path is a symlink,
+// as config files kept in a dotfiles repository often are, the file it points to is replaced
+// instead, in its own directory, so the link is preserved.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	path, err := resolveSymlinks(path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("could not create temporary file in '%s': %w", dir, err)
+	}
+	tmpPath := tmp.Name()
+	trackTempFile(tmpPath)
+	defer untrackTempFile(tmpPath) // Removes the file too; a no-op once renamed.
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write '%s': %w", tmpPath, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not set permissions on '%s': %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write '%s': %w", tmpPath, err)
+	}
+
+	tempFiles.Lock()
+	defer tempFiles.Unlock()
+	if tempFiles.interrupted {
+		return fmt.Errorf("could not replace '%s': %w", path, errInterrupted)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("could not replace '%s': %w", path, err)
+	}
+	return nil
+}
+
+// maxSymlinkHops is how many symlinks resolveSymlinks follows before giving up, as the kernel does.
+const maxSymlinkHops = 40
+
+// resolveSymlinks returns the file that path ultimately refers to, following any chain of
+// symlinks. Unlike filepath.EvalSymlinks, the final target need not exist yet. Paths that
+// aren't symlinks, or can't be inspected, are returned unchanged.
+func resolveSymlinks(path string) (string, error) {
+	for range maxSymlinkHops {
+		info, err := os.Lstat(path)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", fmt.Errorf("could not resolve symlink '%s': %w", path, err)
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return "", fmt.Errorf("could not resolve '%s': too many levels of symbolic links", path)
+}
+
+// trackTempFile records a temporary file for removeTempFiles.
+func trackTempFile(path string) {
+	tempFiles.Lock()
+	defer tempFiles.Unlock()
+	tempFiles.paths[path] = true
+}
+
+// untrackTempFile removes a temporary file, if it still exists, and stops tracking it.
+func untrackTempFile(path string) {
+	tempFiles.Lock()
+	defer tempFiles.Unlock()
+	os.Remove(path)
+	delete(tempFiles.paths, path)
+}
+
+// removeTempFiles removes the temporary files of all writes in progress and stops any of them
+// from completing.
+func removeTempFiles() {
+	tempFiles.Lock()
+	defer tempFiles.Unlock()
+	tempFiles.interrupted = true
+	for path := range tempFiles.paths {
+		os.Remove(path)
+		delete(tempFiles.paths, path)
+	}
+}
+
+// exitAfterInterrupt ends the process once an interrupt has been cleaned up. Tests can override it.
+var exitAfterInterrupt = os.Exit
+
+// installInterruptHandler cleans up after SIGINT and SIGTERM instead of dying mid-write.
+func installInterruptHandler() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go handleInterrupts(signals)
+}
+
+// handleInterrupts waits for a signal, cancels requests in flight, removes temporary files and
+// exits with exitInterrupted. As in git, SIGINT is ignored while a pager runs: Ctrl-C reaches the
+// pager too, and pagers like less use it for themselves. Before exiting, the pager's input is
+// closed and it is waited for, so it never outlives the process holding the terminal.
+func handleInterrupts(signals <-chan os.Signal) {
+	for sig := range signals {
+		if sig == os.Interrupt && pagerActive() {
+			continue
+		}
+		cancelRequests()
+		removeTempFiles()
+		waitForPager()
+		exitAfterInterrupt(exitInterrupted)
+		return
+	}
+}
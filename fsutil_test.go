@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInterruptRemovesTempFiles(t *testing.T) {
+	path := useTestConfigFile(t)
+	dir := filepath.Dir(path)
+	defer func(original func(int)) {
+		exitAfterInterrupt = original
+		requestContext, cancelRequests = context.WithCancel(context.Background())
+		tempFiles.Lock()
+		tempFiles.interrupted = false
+		tempFiles.Unlock()
+	}(exitAfterInterrupt)
+	if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+
+	// A save that was interrupted after creating its temporary file.
+	tmp, err := os.CreateTemp(dir, ".versions.toml.tmp-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmp.Close()
+	trackTempFile(tmp.Name())
+
+	exitCodes := make(chan int, 1)
+	exitAfterInterrupt = func(code int) { exitCodes <- code }
+	signals := make(chan os.Signal, 1)
+	signals <- os.Interrupt
+	handleInterrupts(signals)
+
+	if code := <-exitCodes; code != exitInterrupted {
+		t.Errorf("Expected exit code %d, got %d", exitInterrupted, code)
+	}
+	if !errors.Is(requestContext.Err(), context.Canceled) {
+		t.Errorf("Expected requests in flight to be cancelled, got %v", requestContext.Err())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to list %s: %v", dir, err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != filepath.Base(path) {
+			t.Errorf("Expected only the config file to remain, found %s", entry.Name())
+		}
+	}
+
+	// Saves that were still in progress must not replace the config afterwards.
+	if err := saveConfig(Config{"owner/repo": "2.0.0"}); !errors.Is(err, errInterrupted) {
+		t.Errorf("Expected a save after the interrupt to fail, got %v", err)
+	}
+	if after, err := os.ReadFile(path); err != nil || string(after) != string(before) {
+		t.Errorf("Expected the previous config to remain intact, got %q (%v)", after, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected the failed save to clean up its temp file, got %d entries", len(entries))
+	}
+}
+
+func TestInterruptWaitsForPager(t *testing.T) {
+	defer func(original func(int)) {
+		exitAfterInterrupt = original
+		requestContext, cancelRequests = context.WithCancel(context.Background())
+		tempFiles.Lock()
+		tempFiles.interrupted = false
+		tempFiles.Unlock()
+	}(exitAfterInterrupt)
+	exitCodes := make(chan int, 1)
+	exitAfterInterrupt = func(code int) { exitCodes <- code }
+
+	paged := captureOutput(func() {
+		closePager, err := startPager("tr a-z A-Z")
+		if err != nil {
+			t.Fatalf("Failed to start pager: %v", err)
+		}
+		defer closePager()
+		signals := make(chan os.Signal, 1)
+		done := make(chan struct{})
+		go func() {
+			handleInterrupts(signals)
+			close(done)
+		}()
+
+		// Ctrl-C belongs to the pager while it runs.
+		signals <- os.Interrupt
+		PrintMessage("still paging")
+		select {
+		case code := <-exitCodes:
+			t.Fatalf("Expected SIGINT to be ignored while paging, exited with %d", code)
+		case <-time.After(50 * time.Millisecond):
+		}
+		if requestContext.Err() != nil {
+			t.Errorf("Expected requests to continue after SIGINT while paging, got %v", requestContext.Err())
+		}
+
+		signals <- syscall.SIGTERM
+		<-done
+		if code := <-exitCodes; code != exitInterrupted {
+			t.Errorf("Expected exit code %d, got %d", exitInterrupted, code)
+		}
+	})
+	// The pager got all output and had exited by the time the process would have.
+	if !strings.Contains(paged, "STILL PAGING") {
+		t.Errorf("Expected the pager to have flushed its output before exiting, got %q", paged)
+	}
+}
+
+func TestWriteFileAtomicThroughSymlink(t *testing.T) {
+	path := useTestConfigFile(t)
+	dotfiles := t.TempDir()
+	target := filepath.Join(dotfiles, "versions.toml")
+	if err := os.WriteFile(target, []byte("\"owner/repo\" = \"1.0.0\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	// A relative link, resolved against the link's own directory.
+	relative, err := filepath.Rel(filepath.Dir(path), target)
+	if err != nil {
+		t.Fatalf("Failed to make a relative path: %v", err)
+	}
+	if err := os.Symlink(relative, path); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	if err := saveConfig(Config{"owner/repo": "2.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if info, err := os.Lstat(path); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("Expected the config to still be a symlink, got %v (%v)", info, err)
+	}
+	if cfg, err := loadConfig(); err != nil || cfg["owner/repo"] != "2.0.0" {
+		t.Errorf("Expected the saved version through the link, got %v (%v)", cfg, err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil || !strings.Contains(string(data), "2.0.0") {
+		t.Errorf("Expected the link's target to be written, got %q (%v)", data, err)
+	}
+	for _, dir := range []string{filepath.Dir(path), dotfiles} {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if strings.Contains(entry.Name(), ".tmp-") {
+				t.Errorf("Expected no temporary files to be left behind, found %s in %s", entry.Name(), dir)
+			}
+		}
+	}
+
+	// A link whose target doesn't exist yet is followed too.
+	dangling := filepath.Join(dotfiles, "new.toml")
+	linkPath := filepath.Join(filepath.Dir(path), "new.toml")
+	if err := os.Symlink(dangling, linkPath); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := writeFileAtomic(linkPath, []byte("x"), 0600); err != nil {
+		t.Fatalf("Failed to write through a dangling link: %v", err)
+	}
+	if data, err := os.ReadFile(dangling); err != nil || string(data) != "x" {
+		t.Errorf("Expected the link's target to be created, got %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import "fmt"
+
+// Fields accepted by 'get -field'.
+const (
+	fieldVersion = "version" // The recorded version.
+	fieldLatest  = "latest"  // The latest released version, fetched from the app's source.
+)
+
+// handleGetCmd prints a single field of a tracked application with nothing around it, for
+// use in scripts. It returns the process exit code: 0 on success, 1 otherwise.
+func handleGetCmd(appName, field string) int {
+	config, err := loadConfig()
+	if err != nil {
+		PrintError("Could not load configuration: %v", err)
+		return 1
+	}
+	appName = resolveAppName(config, appName)
+	version, exists := config[appName]
+	if !exists {
+		PrintError("Application '%s' not found in your managed list.", Colorize(appName, colorYellowFg))
+		return 1
+	}
+
+	switch field {
+	case fieldVersion:
+		fmt.Fprintln(outputWriter(), version)
+	case fieldLatest:
+		latest, err := lookupLatestVersion(requestContext, appName)
+		if err != nil {
+			PrintError("Failed to get the latest version of %s: %v", Colorize(appName, colorMagentaFg), err)
+			return 1
+		}
+		fmt.Fprintln(outputWriter(), latest)
+	default:
+		PrintError("Unknown field '%s'. Supported fields: %s, %s.", field, fieldVersion, fieldLatest)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestHandleGetCommand(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	lookups := 0
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		lookups++
+		return "2.0.0", nil
+	}
+	if err := saveConfig(Config{"owner/repo": "1.5.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	t.Run("Version", func(t *testing.T) {
+		var exitCode int
+		output := captureOutput(func() { exitCode = handleGetCmd("owner/repo", fieldVersion) })
+		if output != "1.5.0\n" || exitCode != 0 {
+			t.Errorf("Expected just the recorded version, got %q (exit %d)", output, exitCode)
+		}
+		if lookups != 0 {
+			t.Errorf("Expected no lookups for the recorded version, got %d", lookups)
+		}
+	})
+
+	t.Run("Latest", func(t *testing.T) {
+		var exitCode int
+		output := captureOutput(func() { exitCode = handleGetCmd("owner/repo", fieldLatest) })
+		if output != "2.0.0\n" || exitCode != 0 {
+			t.Errorf("Expected just the latest version, got %q (exit %d)", output, exitCode)
+		}
+	})
+
+	t.Run("NotTracked", func(t *testing.T) {
+		var exitCode int
+		var output string
+		errOutput := captureStderr(t, func() {
+			output = captureOutput(func() { exitCode = handleGetCmd("owner/unknown", fieldVersion) })
+		})
+		if exitCode != 1 || output != "" || !strings.Contains(errOutput, "Application 'owner/unknown' not found in your managed list.") {
+			t.Errorf("Expected a clear error and exit 1, got exit %d, stdout %q, stderr %q", exitCode, output, errOutput)
+		}
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		var exitCode int
+		errOutput := captureStderr(t, func() { exitCode = handleGetCmd("owner/repo", "owner") })
+		if exitCode != 1 || !strings.Contains(errOutput, "Unknown field 'owner'") {
+			t.Errorf("Expected an unknown field error, got exit %d, stderr %q", exitCode, errOutput)
+		}
+	})
+}
@@ -2,4 +2,10 @@ module github.com/renoinn/shouldupdate
 
 go 1.24.2
 
-require github.com/BurntSushi/toml v1.5.0 // indirect
+require (
+	github.com/BurntSushi/toml v1.5.0
+	golang.org/x/term v0.32.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.33.0 // indirect
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"sort"
+)
+
+// commandUsage is the help for one command, printed by '<command> -h' and 'help <command>'.
+type commandUsage struct {
+	synopses    []string // Invocations, without the program name.
+	description []string
+	examples    []string // Command lines, without the program name.
+}
+
+// commandUsages holds the help of every command, by name.
+var commandUsages = map[string]commandUsage{
+	"add": {
+		synopses: []string{
+			"add [-as <alias>] [-note <text>] [-monotonic] [-force] <application_name> <version>",
+			"add -from-file <path> [-overwrite] [-cacert <file>]",
+			"add -repo-file <go.mod|requirements file> [-y]",
+		},
+		description: []string{"Adds an application to monitor at the given version, or replaces its recorded version."},
+		examples: []string{
+			"add myapp 1.0.2",
+			"add -as bat sharkdp/bat 0.24.0",
+			"add -note \"used by project X\" owner/repo 1.0.0",
+			"add -from-file repos.txt",
+			"add -repo-file go.mod",
+		},
+	},
+	"remove": {
+		synopses:    []string{"remove <application_name>"},
+		description: []string{"Stops monitoring an application."},
+		examples:    []string{"remove myapp"},
+	},
+	"reset": {
+		synopses:    []string{"reset [-y]"},
+		description: []string{"Removes every application from the configuration."},
+	},
+	"import": {
+		synopses: []string{"import [-merge-strategy keep|overwrite|newest | -replace-all [-y]] <file.toml>"},
+		description: []string{
+			"Adds or updates the applications listed in a TOML file in the config format.",
+			"Versions of apps already tracked are kept unless -merge-strategy says otherwise.",
+			"With -replace-all, applications not in the file are removed (a backup is kept).",
+		},
+	},
+	"compare": {
+		synopses:    []string{"compare <old.toml> <new.toml>"},
+		description: []string{"Shows which applications were added, removed or changed version between two config files."},
+		examples:    []string{"compare before.toml after.toml"},
+	},
+	"rollback": {
+		synopses:    []string{"rollback [-list] [-y] [<number>|<backup file>]"},
+		description: []string{"Restores the config from a backup, the newest unless one is given; the current config is backed up first."},
+		examples:    []string{"rollback -list", "rollback 2"},
+	},
+	"list": {
+		synopses:    []string{"list [-group-by owner] [-sort name|version] [-notes] [-descriptions] [-o <path>] [-pager <command> | -no-pager]"},
+		description: []string{"Lists the monitored applications and their recorded versions."},
+	},
+	"get": {
+		synopses:    []string{"get <application_name> [-field version|latest]"},
+		description: []string{"Prints one field of an application, for scripts."},
+		examples:    []string{"get owner/repo -field latest"},
+	},
+	"validate": {
+		synopses:    []string{"validate"},
+		description: []string{"Checks the configuration for problems without making network requests."},
+	},
+	"doctor": {
+		synopses:    []string{"doctor [-token <token>] [-cacert <file>]"},
+		description: []string{"Diagnoses the setup: config file, token, connectivity and rate limit. Changes nothing."},
+	},
+	"token": {
+		synopses:    []string{"token set|clear|status"},
+		description: []string{"Saves a GitHub token read from stdin (without echo) to a private file, removes it, or reports whether one is configured."},
+	},
+	"releases": {
+		synopses:    []string{"releases [-limit <n>] [-sort date|semver] [-token <token>] [-cacert <file>] <owner/repo>"},
+		description: []string{"Lists the most recent releases of a GitHub repository with their tag, name, date and URL."},
+		examples:    []string{"releases -limit 5 sharkdp/bat"},
+	},
+	"changelog": {
+		synopses:    []string{"changelog [-token <token>] [-cacert <file>]"},
+		description: []string{"Prints the release notes of every application with an update available."},
+	},
+	"status": {
+		synopses:    []string{"status [-no-fetch] [-time-format relative|rfc3339|<layout>] [-token <token>] [-cacert <file>]"},
+		description: []string{"Shows every application's recorded and latest version and status in a table."},
+	},
+	"check": {
+		synopses:    []string{"check [options] [<application_name>]"},
+		description: []string{"Checks one or all monitored applications for updates."},
+		examples: []string{
+			"check myapp",
+			"check",
+			"check owner/repo -target 2.0.0",
+			"check -apps owner/a,owner/b",
+			"check -on-update 'notify-send {app} {latest}'",
+		},
+	},
+}
+
+// setCommandUsages makes each of commands print its help, followed by its flags, for -h or -help.
+func setCommandUsages(commands []*flag.FlagSet) {
+	for _, fs := range commands {
+		fs.Usage = func() { printCommandUsage(fs) }
+	}
+}
+
+// printCommandUsage prints the help of the command fs parses the flags of, then describes its flags.
+func printCommandUsage(fs *flag.FlagSet) {
+	usage := commandUsages[fs.Name()]
+	for i, synopsis := range usage.synopses {
+		if i == 0 {
+			PrintUsageMessage("Usage: %s %s", os.Args[0], synopsis)
+		} else {
+			PrintUsageMessage("       %s %s", os.Args[0], synopsis)
+		}
+	}
+	for _, line := range usage.description {
+		PrintUsageMessage("%s", line)
+	}
+	for _, example := range usage.examples {
+		PrintUsageMessage("Example: %s %s", Colorize(os.Args[0], colorCyanFg), example)
+	}
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		PrintUsageMessage("Options:")
+		fs.SetOutput(errorWriter())
+		fs.PrintDefaults()
+	}
+}
+
+// handleHelpCmd prints the help of the command named in args, one of commands, or the overall
+// usage without one. It returns the process exit code.
+func handleHelpCmd(commands []*flag.FlagSet, args []string) int {
+	if len(args) == 0 {
+		printOverallUsage()
+		return 0
+	}
+	for _, fs := range commands {
+		if fs.Name() == args[0] {
+			fs.Usage()
+			return 0
+		}
+	}
+	names := make([]string, 0, len(commands))
+	for _, fs := range commands {
+		names = append(names, fs.Name())
+	}
+	sort.Strings(names)
+	PrintError("Unknown command '%s'. Commands: %v", args[0], names)
+	return 1
+}
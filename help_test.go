@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCommandUsage(t *testing.T) {
+	var commands []*flag.FlagSet
+	for name := range commandUsages {
+		commands = append(commands, flag.NewFlagSet(name, flag.ContinueOnError))
+	}
+	setCommandUsages(commands)
+
+	for _, fs := range commands {
+		t.Run(fs.Name(), func(t *testing.T) {
+			usage := commandUsages[fs.Name()]
+			var exitCode int
+			output := captureStderr(t, func() { exitCode = handleHelpCmd(commands, []string{fs.Name()}) })
+			if exitCode != 0 {
+				t.Errorf("Expected exit code 0, got %d", exitCode)
+			}
+			if !strings.HasPrefix(output, "Usage: "+os.Args[0]+" "+fs.Name()) {
+				t.Errorf("Expected the command's usage first, got:\n%s", output)
+			}
+			if len(usage.description) == 0 || !strings.Contains(output, usage.description[0]) {
+				t.Errorf("Expected a description, got:\n%s", output)
+			}
+			if strings.Contains(output, "Options:") {
+				t.Errorf("Expected no options section for a command without flags, got:\n%s", output)
+			}
+
+			// -h prints the same, followed by the command's flags.
+			fs.Bool("y", false, "Don't ask for confirmation")
+			fs.SetOutput(os.Stderr)
+			var err error
+			help := captureStderr(t, func() { err = fs.Parse([]string{"-h"}) })
+			if err != flag.ErrHelp {
+				t.Errorf("Expected flag.ErrHelp, got %v", err)
+			}
+			if !strings.HasPrefix(help, output) || !strings.Contains(help, "Options:\n  -y\tDon't ask for confirmation") {
+				t.Errorf("Expected the usage followed by the flags, got:\n%s", help)
+			}
+		})
+	}
+
+	t.Run("Overall", func(t *testing.T) {
+		var exitCode int
+		output := stripAnsiCodes(captureOutput(func() {
+			captureStderr(t, func() { exitCode = handleHelpCmd(commands, nil) })
+		}))
+		if exitCode != 0 || !strings.Contains(output, "help [<command>]") {
+			t.Errorf("Expected the overall usage listing help (exit 0), got exit %d and:\n%s", exitCode, output)
+		}
+	})
+
+	t.Run("UnknownCommand", func(t *testing.T) {
+		var exitCode int
+		output := captureStderr(t, func() { exitCode = handleHelpCmd(commands, []string{"frobnicate"}) })
+		if exitCode != 1 || !strings.Contains(output, "Unknown command 'frobnicate'") {
+			t.Errorf("Expected an unknown command to fail, got exit %d and:\n%s", exitCode, output)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// expandHookCommand splits the 'check -on-update' command on whitespace and fills in the
+// {app}, {current} and {latest} placeholders of each field from result. Placeholders are
+// replaced after splitting, so a value never turns into extra arguments.
+func expandHookCommand(command string, result checkResult) []string {
+	replacer := strings.NewReplacer("{app}", result.appName, "{current}", result.currentVersion, "{latest}", result.latestVersion)
+	fields := strings.Fields(command)
+	for i, field := range fields {
+		fields[i] = replacer.Replace(field)
+	}
+	return fields
+}
+
+// runUpdateHooks runs command once for each app in results with an update available, after all
+// checks are done, and prints each command's output and exit status. Commands are run directly,
+// without a shell. With toStderr, the report goes to stderr so machine-readable output stays
+// clean. It returns the number of commands that failed.
+func runUpdateHooks(command string, results []checkResult, toStderr bool) int {
+	if toStderr {
+		previousOutput := output
+		output = errorWriter()
+		defer func() { output = previousOutput }()
+	}
+	ran, failed := 0, 0
+	for _, result := range results {
+		if result.status != statusUpdateAvailable {
+			continue
+		}
+		fields := expandHookCommand(command, result)
+		if len(fields) == 0 {
+			return 0
+		}
+		ran++
+		out, err := runCommand(fields[0], fields[1:]...)
+		commandLine := strings.Join(fields, " ")
+		if err != nil {
+			failed++
+			PrintError("Hook for %s failed (%s): %s", Colorize(result.appName, colorYellowFg), hookExitStatus(err), commandLine)
+		} else {
+			PrintSuccess("Hook for %s succeeded: %s", Colorize(result.appName, colorYellowFg), commandLine)
+		}
+		if text := strings.TrimRight(string(out), "\n"); text != "" {
+			for _, line := range strings.Split(text, "\n") {
+				PrintMessage("    %s", line)
+			}
+		}
+	}
+	if ran > 0 {
+		PrintInfo("Ran %s for available updates; %d failed.", pluralize(ran, "hook", "hooks"), failed)
+	}
+	return failed
+}
+
+// hookExitStatus describes how a hook command failed: its exit code if it ran, or else why it
+// couldn't be started.
+func hookExitStatus(err error) string {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Sprintf("exit status %d", exitErr.ExitCode())
+	}
+	return err.Error()
+}
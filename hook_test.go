@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandHookCommand(t *testing.T) {
+	result := checkResult{appName: "owner/repo", currentVersion: "1.0.0", latestVersion: "1.1.0"}
+	got := expandHookCommand("install.sh --app={app} {current}..{latest}", result)
+	expected := []string{"install.sh", "--app=owner/repo", "1.0.0..1.1.0"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	// A value with spaces stays a single argument.
+	result.latestVersion = "1.1.0 rm -rf"
+	if got := expandHookCommand("echo {latest}", result); len(got) != 2 || got[1] != "1.1.0 rm -rf" {
+		t.Errorf("Expected the value to be substituted as one argument, got %q", got)
+	}
+}
+
+func TestCheckOnUpdate(t *testing.T) {
+	useTestConfigFile(t)
+	ran := useFakeCommands(t, map[string]string{
+		"notify owner/a 1.0.0 1.1.0": "sent\n",
+	})
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return map[string]string{"owner/a": "1.1.0", "owner/b": "2.1.0", "owner/c": "3.0.0"}[appIdentifier], nil
+	}
+	if err := saveConfig(Config{"owner/a": "1.0.0", "owner/b": "2.0.0", "owner/c": "3.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	var exitCode int
+	output := stripAnsiCodes(captureOutput(func() {
+		exitCode = handleCheckCmd("", checkOptions{onUpdate: "notify {app} {current} {latest}"})
+	}))
+	expectedRan := []string{"notify owner/a 1.0.0 1.1.0", "notify owner/b 2.0.0 2.1.0"}
+	if !reflect.DeepEqual(*ran, expectedRan) {
+		t.Errorf("Expected hooks %q, got %q", expectedRan, *ran)
+	}
+	for _, expected := range []string{
+		"Hook for owner/a succeeded: notify owner/a 1.0.0 1.1.0",
+		"    sent",
+		"Ran 2 hooks for available updates; 1 failed.",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+	if strings.Index(output, "Hook for") < strings.Index(output, "Checking owner/c") {
+		t.Errorf("Expected hooks to run after all checks, got:\n%s", output)
+	}
+	if exitCode != exitCheckFailed {
+		t.Errorf("Expected exit code %d when a hook fails, got %d", exitCheckFailed, exitCode)
+	}
+
+	*ran = nil
+	output = captureOutput(func() {
+		handleCheckCmd("owner/a", checkOptions{format: formatNDJSON, onUpdate: "notify {app} {current} {latest}"})
+	})
+	if len(*ran) != 1 || strings.Contains(output, "Hook for") {
+		t.Errorf("Expected the hook report to stay out of ndjson output, ran %q, got: %s", *ran, output)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+// How 'import -merge-strategy' resolves an app tracked at different versions in the config and
+// in the imported file.
+const (
+	mergeKeep      = "keep"      // The existing version wins.
+	mergeOverwrite = "overwrite" // The imported version wins.
+	mergeNewest    = "newest"    // The higher version wins, compared like compareVersions; a tie keeps the existing one.
+)
+
+// importConflict is an app tracked at different versions in the config and the imported file.
+type importConflict struct {
+	appName            string
+	existing, incoming string
+	incomingWins       bool
+}
+
+// importSummary counts what an import changed. Conflicts are in app name order; updated counts
+// those the imported version won, and kept those the existing one did.
+type importSummary struct {
+	added, removed, updated, kept, unchanged int
+	conflicts                                []importConflict
+}
+
+// planImport returns the config that results from importing imported into config, along with
+// what changed. With replaceAll, apps missing from imported are removed and imported versions
+// always win; otherwise the apps are kept and strategy, one of the merge* constants, decides
+// between differing versions.
+func planImport(config, imported Config, replaceAll bool, strategy string) (Config, importSummary) {
+	if replaceAll {
+		strategy = mergeOverwrite
+	}
+	var summary importSummary
+	result := make(Config, len(imported))
+	for appName, version := range config {
+		if _, ok := imported[appName]; !ok {
+			if replaceAll {
+				summary.removed++
+				continue
+			}
+			result[appName] = version
+		}
+	}
+	for _, appName := range sortedAppNames(imported) {
+		version := imported[appName]
+		current, exists := config[appName]
+		switch {
+		case !exists:
+			summary.added++
+		case current != version:
+			conflict := importConflict{appName: appName, existing: current, incoming: version}
+			switch strategy {
+			case mergeOverwrite:
+				conflict.incomingWins = true
+			case mergeNewest:
+				conflict.incomingWins = compareVersions(version, current) > 0
+			}
+			summary.conflicts = append(summary.conflicts, conflict)
+			if !conflict.incomingWins {
+				summary.kept++
+				version = current
+			} else {
+				summary.updated++
+			}
+		default:
+			summary.unchanged++
+		}
+		result[appName] = version
+	}
+	return result, summary
+}
+
+// printImportConflicts shows, for each app tracked at different versions, which version won.
+func printImportConflicts(conflicts []importConflict) {
+	for _, conflict := range conflicts {
+		winner, winning, losing, losingVersion := "existing", conflict.existing, "incoming", conflict.incoming
+		if conflict.incomingWins {
+			winner, winning, losing, losingVersion = "incoming", conflict.incoming, "existing", conflict.existing
+		}
+		PrintMessage("  ~ %s: %s %s wins over %s %s", Colorize(conflict.appName, colorYellowFg),
+			winner, Colorize(winning, colorCyanFg), losing, losingVersion)
+	}
+}
+
+// handleImportCmd merges the applications listed in a TOML file into the configuration,
+// resolving differing versions by strategy (see planImport).
+// With replaceAll, the file becomes the complete list of tracked applications: after
+// confirmation (unless skipConfirm) and a backup, everything is replaced in a single save.
+// Options given in the file, such as a note or version_field, come along: for every app with
+// replaceAll, otherwise for the apps it adds.
+func handleImportCmd(path, strategy string, replaceAll, skipConfirm bool) {
+	imported, importedOptions, err := readAppsFileWithOptions(path)
+	if err != nil {
+		PrintError("Could not read '%s': %v", path, err)
+		return
+	}
+	config, err := loadConfig()
+	if err != nil {
+		PrintError("Could not load configuration: %v", err)
+		return
+	}
+
+	result, summary := planImport(config, imported, replaceAll, strategy)
+	printImportConflicts(summary.conflicts)
+	if summary.added+summary.removed+summary.updated == 0 {
+		if summary.kept > 0 {
+			PrintInfo("Nothing to import; kept the existing versions of %s.", pluralize(summary.kept, "conflicting application", "conflicting applications"))
+			return
+		}
+		PrintInfo("Nothing to import; all %s already tracked at the same versions.", pluralize(summary.unchanged, "application is", "applications are"))
+		return
+	}
+
+	if replaceAll {
+		for _, appName := range sortedAppNames(config) {
+			if _, ok := imported[appName]; !ok {
+				PrintMessage("  - %s %s", Colorize(appName, colorRedFg), Colorize(config[appName], colorCyanFg))
+			}
+		}
+		if !skipConfirm && !Confirm("Replace the tracked applications with the %d in '%s', removing %d?", len(imported), path, summary.removed) {
+			PrintInfo("Import aborted. No changes made.")
+			return
+		}
+		backupPath, err := backupConfig()
+		if err != nil {
+			PrintError("%v", err)
+			return
+		}
+		if backupPath != "" {
+			PrintInfo("Backed up the previous configuration to %s.", backupPath)
+		}
+	}
+
+	for _, appName := range sortedAppNames(imported) {
+		if _, exists := config[appName]; exists && !replaceAll {
+			continue
+		}
+		if opts, ok := importedOptions[appName]; ok {
+			appOptions[appName] = opts
+		} else {
+			delete(appOptions, appName)
+		}
+	}
+	if err := saveConfig(result); err != nil {
+		PrintError("Could not save configuration: %v", err)
+		return
+	}
+	PrintSuccess("Added %d, removed %d, updated %d, kept %d, unchanged %d.", summary.added, summary.removed, summary.updated, summary.kept, summary.unchanged)
+}
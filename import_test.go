@@ -0,0 +1,213 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeImportFile writes content to a TOML file in a temp dir and returns its path.
+func writeImportFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "apps.toml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write import file: %v", err)
+	}
+	return path
+}
+
+func TestPlanImport(t *testing.T) {
+	config := Config{"owner/keep": "1.0.0", "owner/bump": "1.0.0", "owner/drop": "1.0.0"}
+	imported := Config{"owner/keep": "1.0.0", "owner/bump": "2.0.0", "owner/new": "0.1.0"}
+
+	bumped := []importConflict{{appName: "owner/bump", existing: "1.0.0", incoming: "2.0.0", incomingWins: true}}
+
+	result, summary := planImport(config, imported, true, mergeKeep)
+	if !reflect.DeepEqual(result, imported) {
+		t.Errorf("Expected replace-all to yield exactly the imported apps, got %v", result)
+	}
+	if !reflect.DeepEqual(summary, importSummary{added: 1, removed: 1, updated: 1, unchanged: 1, conflicts: bumped}) {
+		t.Errorf("Unexpected replace-all summary: %+v", summary)
+	}
+
+	result, summary = planImport(config, imported, false, mergeOverwrite)
+	if result["owner/drop"] != "1.0.0" || result["owner/bump"] != "2.0.0" || len(result) != 4 {
+		t.Errorf("Expected a merge to keep unlisted apps, got %v", result)
+	}
+	if !reflect.DeepEqual(summary, importSummary{added: 1, updated: 1, unchanged: 1, conflicts: bumped}) {
+		t.Errorf("Unexpected merge summary: %+v", summary)
+	}
+}
+
+func TestPlanImportMergeStrategies(t *testing.T) {
+	config := Config{"owner/older": "1.0.0", "owner/newer": "3.0.0", "owner/same": "1.0.0"}
+	imported := Config{"owner/older": "2.0.0", "owner/newer": "2.5.0", "owner/same": "1.0.0"}
+
+	tests := []struct {
+		strategy string
+		expected Config
+		wins     map[string]bool // Whether the incoming version won, per conflicting app.
+	}{
+		{mergeKeep, Config{"owner/older": "1.0.0", "owner/newer": "3.0.0", "owner/same": "1.0.0"},
+			map[string]bool{"owner/newer": false, "owner/older": false}},
+		{mergeOverwrite, Config{"owner/older": "2.0.0", "owner/newer": "2.5.0", "owner/same": "1.0.0"},
+			map[string]bool{"owner/newer": true, "owner/older": true}},
+		{mergeNewest, Config{"owner/older": "2.0.0", "owner/newer": "3.0.0", "owner/same": "1.0.0"},
+			map[string]bool{"owner/newer": false, "owner/older": true}},
+	}
+	for _, tt := range tests {
+		result, summary := planImport(config, imported, false, tt.strategy)
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.strategy, tt.expected, result)
+		}
+		wins := make(map[string]bool)
+		updated := 0
+		for _, conflict := range summary.conflicts {
+			wins[conflict.appName] = conflict.incomingWins
+			if conflict.incomingWins {
+				updated++
+			}
+		}
+		if !reflect.DeepEqual(wins, tt.wins) {
+			t.Errorf("%s: expected winners %v, got %v", tt.strategy, tt.wins, wins)
+		}
+		if summary.updated != updated || summary.kept != 2-updated || summary.unchanged != 1 {
+			t.Errorf("%s: unexpected counts %+v", tt.strategy, summary)
+		}
+	}
+}
+
+func TestHandleImportCommand(t *testing.T) {
+	seed := Config{"owner/keep": "1.0.0", "owner/bump": "1.0.0", "owner/drop": "1.0.0"}
+	importPath := writeImportFile(t, "\"owner/keep\" = \"1.0.0\"\n\"owner/bump\" = \"2.0.0\"\n\"owner/new\" = \"0.1.0\"\n")
+
+	t.Run("ReplaceAllConfirmed", func(t *testing.T) {
+		configPath := useTestConfigFile(t)
+		if err := saveConfig(seed); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		useScriptedInput(t, "y\n", true)
+		output := stripAnsiCodes(captureOutput(func() { handleImportCmd(importPath, mergeKeep, true, false) }))
+
+		if !strings.Contains(output, "  - owner/drop 1.0.0") || !strings.Contains(output, "removing 1? [y/N]") {
+			t.Errorf("Expected the removed apps and a confirmation prompt. Got:\n%s", output)
+		}
+		if !strings.Contains(output, "  ~ owner/bump: incoming 2.0.0 wins over existing 1.0.0\n") {
+			t.Errorf("Expected the imported version to win with -replace-all. Got:\n%s", output)
+		}
+		if !strings.Contains(output, "Success: Added 1, removed 1, updated 1, kept 0, unchanged 1.") {
+			t.Errorf("Expected a summary line. Got:\n%s", output)
+		}
+		cfg, _ := loadConfig()
+		if !reflect.DeepEqual(cfg, Config{"owner/keep": "1.0.0", "owner/bump": "2.0.0", "owner/new": "0.1.0"}) {
+			t.Errorf("Expected the config to match the imported file, got %v", cfg)
+		}
+
+		// The previous configuration is kept as a backup.
+		backups, _ := listConfigBackups()
+		if len(backups) != 1 || !strings.HasPrefix(backups[0], configPath+".") {
+			t.Fatalf("Expected a backup file next to the config, got %v", backups)
+		}
+		backup, err := os.ReadFile(backups[0])
+		if err != nil {
+			t.Fatalf("Expected a backup file: %v", err)
+		}
+		if !strings.Contains(string(backup), "owner/drop") {
+			t.Errorf("Expected the backup to hold the previous config, got:\n%s", backup)
+		}
+	})
+
+	t.Run("ReplaceAllKeepsOptions", func(t *testing.T) {
+		useTestConfigFile(t)
+		defer func() { appOptions = make(map[string]AppOptions) }()
+		appOptions["owner/keep"] = AppOptions{Note: "old note"}
+		if err := saveConfig(seed); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		withOptions := writeImportFile(t, "[\"owner/bump\"]\nversion = \"2.0.0\"\nnote = \"pinned by ops\"\nversion_field = \"name\"\n\n"+
+			"[\"owner/keep\"]\nversion = \"1.0.0\"\ncompare_mode = \"exact\"\n")
+		captureOutput(func() { handleImportCmd(withOptions, mergeKeep, true, true) })
+
+		if _, err := loadConfig(); err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if opts := appOptions["owner/bump"]; opts.Note != "pinned by ops" || opts.VersionField != versionFieldName {
+			t.Errorf("Expected the imported options of owner/bump to be saved, got %+v", opts)
+		}
+		if opts := appOptions["owner/keep"]; !reflect.DeepEqual(opts, AppOptions{CompareMode: compareExact}) {
+			t.Errorf("Expected the imported options of owner/keep to replace the existing ones, got %+v", opts)
+		}
+	})
+
+	t.Run("ReplaceAllAborted", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(seed); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		useScriptedInput(t, "n\n", true)
+		output := stripAnsiCodes(captureOutput(func() { handleImportCmd(importPath, mergeKeep, true, false) }))
+		if !strings.Contains(output, "Import aborted.") {
+			t.Errorf("Expected abort message. Got:\n%s", output)
+		}
+		if cfg, _ := loadConfig(); !reflect.DeepEqual(cfg, seed) {
+			t.Errorf("Expected config to be untouched, got %v", cfg)
+		}
+		if backups, _ := listConfigBackups(); len(backups) != 0 {
+			t.Errorf("Expected no backup when aborted, got %v", backups)
+		}
+	})
+
+	t.Run("MergeKeepsUnlistedApps", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(seed); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleImportCmd(importPath, mergeKeep, false, false) }))
+		if !strings.Contains(output, "  ~ owner/bump: existing 1.0.0 wins over incoming 2.0.0\n") {
+			t.Errorf("Expected the existing version to win by default. Got:\n%s", output)
+		}
+		if !strings.Contains(output, "Success: Added 1, removed 0, updated 0, kept 1, unchanged 1.") {
+			t.Errorf("Expected a summary line. Got:\n%s", output)
+		}
+		if cfg, _ := loadConfig(); cfg["owner/drop"] != "1.0.0" || cfg["owner/new"] != "0.1.0" || cfg["owner/bump"] != "1.0.0" {
+			t.Errorf("Expected a merge, got %v", cfg)
+		}
+	})
+
+	t.Run("MergeNewest", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/bump": "1.0.0", "owner/keep": "1.5.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleImportCmd(importPath, mergeNewest, false, false) }))
+		if !strings.Contains(output, "  ~ owner/bump: incoming 2.0.0 wins over existing 1.0.0\n") ||
+			!strings.Contains(output, "  ~ owner/keep: existing 1.5.0 wins over incoming 1.0.0\n") {
+			t.Errorf("Expected the higher version to win for each app. Got:\n%s", output)
+		}
+		if cfg, _ := loadConfig(); cfg["owner/bump"] != "2.0.0" || cfg["owner/keep"] != "1.5.0" {
+			t.Errorf("Expected the newest versions, got %v", cfg)
+		}
+	})
+
+	t.Run("AllConflictsKept", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/keep": "1.0.0", "owner/bump": "3.0.0", "owner/new": "0.1.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleImportCmd(importPath, mergeKeep, false, false) }))
+		if !strings.Contains(output, "Nothing to import; kept the existing versions of 1 conflicting application.") {
+			t.Errorf("Expected nothing to be imported. Got:\n%s", output)
+		}
+	})
+
+	t.Run("InvalidFile", func(t *testing.T) {
+		useTestConfigFile(t)
+		badPath := writeImportFile(t, "\"owner/repo\" = 1\n")
+		errOutput := captureStderr(t, func() { handleImportCmd(badPath, mergeKeep, true, true) })
+		if !strings.Contains(errOutput, "must be a string") {
+			t.Errorf("Expected a parse error. Got: %s", errOutput)
+		}
+	})
+}
@@ -1,105 +1,617 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"log"
 	"os"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Old color constants are removed from here, will use ui.go
 
 func main() {
+	installInterruptHandler()
+
+	// Global flags come before the command, e.g. "shepherd -color=never check".
+	globalFlags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	globalColor := globalFlags.String("color", colorAuto, "When to use colors: always, auto or never")
+	globalAPIBase := globalFlags.String("api-base", "", "GitHub API base URL (e.g. for GitHub Enterprise or a proxy)")
+	var globalConfig configPaths
+	globalFlags.Var(&globalConfig, "config", "Config file to use; a .yaml or .yml extension selects YAML instead of TOML. Repeat to merge several, later ones winning; the config can then only be read")
+	globalEnvPrefix := globalFlags.String("env-prefix", defaultEnvPrefix, "Prefix of the environment variables to read settings from")
+	globalDropEmpty := globalFlags.Bool("drop-empty", false, "Ignore config entries with an empty name or version, removing them on the next save")
+	globalInsecure := globalFlags.Bool("insecure-skip-verify", false, "DANGEROUS: don't verify TLS certificates; only for testing against self-signed servers")
+	globalKeepVPrefix := globalFlags.Bool("keep-v-prefix", false, "Keep the leading \"v\" of release tags instead of stripping it")
+	globalLimitRate := globalFlags.Int("limit-rate", 0, "Read release notes, assets and release history no faster than this many KB/s (0 for no limit)")
+	globalFlags.Usage = printOverallUsage
+
 	// Define common flag sets for subcommands
 	addCmd := flag.NewFlagSet("add", flag.ExitOnError)
+	addFromFile := addCmd.String("from-file", "", "Add every owner/repo listed in a file at its current latest version")
+	addOverwrite := addCmd.Bool("overwrite", false, "With -from-file, re-record apps that are already tracked")
+	addRepoFile := addCmd.String("repo-file", "", "Offer to add the GitHub repositories a go.mod or requirements-style file depends on, at its versions")
+	addYes := addCmd.Bool("y", false, "With -repo-file, add without asking for confirmation")
+	addAs := addCmd.String("as", "", "A friendlier name to show for the application, also accepted by commands")
+	addNote := addCmd.String("note", "", "A free-text note on why the application is tracked, shown by 'list -notes'")
+	addMonotonic := addCmd.Bool("monotonic", false, "Refuse to record a version lower than the one already recorded, as for apps with the monotonic option")
+	addForce := addCmd.Bool("force", false, "Record the version even if it is a downgrade that monotonic forbids")
+	addCACert := addCmd.String("cacert", "", "PEM file with an extra root CA to trust (e.g. for a corporate proxy)")
 	removeCmd := flag.NewFlagSet("remove", flag.ExitOnError)
+	resetCmd := flag.NewFlagSet("reset", flag.ExitOnError)
+	resetYes := resetCmd.Bool("y", false, "Don't ask for confirmation")
+	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+	importReplaceAll := importCmd.Bool("replace-all", false, "Replace the whole configuration with the file's contents")
+	importYes := importCmd.Bool("y", false, "With -replace-all, don't ask for confirmation")
+	importMergeStrategy := importCmd.String("merge-strategy", mergeKeep, "For apps already tracked at another version: keep (existing wins), overwrite (imported wins) or newest (higher version wins)")
+	compareCmd := flag.NewFlagSet("compare", flag.ExitOnError)
+	rollbackCmd := flag.NewFlagSet("rollback", flag.ExitOnError)
+	rollbackList := rollbackCmd.Bool("list", false, "Only list the backups")
+	rollbackYes := rollbackCmd.Bool("y", false, "Don't ask for confirmation")
+	validateCmd := flag.NewFlagSet("validate", flag.ExitOnError)
+	getCmd := flag.NewFlagSet("get", flag.ExitOnError)
+	getField := getCmd.String("field", fieldVersion, "Field to print: version (recorded) or latest (fetched)")
+	doctorCmd := flag.NewFlagSet("doctor", flag.ExitOnError)
+	doctorToken := doctorCmd.String("token", "", "GitHub token to use (overrides GITHUB_TOKEN, gh CLI and token file)")
+	doctorCACert := doctorCmd.String("cacert", "", "PEM file with an extra root CA to trust (e.g. for a corporate proxy)")
+	tokenCmd := flag.NewFlagSet("token", flag.ExitOnError)
+	releasesCmd := flag.NewFlagSet("releases", flag.ExitOnError)
+	releasesLimit := releasesCmd.Int("limit", defaultReleasesLimit, "Maximum number of releases to print, newest first")
+	releasesSort := releasesCmd.String("sort", "", "Order of releases: date (published, newest first) or semver (newest first); GitHub's order by default")
+	releasesToken := releasesCmd.String("token", "", "GitHub token to use (overrides GITHUB_TOKEN, gh CLI and token file)")
+	releasesCACert := releasesCmd.String("cacert", "", "PEM file with an extra root CA to trust (e.g. for a corporate proxy)")
+	changelogCmd := flag.NewFlagSet("changelog", flag.ExitOnError)
+	changelogToken := changelogCmd.String("token", "", "GitHub token to use (overrides GITHUB_TOKEN, gh CLI and token file)")
+	changelogCACert := changelogCmd.String("cacert", "", "PEM file with an extra root CA to trust (e.g. for a corporate proxy)")
+	statusCmd := flag.NewFlagSet("status", flag.ExitOnError)
+	statusNoFetch := statusCmd.Bool("no-fetch", false, "Show only the recorded versions, without looking anything up")
+	statusTimeFormat := statusCmd.String("time-format", timeFormatRelative, "How to show times: relative, rfc3339 or a Go layout such as \"2006-01-02 15:04\"")
+	statusToken := statusCmd.String("token", "", "GitHub token to use (overrides GITHUB_TOKEN, gh CLI and token file)")
+	statusCACert := statusCmd.String("cacert", "", "PEM file with an extra root CA to trust (e.g. for a corporate proxy)")
 	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
+	listOutput := listCmd.String("o", "", "Write output to a file instead of stdout")
+	listGroupBy := listCmd.String("group-by", "", "Group applications under headers; supported: owner")
+	listSort := listCmd.String("sort", sortByName, "Order of applications: name or version (newest first)")
+	listPager := listCmd.String("pager", "", "Page output through this command on a terminal (default $PAGER, then \"less -R\")")
+	listNoPager := listCmd.Bool("no-pager", false, "Never page output")
+	listNotes := listCmd.Bool("notes", false, "Show each application's note under it")
+	listDescriptions := listCmd.Bool("descriptions", false, "Show the description of each application's GitHub repository under it (fetched, then cached)")
 	checkCmd := flag.NewFlagSet("check", flag.ExitOnError)
+	checkToken := checkCmd.String("token", "", "GitHub token to use (overrides GITHUB_TOKEN, gh CLI and token file)")
+	checkFormat := checkCmd.String("format", formatText, "Output format: text, github-actions, porcelain or ndjson")
+	checkPorcelain := checkCmd.Bool("porcelain", false, "Stable, script-friendly output; same as -format porcelain")
+	checkNDJSON := checkCmd.Bool("ndjson", false, "One JSON object per line as each app is checked; same as -format ndjson")
+	checkThrottle := checkCmd.Duration("throttle", 0, "Minimum delay between requests to the same host (e.g. 500ms)")
+	checkOutput := checkCmd.String("o", "", "Write output to a file instead of stdout")
+	checkAPIVersion := checkCmd.String("github-api-version", defaultGitHubAPIVersion, "Value of the X-GitHub-Api-Version header")
+	checkRenameMoved := checkCmd.Bool("rename-moved", false, "Rename entries for repositories that now redirect to a new name")
+	checkQuietErrors := checkCmd.Bool("quiet-errors", false, "Don't print apps that failed to check; they are only counted and still exit 2")
+	checkCompact := checkCmd.Bool("compact", false, "Print a single summary line; exit 1 if updates are available, 2 on errors")
+	checkAssets := checkCmd.Bool("assets", false, "List the release's downloadable assets when an update is available")
+	checkPlainStatus := checkCmd.Bool("plain-status", false, "Print nothing; exit 0 if all current, 1 if updates are available, 2 on errors")
+	checkRunVersionCommands := checkCmd.Bool("run-version-commands", false, "Run each app's version_command to compare against the installed version")
+	checkOnlyUpdates := checkCmd.Bool("only-updates", false, "Print only the names of apps with an update available, one per line")
+	checkCompareMode := checkCmd.String("compare-mode", compareSemver, "How to compare versions: semver, or exact (any difference is an update)")
+	checkMetricsFile := checkCmd.String("metrics-file", "", "Write Prometheus metrics for node_exporter's textfile collector to this file")
+	checkIncludeDrafts := checkCmd.Bool("include-drafts", false, "Also consider draft releases on GitHub (needs a token with push access)")
+	checkApps := checkCmd.String("apps", "", "Check only these applications, given as a comma-separated list of exact names")
+	checkMaxReleasePages := checkCmd.Int("max-release-pages", defaultMaxReleasePages, "With -include-drafts, how many pages of 30 releases to look through at most")
+	checkAuditLog := checkCmd.String("audit-log", "", "Append a JSON line summarizing each run to this file")
+	checkOnUpdate := checkCmd.String("on-update", "", "Run this command for each app with an update, after all checks; {app}, {current} and {latest} are filled in")
+	checkVerify := checkCmd.Bool("verify", false, "Before reporting an update, confirm its GitHub release still exists; a deleted one is only a warning")
+	checkPreflight := checkCmd.Bool("preflight", false, "When checking all apps, first make one request to the GitHub API and stop if it's unreachable or rate limited")
+	checkChangedOnly := checkCmd.Bool("changed-only", false, "Print only apps whose status changed since the last check, e.g. that newly have an update; for notifications that shouldn't repeat")
+	checkCommitsBehind := checkCmd.Bool("commits-behind", false, "For apps with an update on GitHub, show how many commits the latest tag is ahead of the current one (one more request each)")
+	checkParallelSources := checkCmd.Bool("parallel-sources", false, "Check apps on different hosts concurrently, sending one request at a time to each host")
+	checkDescriptions := checkCmd.Bool("descriptions", false, "Show the description of each application's GitHub repository (fetched, then cached)")
+	checkBadge := checkCmd.String("badge", "", "Write a shields.io endpoint badge JSON summarizing the run to this file")
+	checkMinBump := checkCmd.String("min-bump", "", "Only report updates at least this big: patch, minor or major")
+	checkTarget := checkCmd.String("target", "", "With an application name, check whether this version was released instead of the latest")
+	checkGroupOutput := checkCmd.Bool("group-output", false, "Print results in sections by status (updates, up to date, errors, skipped) once all apps are checked")
+	checkSort := checkCmd.String("sort", sortByName, "Order of results: name, version (newest first) or status (updates first)")
+	checkOffline := checkCmd.Bool("offline", false, "Make no network requests; report the latest versions last cached, or unknown")
+	checkCacheTTL := checkCmd.Duration("cache-ttl", 0, "Reuse latest versions looked up within this long (e.g. 1h), kept in a cache file")
+	checkPager := checkCmd.String("pager", "", "Page output through this command on a terminal (default $PAGER, then \"less -R\")")
+	checkNoPager := checkCmd.Bool("no-pager", false, "Never page output")
+	checkCACert := checkCmd.String("cacert", "", "PEM file with an extra root CA to trust (e.g. for a corporate proxy)")
+
+	// Each command's -h prints its help and flags, as does 'help <command>'.
+	commands := []*flag.FlagSet{addCmd, removeCmd, resetCmd, importCmd, compareCmd, rollbackCmd, validateCmd,
+		getCmd, doctorCmd, tokenCmd, releasesCmd, changelogCmd, statusCmd, listCmd, checkCmd}
+	setCommandUsages(commands)
 
-	// Custom usage for subcommands to ensure they are displayed correctly
-	addCmd.Usage = func() {
-		PrintUsageMessage("Usage: %s add <application_name> <version>", os.Args[0])
-		PrintUsageMessage("Example: %s add myapp 1.0.2", Colorize(os.Args[0], colorCyanFg))
+	globalFlags.Parse(os.Args[1:]) // Exits on error.
+	envPrefix = *globalEnvPrefix
+	if len(globalConfig) == 0 && envConfig().ConfigFile != "" {
+		globalConfig = configPaths{envConfig().ConfigFile}
 	}
-	removeCmd.Usage = func() {
-		PrintUsageMessage("Usage: %s remove <application_name>", os.Args[0])
-		PrintUsageMessage("Example: %s remove myapp", Colorize(os.Args[0], colorCyanFg))
+	if len(globalConfig) > 0 {
+		configFile, overlayConfigFiles = globalConfig[0], globalConfig[1:]
 	}
-	listCmd.Usage = func() {
-		PrintUsageMessage("Usage: %s list", os.Args[0])
+
+	// The config's [settings] provide defaults for flags: those given on the command line win.
+	settings, err := readSettingsFile(configFile)
+	if err != nil {
+		log.Printf("Warning: Could not read settings from config file '%s': %v", configFile, err)
 	}
-	checkCmd.Usage = func() {
-		PrintUsageMessage("Usage: %s check [<application_name>]", os.Args[0])
-		PrintUsageMessage("Example: %s check myapp", Colorize(os.Args[0], colorCyanFg))
-		PrintUsageMessage("Example: %s check", Colorize(os.Args[0], colorCyanFg))
+	explicitGlobals := make(map[string]bool)
+	globalFlags.Visit(func(f *flag.Flag) { explicitGlobals[f.Name] = true })
+	for _, warning := range applySettings(settings, explicitGlobals, append([]*flag.FlagSet{globalFlags}, commands...)...) {
+		log.Printf("Warning: Config file '%s': %s.", configFile, warning)
 	}
-
-	if len(os.Args) < 2 {
+	if err := setColorMode(*globalColor); err != nil {
+		PrintError("%v", err)
+		os.Exit(1)
+	}
+	if *globalAPIBase == "" {
+		*globalAPIBase = envConfig().APIBase
+	}
+	if *globalAPIBase != "" {
+		if err := validateAPIBase(*globalAPIBase); err != nil {
+			PrintError("%v", err)
+			os.Exit(1)
+		}
+		githubAPIBase = *globalAPIBase
+	}
+	if *globalInsecure {
+		log.Printf("Warning: TLS certificate verification is disabled by -insecure-skip-verify; connections can be intercepted.")
+		insecureSkipVerify = true
+		httpClient = newHTTPClient(newTransport(nil))
+	}
+	keepVPrefix = *globalKeepVPrefix
+	if *globalLimitRate < 0 {
+		PrintError("Invalid -limit-rate %d: must be 0 or more KB/s.", *globalLimitRate)
+		os.Exit(1)
+	}
+	limitRate = *globalLimitRate
+	dropEmptyEntries = *globalDropEmpty
+	if globalFlags.NArg() < 1 {
 		printOverallUsage()
 		os.Exit(1)
 	}
+	command, commandArgs := globalFlags.Arg(0), globalFlags.Args()[1:]
+	// Commands that read the config offer to add a first application if there is none yet.
+	switch command {
+	case "list", "get", "validate", "doctor", "releases", "changelog", "status", "check":
+		firstRunWizardPending = true
+	}
 
-	switch os.Args[1] {
+	switch command {
+	case "help":
+		os.Exit(handleHelpCmd(commands, commandArgs))
 	case "add":
-		addCmd.Parse(os.Args[2:])
-		if len(addCmd.Args()) < 2 {
+		args := parseArgs(addCmd, commandArgs)
+		if *addRepoFile != "" {
+			if len(args) > 0 || *addFromFile != "" {
+				PrintError("'add -repo-file' does not take an application name, version or -from-file.")
+				addCmd.Usage()
+				os.Exit(1)
+			}
+			handleAddFromRepoFileCmd(*addRepoFile, *addYes)
+			return
+		}
+		if *addFromFile != "" {
+			if len(args) > 0 {
+				PrintError("'add -from-file' does not take an application name or version.")
+				addCmd.Usage()
+				os.Exit(1)
+			}
+			githubToken = resolveToken("")
+			configureTransportOrExit(*addCACert)
+			handleAddFromFileCmd(*addFromFile, *addOverwrite)
+			return
+		}
+		if len(args) < 2 {
 			PrintError("Missing application name and/or version for 'add' command.")
 			addCmd.Usage()
 			os.Exit(1)
 		}
-		appName := addCmd.Args()[0]
-		appVersion := addCmd.Args()[1]
-		handleAddCmd(appName, appVersion)
+		appName := args[0]
+		appVersion := args[1]
+		handleAddCmd(appName, appVersion, addOptions{alias: *addAs, note: *addNote, monotonic: *addMonotonic, force: *addForce})
 	case "remove":
-		removeCmd.Parse(os.Args[2:])
-		if len(removeCmd.Args()) < 1 {
+		args := parseArgs(removeCmd, commandArgs)
+		if len(args) < 1 {
 			PrintError("Missing application name for 'remove' command.")
 			removeCmd.Usage()
 			os.Exit(1)
 		}
-		appName := removeCmd.Args()[0]
+		appName := args[0]
 		handleRemoveCmd(appName)
+	case "reset":
+		args := parseArgs(resetCmd, commandArgs)
+		if len(args) > 0 {
+			PrintError("'reset' command does not take any arguments.")
+			resetCmd.Usage()
+			os.Exit(1)
+		}
+		handleResetCmd(*resetYes)
+	case "import":
+		args := parseArgs(importCmd, commandArgs)
+		if len(args) != 1 {
+			PrintError("'import' command takes exactly one file.")
+			importCmd.Usage()
+			os.Exit(1)
+		}
+		switch *importMergeStrategy {
+		case mergeKeep, mergeOverwrite, mergeNewest:
+		default:
+			PrintError("Unknown merge strategy '%s'. Supported strategies: %s, %s, %s.", *importMergeStrategy, mergeKeep, mergeOverwrite, mergeNewest)
+			importCmd.Usage()
+			os.Exit(1)
+		}
+		if *importReplaceAll && *importMergeStrategy != mergeKeep {
+			PrintError("'import -merge-strategy' cannot be combined with -replace-all, which always uses the file's versions.")
+			importCmd.Usage()
+			os.Exit(1)
+		}
+		handleImportCmd(args[0], *importMergeStrategy, *importReplaceAll, *importYes)
+	case "compare":
+		args := parseArgs(compareCmd, commandArgs)
+		if len(args) != 2 {
+			PrintError("'compare' command takes exactly two files.")
+			compareCmd.Usage()
+			os.Exit(1)
+		}
+		os.Exit(handleCompareCmd(args[0], args[1]))
+	case "rollback":
+		args := parseArgs(rollbackCmd, commandArgs)
+		if len(args) > 1 {
+			PrintError("'rollback' command takes at most one backup.")
+			rollbackCmd.Usage()
+			os.Exit(1)
+		}
+		choice := ""
+		if len(args) == 1 {
+			choice = args[0]
+		}
+		os.Exit(handleRollbackCmd(choice, *rollbackList, *rollbackYes))
 	case "list":
-		listCmd.Parse(os.Args[2:])
-		if len(listCmd.Args()) > 0 {
+		args := parseArgs(listCmd, commandArgs)
+		if len(args) > 0 {
 			PrintError("'list' command does not take any arguments.")
 			listCmd.Usage()
 			os.Exit(1)
 		}
-		handleListCmd()
+		if *listGroupBy != "" && *listGroupBy != groupByOwner {
+			PrintError("Unknown grouping '%s'. Supported groupings: %s.", *listGroupBy, groupByOwner)
+			listCmd.Usage()
+			os.Exit(1)
+		}
+		if *listSort != sortByName && *listSort != sortByVersion {
+			PrintError("Unknown sort key '%s'. Supported keys: %s, %s.", *listSort, sortByName, sortByVersion)
+			listCmd.Usage()
+			os.Exit(1)
+		}
+		if *listDescriptions {
+			githubToken = resolveToken("")
+			descriptionCache = newFileCache(cacheFile())
+		}
+		maybeRunFirstRunWizard() // Before output goes to a file or pager.
+		closeOutput := redirectOutputOrExit(*listOutput)
+		closePager := startPagerOrWarn(resolvePager(*listPager, *listNoPager || *listOutput != ""))
+		handleListCmd(listOptions{groupBy: *listGroupBy, sortBy: *listSort, notes: *listNotes, descriptions: *listDescriptions})
+		closePager()
+		closeOutput()
+	case "get":
+		args := parseArgs(getCmd, commandArgs)
+		if len(args) != 1 {
+			PrintError("'get' command takes exactly one application name.")
+			getCmd.Usage()
+			os.Exit(1)
+		}
+		if *getField == fieldLatest {
+			githubToken = resolveToken("")
+		}
+		os.Exit(handleGetCmd(args[0], *getField))
+	case "validate":
+		args := parseArgs(validateCmd, commandArgs)
+		if len(args) > 0 {
+			PrintError("'validate' command does not take any arguments.")
+			validateCmd.Usage()
+			os.Exit(1)
+		}
+		os.Exit(handleValidateCmd())
+	case "doctor":
+		args := parseArgs(doctorCmd, commandArgs)
+		if len(args) > 0 {
+			PrintError("'doctor' command does not take any arguments.")
+			doctorCmd.Usage()
+			os.Exit(1)
+		}
+		githubToken = resolveToken(*doctorToken)
+		configureTransportOrExit(*doctorCACert)
+		os.Exit(handleDoctorCmd())
+	case "token":
+		args := parseArgs(tokenCmd, commandArgs)
+		if len(args) != 1 {
+			PrintError("'token' command takes exactly one action: %s, %s or %s.", tokenSet, tokenClear, tokenStatus)
+			tokenCmd.Usage()
+			os.Exit(1)
+		}
+		os.Exit(handleTokenCmd(args[0]))
+	case "releases":
+		args := parseArgs(releasesCmd, commandArgs)
+		if len(args) != 1 {
+			PrintError("'releases' command takes exactly one application name.")
+			releasesCmd.Usage()
+			os.Exit(1)
+		}
+		if *releasesLimit < 1 {
+			PrintError("-limit must be at least 1, got %d.", *releasesLimit)
+			os.Exit(1)
+		}
+		if *releasesSort != "" && *releasesSort != sortByDate && *releasesSort != sortBySemver {
+			PrintError("Unknown sort key '%s'. Supported keys: %s, %s.", *releasesSort, sortByDate, sortBySemver)
+			releasesCmd.Usage()
+			os.Exit(1)
+		}
+		githubToken = resolveToken(*releasesToken)
+		configureTransportOrExit(*releasesCACert)
+		os.Exit(handleReleasesCmd(args[0], *releasesLimit, *releasesSort))
+	case "changelog":
+		args := parseArgs(changelogCmd, commandArgs)
+		if len(args) > 0 {
+			PrintError("'changelog' command does not take any arguments.")
+			changelogCmd.Usage()
+			os.Exit(1)
+		}
+		githubToken = resolveToken(*changelogToken)
+		configureTransportOrExit(*changelogCACert)
+		handleChangelogCmd()
+	case "status":
+		args := parseArgs(statusCmd, commandArgs)
+		if len(args) > 0 {
+			PrintError("'status' command does not take any arguments.")
+			statusCmd.Usage()
+			os.Exit(1)
+		}
+		if err := validateTimeFormat(*statusTimeFormat); err != nil {
+			PrintError("%v", err)
+			statusCmd.Usage()
+			os.Exit(1)
+		}
+		if !*statusNoFetch {
+			githubToken = resolveToken(*statusToken)
+			configureTransportOrExit(*statusCACert)
+		}
+		handleStatusCmd(statusOptions{noFetch: *statusNoFetch, timeFormat: *statusTimeFormat})
 	case "check":
-		checkCmd.Parse(os.Args[2:])
+		args := parseArgs(checkCmd, commandArgs)
 		specificApp := ""
-		if len(checkCmd.Args()) > 1 { // check can have 0 or 1 arg
+		if len(args) > 1 { // check can have 0 or 1 arg
 			PrintError("'check' command accepts at most one application name.")
 			checkCmd.Usage()
 			os.Exit(1)
 		}
-		if len(checkCmd.Args()) == 1 {
-			specificApp = checkCmd.Args()[0]
+		if len(args) == 1 {
+			specificApp = args[0]
+		}
+		apps := parseAppsFlag(*checkApps)
+		if len(apps) > 0 && (specificApp != "" || *checkTarget != "") {
+			PrintError("'check -apps' cannot be combined with an application name or -target.")
+			checkCmd.Usage()
+			os.Exit(1)
+		}
+		if *checkTarget != "" {
+			if specificApp == "" {
+				PrintError("'check -target' needs an application name.")
+				checkCmd.Usage()
+				os.Exit(1)
+			}
+			githubToken = resolveToken(*checkToken)
+			configureTransportOrExit(*checkCACert)
+			githubAPIVersion = *checkAPIVersion
+			os.Exit(handleCheckTargetCmd(specificApp, *checkTarget))
+		}
+		if *checkPorcelain {
+			*checkFormat = formatPorcelain
+		}
+		if *checkNDJSON {
+			*checkFormat = formatNDJSON
 		}
-		handleCheckCmd(specificApp)
+		switch *checkFormat {
+		case formatText, formatGitHubActions, formatPorcelain, formatNDJSON:
+		default:
+			PrintError("Unknown format '%s'. Supported formats: %s, %s, %s, %s.", *checkFormat, formatText, formatGitHubActions, formatPorcelain, formatNDJSON)
+			checkCmd.Usage()
+			os.Exit(1)
+		}
+		if *checkSort != sortByName && *checkSort != sortByVersion && *checkSort != sortByStatus {
+			PrintError("Unknown sort key '%s'. Supported keys: %s, %s, %s.", *checkSort, sortByName, sortByVersion, sortByStatus)
+			checkCmd.Usage()
+			os.Exit(1)
+		}
+		if *checkCompareMode != compareSemver && *checkCompareMode != compareExact {
+			PrintError("Unknown compare mode '%s'. Supported modes: %s, %s.", *checkCompareMode, compareSemver, compareExact)
+			checkCmd.Usage()
+			os.Exit(1)
+		}
+		if *checkGroupOutput && *checkFormat != formatText {
+			PrintError("-group-output only applies to the text format, not '%s'.", *checkFormat)
+			checkCmd.Usage()
+			os.Exit(1)
+		}
+		if _, ok := bumpRanks[*checkMinBump]; *checkMinBump != "" && !ok {
+			PrintError("Unknown bump size '%s'. Supported sizes: %s, %s, %s.", *checkMinBump, bumpPatch, bumpMinor, bumpMajor)
+			checkCmd.Usage()
+			os.Exit(1)
+		}
+		if err := validateMaxReleasePages(*checkMaxReleasePages); err != nil {
+			PrintError("%v.", err)
+			checkCmd.Usage()
+			os.Exit(1)
+		}
+		compareMode = *checkCompareMode
+		githubToken = resolveToken(*checkToken)
+		if *checkPlainStatus {
+			firstRunWizardPending = false
+		}
+		requestThrottle = newHostThrottle(*checkThrottle)
+		maxReleasePages = *checkMaxReleasePages
+		if *checkOffline {
+			offlineMode = true
+			latestCache = newFileCache(cacheFile())
+		} else if *checkCacheTTL > 0 {
+			latestCache, latestCacheTTL = newFileCache(cacheFile()), *checkCacheTTL
+		}
+		if *checkDescriptions {
+			descriptionCache = newFileCache(cacheFile())
+		}
+		configureTransportOrExit(*checkCACert)
+		githubAPIVersion = *checkAPIVersion
+		maybeRunFirstRunWizard() // Before output goes to a file or pager.
+		closeOutput := redirectOutputOrExit(*checkOutput)
+		closePager := startPagerOrWarn(resolvePager(*checkPager, *checkNoPager || *checkPlainStatus || *checkOutput != ""))
+		exitCode := handleCheckCmd(specificApp, checkOptions{
+			format:             *checkFormat,
+			renameMoved:        *checkRenameMoved,
+			compact:            *checkCompact,
+			assets:             *checkAssets,
+			plainStatus:        *checkPlainStatus,
+			onlyUpdates:        *checkOnlyUpdates,
+			runVersionCommands: *checkRunVersionCommands,
+			sortBy:             *checkSort,
+			metricsFile:        *checkMetricsFile,
+			includeDrafts:      *checkIncludeDrafts,
+			apps:               apps,
+			quietErrors:        *checkQuietErrors,
+			minBump:            *checkMinBump,
+			auditLog:           *checkAuditLog,
+			onUpdate:           *checkOnUpdate,
+			verify:             *checkVerify,
+			preflight:          *checkPreflight,
+			changedOnly:        *checkChangedOnly,
+			commitsBehind:      *checkCommitsBehind,
+			parallelSources:    *checkParallelSources,
+			badgeFile:          *checkBadge,
+			groupOutput:        *checkGroupOutput,
+			descriptions:       *checkDescriptions,
+		})
+		closePager()
+		closeOutput()
+		os.Exit(exitCode)
 	default:
-		PrintError("Unknown command '%s'.", os.Args[1])
+		PrintError("Unknown command '%s'.", command)
 		printOverallUsage()
 		os.Exit(1)
 	}
 }
 
+// parseArgs parses fs's flags from args and returns the positional arguments. Unlike fs.Parse,
+// flags may also follow positional arguments, as in "check owner/repo -assets".
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args) // Exits on error, as the flag sets use flag.ExitOnError.
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// redirectOutputOrExit redirects regular output to path when it is non-empty, exiting on failure.
+// The returned function closes the file and reports any error writing it.
+func redirectOutputOrExit(path string) func() {
+	if path == "" {
+		return func() {}
+	}
+	closeFile, err := redirectOutput(path)
+	if err != nil {
+		PrintError("%v", err)
+		os.Exit(1)
+	}
+	return func() {
+		if err := closeFile(); err != nil {
+			PrintError("Could not write output file '%s': %v", path, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// startPagerOrWarn pages regular output through command when it is non-empty. A pager that
+// can't be started is reported and output goes straight to stdout instead.
+// The returned function waits for the pager to exit.
+func startPagerOrWarn(command string) func() {
+	if command == "" {
+		return func() {}
+	}
+	closePager, err := startPager(command)
+	if err != nil {
+		PrintError("%v", err)
+		return func() {}
+	}
+	return func() {
+		if err := closePager(); err != nil {
+			log.Printf("Debug: Pager '%s' exited with an error: %v", command, err)
+		}
+	}
+}
+
+// configureTransportOrExit applies the -cacert flag, exiting if the file can't be used.
+func configureTransportOrExit(caCertFile string) {
+	if err := configureTransport(caCertFile); err != nil {
+		PrintError("%v", err)
+		os.Exit(1)
+	}
+}
+
 func printOverallUsage() {
-	PrintUsageMessage("Usage: %s <command> [arguments]", os.Args[0])
+	PrintUsageMessage("Usage: %s [-color always|auto|never] [-config <file>]... [-api-base <url>] [-env-prefix <prefix>] [-keep-v-prefix] [-limit-rate <KB/s>] <command> [arguments]", os.Args[0])
 	PrintUsageMessage("Commands:")
 	// Using PrintMessage for command descriptions to allow custom coloring within them
 	// Colorize parts of the string for more detailed control if needed.
 	PrintMessage("  %s %s\tAdd a new application to monitor", Colorize("add", colorGreenFg), Colorize("<name> <version>", colorFgDefault))
 	PrintMessage("  %s %s\tRemove an application from monitoring", Colorize("remove", colorRedFg), Colorize("<name>", colorFgDefault))
+	PrintMessage("  %s %s\t\tStop monitoring all applications", Colorize("reset", colorRedFg), Colorize("[-y]", colorFgDefault))
+	PrintMessage("  %s %s\tAdd or replace applications from a TOML file", Colorize("import", colorGreenFg), Colorize("<file>", colorFgDefault))
+	PrintMessage("  %s %s\tShow what changed between two config files", Colorize("compare", colorBlueFg), Colorize("<old> <new>", colorFgDefault))
+	PrintMessage("  %s %s\tRestore the config from a backup", Colorize("rollback", colorRedFg), Colorize("[<number>]", colorFgDefault))
+	PrintMessage("  %s %s\t\tPrint one field of an application, for scripts", Colorize("get", colorBlueFg), Colorize("<name>", colorFgDefault))
 	PrintMessage("  %s\t\t\tList all monitored applications", Colorize("list", colorBlueFg))
+	PrintMessage("  %s\t\tShow recorded and latest versions in a table", Colorize("status", colorBlueFg))
 	PrintMessage("  %s %s\tCheck for updates, optionally for a specific app", Colorize("check", colorYellowFg), Colorize("[<name>]", colorFgDefault))
-	PrintUsageMessage("\nUse \"%s <command> --help\" for more information about a command (not yet implemented).", os.Args[0])
+	PrintMessage("  %s\t\tCheck the configuration for problems, offline", Colorize("validate", colorBlueFg))
+	PrintMessage("  %s %s\tStore, remove or check the saved GitHub token", Colorize("token", colorBlueFg), Colorize("set|clear|status", colorFgDefault))
+	PrintMessage("  %s\t\tDiagnose the setup (config, token, connectivity)", Colorize("doctor", colorBlueFg))
+	PrintMessage("  %s %s\tList the most recent releases of a repository", Colorize("releases", colorBlueFg), Colorize("<name>", colorFgDefault))
+	PrintMessage("  %s\t\tShow release notes for available updates", Colorize("changelog", colorYellowFg))
+	PrintMessage("  %s %s\tShow help for a command", Colorize("help", colorBlueFg), Colorize("[<command>]", colorFgDefault))
+	PrintUsageMessage("\nUse \"%s help <command>\" or \"%s <command> -help\" for more information about a command.", os.Args[0], os.Args[0])
 }
 
-func handleAddCmd(appName string, appVersion string) {
+// addOptions are the options of 'add' for a single application.
+type addOptions struct {
+	alias     string // Stored as the app's alias, replacing any previous one, if non-empty.
+	note      string // Stored as the app's note, replacing any previous one, if non-empty.
+	monotonic bool   // Refuse a version lower than the recorded one, as the app's monotonic option does.
+	force     bool   // Record the version even if monotonic refuses it.
+}
+
+// handleAddCmd records appVersion as the version of appName, with the alias and note in opts.
+func handleAddCmd(appName string, appVersion string, opts addOptions) {
+	appName, appVersion = strings.TrimSpace(appName), strings.TrimSpace(appVersion)
+	if appName == "" {
+		PrintError("Application name cannot be empty.")
+		return
+	}
+	if appVersion == "" {
+		PrintError("Version for '%s' cannot be empty.", appName)
+		return
+	}
+	if appName == metaTableKey || appName == settingsTableKey {
+		PrintError("'%s' is reserved for configuration metadata and cannot be used as an application name.", appName)
+		return
+	}
+
 	config, err := loadConfig()
 	if err != nil {
 		PrintError("Could not load configuration: %v", err)
@@ -107,6 +619,37 @@ func handleAddCmd(appName string, appVersion string) {
 	}
 
 	oldVersion, exists := config[appName]
+	if exists && (opts.monotonic || appOptions[appName].Monotonic) && !opts.force && isDowngrade(oldVersion, appVersion) {
+		PrintError("Refusing to downgrade '%s' from version '%s' to '%s': its version may only increase. Use -force to record it anyway.",
+			appName, oldVersion, appVersion)
+		return
+	}
+
+	if opts.alias != "" {
+		if other := resolveAppName(config, opts.alias); other != appName {
+			if _, taken := config[other]; taken {
+				PrintError("'%s' already refers to '%s' and cannot be used as an alias for '%s'.", opts.alias, other, appName)
+				return
+			}
+		}
+		appOpts := appOptions[appName]
+		appOpts.Alias = opts.alias
+		appOptions[appName] = appOpts
+	}
+	if note := strings.TrimSpace(opts.note); note != "" {
+		appOpts := appOptions[appName]
+		appOpts.Note = note
+		appOptions[appName] = appOpts
+	}
+
+	if !exists {
+		for existingName := range config {
+			if canonicalKey(existingName) == canonicalKey(appName) {
+				PrintInfo("'%s' refers to the same repository as already-tracked '%s'. Consider consolidating with '%s remove %s'.",
+					Colorize(appName, colorYellowFg), Colorize(existingName, colorYellowFg), os.Args[0], existingName)
+			}
+		}
+	}
 	config[appName] = appVersion
 
 	err = saveConfig(config)
@@ -116,10 +659,8 @@ func handleAddCmd(appName string, appVersion string) {
 	}
 
 	if exists {
-		PrintSuccess("Application '%s' updated from version '%s' to '%s'.",
-			Colorize(appName, colorYellowFg),
-			Colorize(oldVersion, colorMagentaFg),
-			Colorize(appVersion, colorCyanFg))
+		oldShown, newShown := versionDiff(oldVersion, appVersion, colorMagentaFg, colorCyanFg)
+		PrintSuccess("Application '%s' updated from version '%s' to '%s'.", Colorize(appName, colorYellowFg), oldShown, newShown)
 	} else {
 		PrintSuccess("Application '%s' added with version '%s'.",
 			Colorize(appName, colorYellowFg),
@@ -127,6 +668,120 @@ func handleAddCmd(appName string, appVersion string) {
 	}
 }
 
+// bulkAddConcurrency caps the number of simultaneous lookups made by 'add -from-file'.
+const bulkAddConcurrency = 8
+
+// handleAddFromFileCmd starts tracking every app identifier listed in path at its current latest version.
+// Blank lines and lines starting with '#' are ignored. Apps that are already tracked are skipped unless overwrite is set;
+// apps recorded as latestSentinel are always skipped.
+func handleAddFromFileCmd(path string, overwrite bool) {
+	appNames, err := readAppListFile(path)
+	if err != nil {
+		PrintError("Could not read '%s': %v", path, err)
+		return
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		PrintError("Could not load configuration: %v", err)
+		return
+	}
+
+	var toFetch []string
+	skipped := 0
+	for _, appName := range appNames {
+		if config[appName] == latestSentinel {
+			// There's nothing to re-record: the app already follows the latest release.
+			PrintInfo("Skipping '%s': tracking the latest version.", Colorize(appName, colorYellowFg))
+			skipped++
+			continue
+		}
+		if _, exists := config[appName]; exists && !overwrite {
+			PrintInfo("Skipping '%s': already tracked at version '%s'.", Colorize(appName, colorYellowFg), Colorize(config[appName], colorCyanFg))
+			skipped++
+			continue
+		}
+		toFetch = append(toFetch, appName)
+	}
+
+	// Fetch the latest versions concurrently, keeping results in file order.
+	latestVersions := make([]string, len(toFetch))
+	fetchErrors := make([]error, len(toFetch))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, bulkAddConcurrency)
+	for i, appName := range toFetch {
+		wg.Add(1)
+		go func(i int, appName string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			latestVersions[i], fetchErrors[i] = lookupLatestVersion(requestContext, appName)
+		}(i, appName)
+	}
+	wg.Wait()
+
+	added, updated, failed := 0, 0, 0
+	for i, appName := range toFetch {
+		if fetchErrors[i] != nil {
+			PrintError("Could not add '%s': %v", Colorize(appName, colorMagentaFg), fetchErrors[i])
+			failed++
+			continue
+		}
+		if _, exists := config[appName]; exists {
+			updated++
+		} else {
+			added++
+		}
+		config[appName] = latestVersions[i]
+		PrintMessage("  + %s %s", Colorize(appName, colorYellowFg), Colorize(latestVersions[i], colorCyanFg))
+	}
+
+	if added+updated > 0 {
+		if err := saveConfig(config); err != nil {
+			PrintError("Could not save configuration: %v", err)
+			return
+		}
+	}
+	PrintSuccess("Added %d, updated %d, skipped %d, failed %d.", added, updated, skipped, failed)
+}
+
+// readAppListFile reads app identifiers from a newline-delimited file, ignoring blank lines,
+// '#' comments and repeated entries.
+func readAppListFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var appNames []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || seen[line] {
+			continue
+		}
+		seen[line] = true
+		appNames = append(appNames, line)
+	}
+	return appNames, nil
+}
+
+// parseAppsFlag splits the comma-separated value of 'check -apps' into sorted, distinct names.
+func parseAppsFlag(value string) []string {
+	var appNames []string
+	seen := make(map[string]bool)
+	for _, appName := range strings.Split(value, ",") {
+		appName = strings.TrimSpace(appName)
+		if appName == "" || seen[appName] {
+			continue
+		}
+		seen[appName] = true
+		appNames = append(appNames, appName)
+	}
+	sort.Strings(appNames)
+	return appNames
+}
+
 func handleRemoveCmd(appName string) {
 	config, err := loadConfig()
 	if err != nil {
@@ -149,7 +804,56 @@ func handleRemoveCmd(appName string) {
 	PrintSuccess("Application '%s' removed.", Colorize(appName, colorYellowFg))
 }
 
-func handleListCmd() {
+// handleResetCmd removes every application from the configuration, asking first unless skipConfirm is set.
+func handleResetCmd(skipConfirm bool) {
+	config, err := loadConfig()
+	if err != nil {
+		PrintError("Could not load configuration: %v", err)
+		return
+	}
+
+	if len(config) == 0 {
+		PrintInfo("No applications currently managed. Nothing to reset.")
+		return
+	}
+
+	if !skipConfirm && !Confirm("Remove all %d tracked applications?", len(config)) {
+		PrintInfo("Reset aborted. No changes made.")
+		return
+	}
+
+	backupPath, err := backupConfig()
+	if err != nil {
+		PrintError("%v", err)
+		return
+	}
+	if backupPath != "" {
+		PrintInfo("Backed up the previous configuration to %s.", backupPath)
+	}
+	if err := saveConfig(Config{}); err != nil {
+		PrintError("Could not save configuration: %v", err)
+		return
+	}
+	PrintSuccess("Removed %s.", pluralize(len(config), "application", "applications"))
+}
+
+// groupByOwner groups 'list' output by repository owner.
+const groupByOwner = "owner"
+
+// otherGroup is the heading for entries that aren't in owner/repo form.
+const otherGroup = "other"
+
+// listOptions holds the flags that affect how 'list' prints applications.
+type listOptions struct {
+	groupBy string // Empty for a flat list, or groupByOwner.
+	sortBy  string // One of the sortBy* keys supported by sortAppNames; empty means by name.
+	notes   bool   // Show each application's note, if it has one.
+
+	descriptions bool // Show the description of each app's GitHub repository, if it has one.
+}
+
+// handleListCmd prints every managed application.
+func handleListCmd(opts listOptions) {
 	config, err := loadConfig()
 	if err != nil {
 		PrintError("Could not load configuration: %v", err)
@@ -161,90 +865,654 @@ func handleListCmd() {
 		return
 	}
 
+	var descriptions map[string]string
+	if opts.descriptions && !offlineMode {
+		descriptions = fetchDescriptions(sortedAppNames(config))
+	}
+
+	defer bufferOutput()()
 	PrintHeader("Managed Applications")
 
-	// Sort keys for consistent output order
-	var sortedAppNames []string
-	for appName := range config {
-		sortedAppNames = append(sortedAppNames, appName)
+	if opts.groupBy == groupByOwner {
+		owners, groups := groupAppsByOwner(config)
+		for _, owner := range owners {
+			PrintHeader("%s (%d)", owner, len(groups[owner]))
+			for _, appName := range sortAppNames(config, groups[owner], opts.sortBy) {
+				printListEntry(appName, config[appName], descriptions[appName], opts)
+			}
+		}
+	} else {
+		for _, appName := range sortAppNames(config, sortedAppNames(config), opts.sortBy) {
+			printListEntry(appName, config[appName], descriptions[appName], opts)
+		}
 	}
-	sort.Strings(sortedAppNames)
 
-	for _, appName := range sortedAppNames {
-		appVersion := config[appName]
-		PrintMessage("  - Application: %s, Version: %s",
-			Colorize(appName, colorYellowFg),
-			Colorize(appVersion, colorCyanFg))
+	for _, names := range findDuplicates(config) {
+		PrintInfo("Duplicate entries for the same repository: %s. Consider consolidating them with '%s remove <name>'.",
+			Colorize(strings.Join(names, ", "), colorYellowFg), os.Args[0])
+	}
+}
+
+// printListEntry prints one application line of the 'list' output, followed by its note if
+// opts asks for notes and it has one, and by its repository's description if that isn't empty.
+// Apps recorded as latestSentinel are marked as tracking.
+func printListEntry(appName, appVersion, description string, opts listOptions) {
+	if appVersion == latestSentinel {
+		appVersion += " (tracking)"
+	}
+	PrintMessage("  - Application: %s, Version: %s",
+		Colorize(displayName(appName), colorYellowFg),
+		Colorize(appVersion, colorCyanFg))
+	if note := appOptions[appName].Note; opts.notes && note != "" {
+		PrintMessage("      Note: %s", note)
+	}
+	if description != "" {
+		PrintMessage("      Description: %s", description)
+	}
+}
+
+// groupAppsByOwner groups the app names in config by the owner part of their identifier,
+// ignoring any source prefix. Owners are returned sorted, with otherGroup last for entries
+// that aren't in owner/repo form; each group's names are sorted too.
+func groupAppsByOwner(config Config) ([]string, map[string][]string) {
+	groups := make(map[string][]string)
+	for _, appName := range sortedAppNames(config) {
+		owner := otherGroup
+		_, identifier := splitSourcePrefix(appName)
+		if i := strings.Index(identifier, "/"); i > 0 {
+			owner = identifier[:i]
+		}
+		groups[owner] = append(groups[owner], appName)
+	}
+
+	owners := make([]string, 0, len(groups))
+	for owner := range groups {
+		if owner != otherGroup {
+			owners = append(owners, owner)
+		}
+	}
+	sort.Strings(owners)
+	if _, ok := groups[otherGroup]; ok {
+		owners = append(owners, otherGroup)
 	}
+	return owners, groups
 }
 
-func handleCheckCmd(specificApp string) {
+// sortedAppNames returns the application names in config, sorted for consistent output order.
+func sortedAppNames(config Config) []string {
+	appNames := make([]string, 0, len(config))
+	for appName := range config {
+		appNames = append(appNames, appName)
+	}
+	sort.Strings(appNames)
+	return appNames
+}
+
+// handleCheckCmd checks one or all managed applications and returns the process exit code.
+func handleCheckCmd(specificApp string, opts checkOptions) int {
+	if opts.plainStatus {
+		defer silenceOutput()()
+	}
+
 	config, err := loadConfig()
 	if err != nil {
 		PrintError("Could not load configuration: %v", err)
-		return
+		return exitCheckFailed
 	}
 
 	if len(config) == 0 {
-		PrintInfo("No applications currently managed. Use 'add' command to add some.")
-		return
+		if opts.format != formatPorcelain && opts.format != formatNDJSON && !opts.compact && !opts.onlyUpdates {
+			PrintInfo("No applications currently managed. Use 'add' command to add some.")
+		}
+		return finishCheck(nil, opts)
+	}
+
+	if opts.preflight && specificApp == "" && !offlineMode {
+		if failure := preflightFailure(); failure != "" {
+			PrintError("Preflight failed, so no applications were checked: %s", failure)
+			return exitCheckFailed
+		}
 	}
 
 	if specificApp != "" {
-		currentVersion, exists := config[specificApp]
-		if !exists {
+		specificApp = resolveAppName(config, specificApp)
+		if _, exists := config[specificApp]; !exists {
 			PrintError("Application '%s' not found in your managed list.", Colorize(specificApp, colorYellowFg))
+			if opts.format == formatNDJSON {
+				// Keep the JSON consumer's picture complete, as for an unknown name in -apps.
+				printCheckResultNDJSON(checkResult{appName: specificApp, status: statusError, err: errors.New("not found in your managed list")})
+			}
+			return exitCheckFailed
+		}
+	} else if opts.textOutput() && !opts.changedOnly {
+		if len(opts.apps) > 0 {
+			PrintMessage("%sChecking %s for updates...%s", ansi(colorBlueFg), pluralize(len(opts.apps), "application", "applications"), ansi(colorReset))
+		} else {
+			PrintMessage("%sChecking all managed applications for updates...%s", ansi(colorBlueFg), ansi(colorReset)) // Using PrintMessage for specific coloring
+		}
+	}
+
+	var descriptions map[string]string
+	if opts.descriptions && !offlineMode {
+		descriptions = fetchDescriptions(checkedAppNames(config, specificApp, opts))
+	}
+	var results []checkResult
+	if opts.groupOutput && opts.textOutput() {
+		results = runChecks(config, specificApp, opts, func(checkResult) {})
+		for i := range results {
+			results[i].description = descriptions[results[i].appName]
+		}
+		printGroupedResults(results, opts)
+	} else {
+		results = runChecks(config, specificApp, opts, func(result checkResult) {
+			result.description = descriptions[result.appName]
+			printCheckResult(result, opts)
+		})
+	}
+
+	if opts.renameMoved {
+		var movedResults []checkResult
+		for _, result := range results {
+			if result.movedTo != "" {
+				movedResults = append(movedResults, result)
+			}
+		}
+		if len(movedResults) > 0 {
+			renameMovedApps(config, movedResults)
+		}
+	}
+	if opts.metricsFile != "" {
+		if err := writeMetricsFile(opts.metricsFile, results); err != nil {
+			PrintError("%v", err)
+		}
+	}
+	if opts.badgeFile != "" {
+		if err := writeBadgeFile(opts.badgeFile, results); err != nil {
+			PrintError("%v", err)
+		}
+	}
+	if opts.auditLog != "" {
+		if err := appendAuditLog(opts.auditLog, results); err != nil {
+			PrintError("%v", err)
+		}
+	}
+	hookFailures := 0
+	if opts.onUpdate != "" {
+		hookFailures = runUpdateHooks(opts.onUpdate, results, !opts.textOutput())
+	}
+	exitCode := finishCheck(results, opts)
+	if hookFailures > 0 {
+		return exitCheckFailed
+	}
+	return exitCode
+}
+
+// checkedAppNames returns the apps runChecks checks for specificApp and opts.
+func checkedAppNames(config Config, specificApp string, opts checkOptions) []string {
+	if specificApp != "" {
+		return []string{specificApp}
+	}
+	if len(opts.apps) > 0 {
+		appNames := make([]string, len(opts.apps))
+		for i, name := range opts.apps {
+			appNames[i] = resolveAppName(config, name)
+		}
+		return appNames
+	}
+	return sortedAppNames(config)
+}
+
+// runChecks checks specificApp, which must be one of config's applications, or if it is empty
+// all of them or the ones in opts.apps, and returns the results in the order they are to be shown.
+// It records the checks in the state file, but prints nothing: each result is also passed to emit
+// as soon as it can be shown, so a renderer can stream them.
+func runChecks(config Config, specificApp string, opts checkOptions, emit func(checkResult)) []checkResult {
+	state := loadState()
+	stateChanged := false
+	// markChecked records a completed check so check_every can skip the app next time, and
+	// notes in result if its status or latest version has changed since the previous check.
+	// Failed checks only record their status.
+	markChecked := func(result *checkResult) {
+		if result.status == statusSkipped || result.cached {
+			return
+		}
+		entry := state[result.appName]
+		status := ndjsonStatuses[result.status]
+		if entry.LastStatus != "" && entry.LastStatus != status {
+			result.previousStatus = entry.LastStatus
+		}
+		result.statusChanged = entry.LastStatus != status
+		entry.LastStatus = status
+		state[result.appName] = entry
+		stateChanged = true
+		if result.status == statusError {
 			return
 		}
-		checkAppVersion(specificApp, currentVersion)
+		if entry.LastLatest != "" && entry.LastLatest != result.latestVersion {
+			result.previousLatest = entry.LastLatest
+		}
+		entry.LastChecked = time.Now()
+		entry.LastLatest = result.latestVersion
+		state[result.appName] = entry
+		stateChanged = true
+	}
+
+	var results []checkResult
+	if specificApp != "" {
+		result := checkAppVersion(specificApp, config[specificApp], opts)
+		markChecked(&result)
+		emit(result)
+		results = append(results, result)
 	} else {
-		PrintMessage("%sChecking all managed applications for updates...%s", colorBlueFg, colorReset) // Using PrintMessage for specific coloring
-		for appName, currentVersion := range config {
-			checkAppVersion(appName, currentVersion)
+		// An explicit subset is checked like apps named on the command line: check_every doesn't apply.
+		explicit := len(opts.apps) > 0
+		appNames := sortedAppNames(config)
+		if explicit {
+			appNames = make([]string, len(opts.apps))
+			for i, name := range opts.apps {
+				appNames[i] = resolveAppName(config, name)
+			}
+			sort.Strings(appNames)
+		}
+		// Results are emitted as they come in, unless they must be sorted first.
+		streaming := opts.sortBy == "" || opts.sortBy == sortByName
+		// Decide first which apps to look up; the others are skipped or fail right away.
+		results = make([]checkResult, len(appNames))
+		lookUp := make([]bool, len(appNames))
+		checkedKeys := make(map[string]string)
+		now := time.Now()
+		for i, appName := range appNames {
+			key := canonicalKey(appName)
+			if _, tracked := config[appName]; !tracked {
+				results[i] = checkResult{appName: appName, status: statusError, err: errors.New("not found in your managed list")}
+			} else if firstName, seen := checkedKeys[key]; seen {
+				results[i] = checkResult{appName: appName, currentVersion: config[appName], status: statusSkipped,
+					skipReason: fmt.Sprintf("Duplicate of '%s'.", firstName)}
+			} else if reason := notDueReason(appName, state, now); reason != "" && !explicit {
+				checkedKeys[key] = appName
+				results[i] = checkResult{appName: appName, currentVersion: config[appName], status: statusSkipped, skipReason: reason}
+			} else {
+				checkedKeys[key] = appName
+				lookUp[i] = true
+			}
+		}
+		// With -parallel-sources, every lookup is done before anything is printed; otherwise each
+		// app is looked up when its turn comes.
+		if opts.parallelSources {
+			var lookUpNames []string
+			var lookUpIndexes []int
+			for i, appName := range appNames {
+				if lookUp[i] {
+					lookUpNames = append(lookUpNames, appName)
+					lookUpIndexes = append(lookUpIndexes, i)
+				}
+			}
+			scheduled := scheduleByHost(lookUpNames, sourceHost, func(appName string) checkResult {
+				return checkAppVersion(appName, config[appName], opts)
+			})
+			for j, result := range scheduled {
+				results[lookUpIndexes[j]] = result
+			}
+		}
+		for i, appName := range appNames {
+			if lookUp[i] {
+				if !opts.parallelSources {
+					results[i] = checkAppVersion(appName, config[appName], opts)
+				}
+				markChecked(&results[i])
+			}
+			if streaming {
+				emit(results[i])
+			}
+		}
+		if !streaming {
+			sortResults(results, opts.sortBy)
+			for _, result := range results {
+				emit(result)
+			}
+		}
+	}
+
+	if stateChanged {
+		if err := saveState(state); err != nil {
+			log.Printf("Warning: Could not save state file '%s': %v", stateFile(), err)
 		}
 	}
+	return results
 }
 
-func checkAppVersion(appName, currentVersion string) {
-	if !strings.Contains(appName, "/") {
-		PrintInfo("Skipping %s: Not in 'owner/repo' format. Cannot check for updates via GitHub.", Colorize(appName, colorMagentaFg))
-		return
+// finishCheck prints anything that summarizes the whole run and returns the exit code for it.
+func finishCheck(results []checkResult, opts checkOptions) int {
+	summary := summarizeResults(results)
+	if opts.plainStatus {
+		return summary.exitCode()
 	}
+	if opts.compact {
+		PrintMessage("%s", summary.compactLine())
+		return summary.exitCode()
+	}
+	if opts.quietErrors {
+		if opts.textOutput() && summary.errors > 0 {
+			PrintMessage("%s could not be checked.", pluralize(summary.errors, "application", "applications"))
+		}
+	} else if opts.textOutput() && len(results) > 1 && !opts.changedOnly && !opts.groupOutput {
+		// A single check already ends with its error, so only list failures across several apps.
+		// With -changed-only, an app that newly failed has already been shown with its error, and
+		// with -group-output, failures have a section of their own.
+		printFailures(results)
+	}
+	if summary.errors > 0 {
+		return exitCheckFailed
+	}
+	return exitOK
+}
 
-	// Using PrintMessage directly for more control over the line ending and formatting
-	fmt.Printf("%sChecking %s... %s", colorFgDefault, Colorize(appName, colorYellowFg), colorReset)
-	latestVersion, err := getLatestVersion(appName, "") // Call the func variable
-	if err != nil {
-		// PrintError already adds a newline.
-		// Need to ensure the "Checking..." line gets a newline if an error occurs here.
-		fmt.Println() // Add newline after "Checking..." before printing error
-		PrintError("Failed to check %s: %v", Colorize(appName, colorMagentaFg), err)
+// renameMovedApps renames the config entries of repositories that now redirect to a new owner/repo.
+func renameMovedApps(config Config, results []checkResult) {
+	renamed := 0
+	for _, result := range results {
+		if result.source != "" {
+			PrintInfo("Not renaming %s: %s is an entry of its sources list; update it by hand.", Colorize(result.appName, colorMagentaFg), Colorize(result.source, colorYellowFg))
+			continue
+		}
+		if _, exists := config[result.movedTo]; exists {
+			PrintInfo("Not renaming %s: %s is already tracked.", Colorize(result.appName, colorMagentaFg), Colorize(result.movedTo, colorYellowFg))
+			continue
+		}
+		config[result.movedTo] = config[result.appName]
+		delete(config, result.appName)
+		if opts, ok := appOptions[result.appName]; ok {
+			appOptions[result.movedTo] = opts
+			delete(appOptions, result.appName)
+		}
+		if v, ok := unexpandedValues[result.appName]; ok {
+			unexpandedValues[result.movedTo] = v
+			delete(unexpandedValues, result.appName)
+		}
+		renamed++
+	}
+	if renamed == 0 {
 		return
 	}
+	if err := saveConfig(config); err != nil {
+		PrintError("Could not save configuration after renaming moved repositories: %v", err)
+		return
+	}
+	for _, result := range results {
+		if _, exists := config[result.appName]; !exists {
+			PrintSuccess("Renamed '%s' to '%s'.", Colorize(result.appName, colorYellowFg), Colorize(result.movedTo, colorYellowFg))
+		}
+	}
+}
 
-	if latestVersion == currentVersion {
-		fmt.Printf("%s Current: %s, Latest: %s (%s)%s\n",
-			colorFgDefault,
-			Colorize(currentVersion, colorCyanFg),
-			Colorize(latestVersion, colorGreenFg),
-			Colorize("Up to date", colorGreenFg),
-			colorReset)
-	} else {
-		isUpgrade := latestVersion > currentVersion // Lexicographical comparison
-		if isUpgrade {
-			fmt.Printf("%s Current: %s, Latest: %s (%s)%s\n",
-				colorFgDefault,
-				Colorize(currentVersion, colorCyanFg),
-				Colorize(latestVersion, colorRedFg),
-				Colorize("Update Available!", colorRedFg),
-				colorReset)
+// checkAppVersion checks a single application, applying the options that affect the check
+// itself. Like checkApp, it does not print anything.
+func checkAppVersion(appName, currentVersion string, opts checkOptions) checkResult {
+	if opts.runVersionCommands {
+		installed, err := installedVersion(appName)
+		if err != nil {
+			return checkResult{appName: appName, currentVersion: currentVersion, status: statusError, err: err}
+		}
+		if installed != "" {
+			currentVersion = installed
+		}
+	}
+	result := checkApp(appName, currentVersion)
+	if offlineMode {
+		return result // Drafts and assets would need the network.
+	}
+	if opts.includeDrafts && result.status != statusError && result.status != statusSkipped {
+		result = considerDraftRelease(result)
+	}
+	if result.status == statusUpdateAvailable && belowMinBump(result.currentVersion, result.latestVersion, opts.minBump) {
+		result.status = statusUpToDate
+	}
+	if opts.verify && result.status == statusUpdateAvailable && !result.draft {
+		result = verifyLatestRelease(result)
+	}
+	if opts.commitsBehind && result.status == statusUpdateAvailable && !result.draft {
+		result = countCommitsBehind(result)
+	}
+	if opts.assets && result.status == statusUpdateAvailable {
+		source, identifier, _ := resolveSource(result.sourceRef())
+		if result.movedTo != "" {
+			identifier = result.movedTo
+		}
+		if _, isGitHub := source.(githubSource); !isGitHub {
+			result.assetsErr = fmt.Errorf("assets are only available for GitHub releases")
+		} else if release, err := getLatestRelease(withBodyRateLimit(requestContext), identifier, githubAPIBase); err != nil {
+			result.assetsErr = err
 		} else {
-			fmt.Printf("%s Current: %s, Latest: %s (%s)%s\n",
-				colorFgDefault,
-				Colorize(currentVersion, colorCyanFg),
-				Colorize(latestVersion, colorYellowFg),
-				Colorize("Version discrepancy", colorYellowFg),
-				colorReset)
+			result.assets = release.Assets
+		}
+	}
+	return result
+}
+
+// offlineResult completes result for 'check -offline' from the latest version cached under
+// cacheKey, however old, or else the one the last check recorded in the state file, as every
+// check does, with or without -cache-ttl. Without either the latest version is unknown and the
+// app is skipped.
+func offlineResult(result checkResult, cacheKey string) checkResult {
+	latestVersion, ok := "", false
+	if latestCache != nil {
+		latestVersion, ok = latestCache.GetStale(cacheKey)
+	}
+	if !ok {
+		latestVersion = loadState()[result.appName].LastLatest
+		ok = latestVersion != ""
+	}
+	if !ok {
+		result.status = statusSkipped
+		result.skipReason = "Latest version unknown offline: nothing cached yet."
+		return result
+	}
+	result.latestVersion = latestVersion
+	result.cached = true
+	result.status = versionStatus(result.currentVersion, latestVersion, compareModeFor(result.appName))
+	return result
+}
+
+// considerDraftRelease replaces result's latest version with a newer draft release, if the
+// app is on GitHub and has one. Failing to list releases leaves result as it is.
+func considerDraftRelease(result checkResult) checkResult {
+	source, identifier, _ := resolveSource(result.sourceRef())
+	if _, isGitHub := source.(githubSource); !isGitHub {
+		return result
+	}
+	if result.movedTo != "" {
+		identifier = result.movedTo
+	}
+	field := appOptions[result.appName].VersionField
+	release, err := getLatestReleaseIncludingDrafts(requestContext, identifier, field, githubAPIBase)
+	if err != nil || !release.Draft {
+		return result
+	}
+	draftVersion := releaseVersion(release, field)
+	if compareVersions(draftVersion, result.latestVersion) <= 0 {
+		return result
+	}
+	result.latestVersion = draftVersion
+	result.draft = true
+	result.status = versionStatus(result.currentVersion, draftVersion, compareModeFor(result.appName))
+	return result
+}
+
+// verifyLatestRelease confirms for 'check -verify' that the release of result's latest version
+// still exists on GitHub, as it may have been deleted since it was cached. A deleted release turns
+// the update into a skip with a warning. Other apps, and failed lookups, leave result as it is.
+func verifyLatestRelease(result checkResult) checkResult {
+	source, identifier, _ := resolveSource(result.sourceRef())
+	if _, isGitHub := source.(githubSource); !isGitHub {
+		return result
+	}
+	if result.movedTo != "" {
+		identifier = result.movedTo
+	}
+	tag := channelTag(appOptions[result.appName].Channel, result.latestVersion)
+	if _, err := getReleaseByTag(requestContext, identifier, tag, githubAPIBase); errors.Is(err, ErrNotFound) {
+		result.status = statusSkipped
+		result.skipReason = fmt.Sprintf("The release of latest version %s no longer exists; it may have been deleted.", result.latestVersion)
+	} else if err != nil {
+		log.Printf("Warning: Could not verify release %s of %s: %v", tag, result.appName, err)
+	}
+	return result
+}
+
+// countCommitsBehind fills in for 'check -commits-behind' how many commits the tag of result's
+// latest version is ahead of the tag of its current version, on GitHub. Other apps are left as
+// they are; so are failed lookups, such as when the current version has no tag, with a warning.
+func countCommitsBehind(result checkResult) checkResult {
+	source, identifier, _ := resolveSource(result.sourceRef())
+	if _, isGitHub := source.(githubSource); !isGitHub {
+		return result
+	}
+	if result.movedTo != "" {
+		identifier = result.movedTo
+	}
+	channel := appOptions[result.appName].Channel
+	behind, err := getCommitsBehind(requestContext, identifier, channelTag(channel, result.currentVersion), channelTag(channel, result.latestVersion), githubAPIBase)
+	if err != nil {
+		log.Printf("Warning: Could not count commits behind for %s: %v", result.appName, err)
+		return result
+	}
+	result.commitsBehind = behind
+	return result
+}
+
+// checkApp fetches the latest version of an application and compares it to currentVersion.
+// It does not print anything; see printCheckResult.
+// Apps with a sources list try each entry in order and use the first that answers.
+func checkApp(appName, currentVersion string) checkResult {
+	chain := appOptions[appName].Sources
+	if len(chain) == 0 {
+		return checkAppSource(appName, currentVersion, appName)
+	}
+
+	var errs []error
+	for _, ref := range chain {
+		result := checkAppSource(appName, currentVersion, ref)
+		switch result.status {
+		case statusError:
+			errs = append(errs, fmt.Errorf("%s: %w", ref, result.err))
+		case statusSkipped:
+			errs = append(errs, fmt.Errorf("%s: %s", ref, result.skipReason))
+		default:
+			result.source = ref
+			return result
+		}
+	}
+	if offlineMode {
+		return checkResult{appName: appName, currentVersion: currentVersion, status: statusSkipped,
+			skipReason: "Latest version unknown offline: nothing cached yet."}
+	}
+	return checkResult{appName: appName, currentVersion: currentVersion, status: statusError, err: &sourceChainError{errs: errs}}
+}
+
+// parseTimeout parses a timeout value, a positive Go duration such as "10s".
+func parseTimeout(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout '%s'", value)
+	}
+	return d, nil
+}
+
+// lookupContext returns the context to look up appName's latest version with. If the app has a
+// timeout, the lookup is cancelled once it passes, in place of the usual per-request timeout.
+func lookupContext(appName string) (context.Context, context.CancelFunc) {
+	timeout := appOptions[appName].Timeout
+	if timeout == "" {
+		return context.WithCancel(requestContext)
+	}
+	d, err := parseTimeout(timeout)
+	if err != nil {
+		return context.WithCancel(requestContext) // Reported by 'validate'.
+	}
+	return context.WithTimeout(requestContext, d)
+}
+
+// checkAppSource checks appName against the source named by ref, which is appName itself or an
+// entry of its sources list.
+func checkAppSource(appName, currentVersion, ref string) checkResult {
+	result := checkResult{appName: appName, currentVersion: currentVersion}
+	source, identifier, err := resolveSource(ref)
+	if err != nil {
+		result.status = statusError
+		result.err = err
+		return result
+	}
+	if !source.ValidIdentifier(identifier) {
+		result.status = statusSkipped
+		result.skipReason = fmt.Sprintf("Not in '%s' format. Cannot check for updates via %s.", source.IdentifierFormat(), source.DisplayName())
+		return result
+	}
+
+	lookup, cacheKey := source.LatestVersion, ref
+	if opts := appOptions[appName]; opts.Channel != "" || len(opts.Ignore) > 0 {
+		if _, isGitHub := source.(githubSource); !isGitHub {
+			result.status = statusError
+			result.err = fmt.Errorf("release channels and ignored versions are only supported for GitHub releases")
+			return result
+		}
+		lookup = func(ctx context.Context, identifier string) (string, error) {
+			return getLatestMatchingVersion(ctx, identifier, opts.Channel, opts.Ignore, opts.VersionField, githubAPIBase)
+		}
+		cacheKey += "@" + opts.Channel
+		if len(opts.Ignore) > 0 {
+			cacheKey += "!" + strings.Join(opts.Ignore, ",")
+		}
+		if opts.VersionField == versionFieldName {
+			cacheKey += "#" + versionFieldName
+		}
+	} else if opts.VersionField == versionFieldName {
+		if _, isGitHub := source.(githubSource); !isGitHub {
+			result.status = statusError
+			result.err = fmt.Errorf("version_field is only supported for GitHub releases")
+			return result
+		}
+		lookup = func(ctx context.Context, identifier string) (string, error) {
+			release, err := getLatestRelease(ctx, identifier, githubAPIBase)
+			if err != nil {
+				return "", err
+			}
+			if version := releaseVersion(release, opts.VersionField); version != "" {
+				return version, nil
+			}
+			return "", fmt.Errorf("the latest release of %s has no name to read the version from", identifier)
+		}
+		cacheKey += "#" + versionFieldName
+	}
+
+	if offlineMode {
+		return offlineResult(result, cacheKey)
+	}
+
+	latestVersion, cached := cachedLatestVersion(cacheKey)
+	if !cached {
+		ctx, cancel := lookupContext(appName)
+		defer cancel()
+		latestVersion, err = lookup(ctx, identifier)
+		var moved *RepoMovedError
+		if errors.As(err, &moved) {
+			// Report against the repository's new name rather than failing outright.
+			result.movedTo = moved.To
+			latestVersion, err = lookup(ctx, moved.To)
+		}
+		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("lookup timed out after %s (the app's timeout): %w", appOptions[appName].Timeout, err)
+			}
+			result.status = statusError
+			result.err = err
+			return result
+		}
+		// Moved repositories aren't cached, so the move keeps being reported until it's resolved.
+		if result.movedTo == "" {
+			cacheLatestVersion(cacheKey, latestVersion)
 		}
 	}
+	result.latestVersion = latestVersion
+	result.status = versionStatus(currentVersion, latestVersion, compareModeFor(appName))
+	return result
 }
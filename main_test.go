@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
@@ -43,7 +46,7 @@ func TestHandleAddCommand(t *testing.T) {
 		appName := "myNewApp"
 		appVersion := "1.0.0"
 		output := stripAnsiCodes(captureOutput(func() {
-			handleAddCmd(appName, appVersion)
+			handleAddCmd(appName, appVersion, addOptions{})
 		}))
 		cfg, err := loadConfig()
 		if err != nil {
@@ -68,7 +71,7 @@ func TestHandleAddCommand(t *testing.T) {
 			t.Fatalf("Failed to set up initial config: %v", err)
 		}
 		output := stripAnsiCodes(captureOutput(func() {
-			handleAddCmd(appName, updatedVersion)
+			handleAddCmd(appName, updatedVersion, addOptions{})
 		}))
 		cfg, err := loadConfig()
 		if err != nil {
@@ -82,6 +85,41 @@ func TestHandleAddCommand(t *testing.T) {
 			t.Errorf("Expected success message '%s', got '%s'", expectedMsg, output)
 		}
 	})
+
+	t.Run("RejectEmptyNameOrVersion", func(t *testing.T) {
+		tests := []struct {
+			name, appName, appVersion, expected string
+		}{
+			{"EmptyName", "", "1.0.0", "Application name cannot be empty."},
+			{"WhitespaceName", "   ", "1.0.0", "Application name cannot be empty."},
+			{"EmptyVersion", "owner/repo", "", "Version for 'owner/repo' cannot be empty."},
+			{"WhitespaceVersion", "owner/repo", " \t", "Version for 'owner/repo' cannot be empty."},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				os.Remove(testFile)
+				stderr := stripAnsiCodes(captureStderr(t, func() { handleAddCmd(tt.appName, tt.appVersion, addOptions{}) }))
+				if !strings.Contains(stderr, tt.expected) {
+					t.Errorf("Expected error '%s', got '%s'", tt.expected, stderr)
+				}
+				if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+					t.Errorf("Expected no config file to be written, got err %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("TrimsNameAndVersion", func(t *testing.T) {
+		os.Remove(testFile)
+		captureOutput(func() { handleAddCmd(" owner/repo ", " 1.0.0\n", addOptions{}) })
+		cfg, err := loadConfig()
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if cfg["owner/repo"] != "1.0.0" {
+			t.Errorf("Expected trimmed entry owner/repo = 1.0.0, got %v", cfg)
+		}
+	})
 }
 
 // TestHandleListCommand tests the list command functionality including output.
@@ -101,7 +139,7 @@ func TestHandleListCommand(t *testing.T) {
 		if err := saveConfig(initialConfig); err != nil {
 			t.Fatalf("Failed to set up initial config: %v", err)
 		}
-		output := stripAnsiCodes(captureOutput(handleListCmd))
+		output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{}) }))
 
 		if !strings.Contains(output, "== Managed Applications ==") {
 			t.Errorf("Output does not contain header. Got:\n%s", output)
@@ -119,7 +157,7 @@ func TestHandleListCommand(t *testing.T) {
 		if err := saveConfig(emptyConfig); err != nil {
 			t.Fatalf("Failed to save empty config: %v", err)
 		}
-		output := stripAnsiCodes(captureOutput(handleListCmd))
+		output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{}) }))
 		expectedMsg := "Info: No applications currently managed. Use the 'add' command to add some."
 		if !strings.Contains(output, expectedMsg) {
 			t.Errorf("Expected message '%s', got '%s'", expectedMsg, output)
@@ -132,7 +170,7 @@ func TestHandleListCommand(t *testing.T) {
 		if err := saveConfig(initialConfig); err != nil {
 			t.Fatalf("Failed to set up initial config: %v", err)
 		}
-		output := stripAnsiCodes(captureOutput(handleListCmd))
+		output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{}) }))
 		if !strings.Contains(output, "== Managed Applications ==") {
 			t.Errorf("Output does not contain header. Got:\n%s", output)
 		}
@@ -140,6 +178,34 @@ func TestHandleListCommand(t *testing.T) {
 			t.Errorf("Output does not contain singleApp details. Got:\n%s", output)
 		}
 	})
+
+	t.Run("ListGroupedByOwner", func(t *testing.T) {
+		os.Remove(testFile)
+		initialConfig := Config{
+			"zeta/tool":         "1.0.0",
+			"acme/widget":       "2.0.0",
+			"acme/gadget":       "3.0.0",
+			"gitlab:acme/thing": "4.0.0",
+			"localapp":          "0.1.0",
+		}
+		if err := saveConfig(initialConfig); err != nil {
+			t.Fatalf("Failed to set up initial config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{groupBy: groupByOwner}) }))
+
+		expected := "== Managed Applications ==\n" +
+			"== acme (3) ==\n" +
+			"  - Application: acme/gadget, Version: 3.0.0\n" +
+			"  - Application: acme/widget, Version: 2.0.0\n" +
+			"  - Application: gitlab:acme/thing, Version: 4.0.0\n" +
+			"== zeta (1) ==\n" +
+			"  - Application: zeta/tool, Version: 1.0.0\n" +
+			"== other (1) ==\n" +
+			"  - Application: localapp, Version: 0.1.0\n"
+		if output != expected {
+			t.Errorf("Unexpected grouped output.\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
 }
 
 // TestHandleRemoveCommand tests the remove command functionality.
@@ -194,6 +260,7 @@ func TestHandleCheckCommand(t *testing.T) {
 
 	originalGetLatestVersionFunc := getLatestVersion // Save original
 	defer func() {
+		os.Remove(stateFile())
 		configFile = originalConfigFile
 		os.Remove(testFile)
 		getLatestVersion = originalGetLatestVersionFunc // Restore original
@@ -205,7 +272,7 @@ func TestHandleCheckCommand(t *testing.T) {
 	})
 
 	// Setup mock for getLatestVersion
-	getLatestVersion = func(appIdentifier string, apiBaseURL string) (string, error) {
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
 		// apiBaseURL is ignored in this mock as we're not making real HTTP calls
 		if resp, ok := mockResponses[appIdentifier]; ok {
 			return resp.version, resp.err
@@ -222,7 +289,7 @@ func TestHandleCheckCommand(t *testing.T) {
 		}
 		mockResponses[appName] = struct {version string; err error}{version: "1.0.0", err: nil}
 
-		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd(appName) }))
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd(appName, checkOptions{}) }))
 
 		if !strings.Contains(output, "Checking owner/app1...") || !strings.Contains(output, "Current: 1.0.0, Latest: 1.0.0 (Up to date)") {
 			t.Errorf("Expected 'Up to date' message. Got: %s", output)
@@ -237,7 +304,7 @@ func TestHandleCheckCommand(t *testing.T) {
 		}
 		mockResponses[appName] = struct {version string; err error}{version: "1.1.0", err: nil}
 
-		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd(appName) }))
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd(appName, checkOptions{}) }))
 		if !strings.Contains(output, "Current: 1.0.0, Latest: 1.1.0 (Update Available!)") {
 			t.Errorf("Expected 'Update Available!' message. Got: %s", output)
 		}
@@ -251,7 +318,7 @@ func TestHandleCheckCommand(t *testing.T) {
 		}
 		mockResponses[appName] = struct {version string; err error}{version: "1.1.0", err: nil} // Latest is older
 
-		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd(appName) }))
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd(appName, checkOptions{}) }))
 		if !strings.Contains(output, "Current: 1.2.0, Latest: 1.1.0 (Version discrepancy)") {
 			t.Errorf("Expected 'Version discrepancy' message. Got: %s", output)
 		}
@@ -272,7 +339,7 @@ func TestHandleCheckCommand(t *testing.T) {
 		os.Stderr = w
 
 		// Output from successful print before error still goes to stdout capture
-		stdoutOutput := stripAnsiCodes(captureOutput(func() { handleCheckCmd(appName) }))
+		stdoutOutput := stripAnsiCodes(captureOutput(func() { handleCheckCmd(appName, checkOptions{}) }))
 
 		w.Close()
 		errOutputBytes, _ := io.ReadAll(r)
@@ -298,7 +365,7 @@ func TestHandleCheckCommand(t *testing.T) {
 		if pipeErr != nil { t.Fatalf("Failed to create pipe: %v", pipeErr) }
 		os.Stderr = w
 
-		handleCheckCmd("owner/nonExistentApp") // This function's output is what we're testing
+		handleCheckCmd("owner/nonExistentApp", checkOptions{}) // This function's output is what we're testing
 
 		w.Close()
 		errOutputBytes, _ := io.ReadAll(r)
@@ -317,7 +384,7 @@ func TestHandleCheckCommand(t *testing.T) {
 		if err := saveConfig(Config{appNameInvalid: "1.0.0"}); err != nil {
 			t.Fatalf("Failed to save config: %v", err)
 		}
-		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd(appNameInvalid) }))
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd(appNameInvalid, checkOptions{}) }))
 		expectedMsg := "Info: Skipping invalidAppFormat: Not in 'owner/repo' format. Cannot check for updates via GitHub."
 		if !strings.Contains(output, expectedMsg) {
 			t.Errorf("Expected 'invalid format' message. Got: %s", output)
@@ -338,7 +405,7 @@ func TestHandleCheckCommand(t *testing.T) {
 		mockResponses["owner/appB"] = struct {version string; err error}{version: "1.0.0", err: nil}
 		// invalidAppC won't call getLatestVersion
 
-		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("") })) // Empty string for specificApp means check all
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{}) })) // Empty string for specificApp means check all
 
 		if !strings.Contains(output, "Checking all managed applications for updates...") {
 			t.Errorf("Expected 'Checking all' message. Got: %s", output)
@@ -360,7 +427,7 @@ func TestHandleCheckCommand(t *testing.T) {
 		if err := saveConfig(Config{}); err != nil { // Empty config
 			t.Fatalf("Failed to save empty config: %v", err)
 		}
-		rawOutput := captureOutput(func() { handleCheckCmd("") })
+		rawOutput := captureOutput(func() { handleCheckCmd("", checkOptions{}) })
 		output := strings.TrimSpace(stripAnsiCodes(rawOutput))
 		// Setting expectedMsg from the literal "Got" string from the last test failure log
 		expectedMsg := "Info: No applications currently managed. Use 'add' command to add some."
@@ -369,3 +436,655 @@ func TestHandleCheckCommand(t *testing.T) {
 		}
 	})
 }
+
+// TestDuplicateEntries tests that entries differing only by source prefix are reported and checked once.
+func TestDuplicateEntries(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersionFunc := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersionFunc }()
+
+	var requested []string
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		requested = append(requested, appIdentifier)
+		return "1.0.0", nil
+	}
+
+	t.Run("AddWarnsAboutDuplicate", func(t *testing.T) {
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleAddCmd("github:owner/repo", "1.0.0", addOptions{}) }))
+		if !strings.Contains(output, "'github:owner/repo' refers to the same repository as already-tracked 'owner/repo'") {
+			t.Errorf("Expected duplicate warning on add. Got: %s", output)
+		}
+	})
+
+	t.Run("ListReportsDuplicates", func(t *testing.T) {
+		if err := saveConfig(Config{"owner/repo": "1.0.0", "github:owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{}) }))
+		if !strings.Contains(output, "Duplicate entries for the same repository: github:owner/repo, owner/repo.") {
+			t.Errorf("Expected duplicate report in list. Got: %s", output)
+		}
+	})
+
+	t.Run("CheckAllSkipsDuplicates", func(t *testing.T) {
+		requested = nil
+		if err := saveConfig(Config{"owner/repo": "1.0.0", "github:owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{}) }))
+		if len(requested) != 1 || requested[0] != "owner/repo" {
+			t.Errorf("Expected a single lookup for owner/repo, got %v", requested)
+		}
+		if !strings.Contains(output, "Skipping owner/repo: Duplicate of 'github:owner/repo'.") {
+			t.Errorf("Expected duplicate skip message. Got: %s", output)
+		}
+	})
+}
+
+// TestHandleAddFromFileCommand tests bulk-adding apps from a newline-delimited list.
+func TestHandleAddFromFileCommand(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersionFunc := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersionFunc }()
+
+	latest := map[string]string{"owner/a": "1.1.0", "owner/b": "2.0.0", "owner/c": "3.0.0"}
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		if version, ok := latest[appIdentifier]; ok {
+			return version, nil
+		}
+		return "", fmt.Errorf("not found")
+	}
+
+	listFile := t.TempDir() + "/repos.txt"
+	content := "# repos to track\nowner/a\n\n  owner/b  \nowner/c\nowner/missing\nnotarepo\nowner/a\n"
+	if err := os.WriteFile(listFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write list file: %v", err)
+	}
+
+	t.Run("SkipsAlreadyTracked", func(t *testing.T) {
+		if err := saveConfig(Config{"owner/a": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		var output string
+		captureStderr(t, func() {
+			output = stripAnsiCodes(captureOutput(func() { handleAddFromFileCmd(listFile, false) }))
+		})
+		cfg, err := loadConfig()
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		expected := Config{"owner/a": "1.0.0", "owner/b": "2.0.0", "owner/c": "3.0.0"}
+		if len(cfg) != len(expected) {
+			t.Errorf("Expected config %v, got %v", expected, cfg)
+		}
+		for appName, version := range expected {
+			if cfg[appName] != version {
+				t.Errorf("Expected %s at %s, got '%s'", appName, version, cfg[appName])
+			}
+		}
+		if !strings.Contains(output, "Added 2, updated 0, skipped 1, failed 2.") {
+			t.Errorf("Expected summary line. Got: %s", output)
+		}
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		if err := saveConfig(Config{"owner/a": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		var output string
+		errOutput := captureStderr(t, func() {
+			output = stripAnsiCodes(captureOutput(func() { handleAddFromFileCmd(listFile, true) }))
+		})
+		cfg, _ := loadConfig()
+		if cfg["owner/a"] != "1.1.0" {
+			t.Errorf("Expected owner/a to be overwritten to 1.1.0, got '%s'", cfg["owner/a"])
+		}
+		if !strings.Contains(output, "Added 2, updated 1, skipped 0, failed 2.") {
+			t.Errorf("Expected summary line. Got: %s", output)
+		}
+		if !strings.Contains(errOutput, "Could not add 'owner/missing': not found") ||
+			!strings.Contains(errOutput, "Could not add 'notarepo': not in 'owner/repo' format") {
+			t.Errorf("Expected per-app failures on stderr. Got: %s", errOutput)
+		}
+	})
+}
+
+// captureStderr captures os.Stderr for a given function and returns it with ANSI codes stripped.
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+	oldStderr := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	os.Stderr = w
+	f()
+	w.Close()
+	os.Stderr = oldStderr
+	outBytes, _ := io.ReadAll(r)
+	return stripAnsiCodes(string(outBytes))
+}
+
+// TestCheckRenamedRepository tests that repositories redirecting to a new name are reported and optionally renamed.
+func TestCheckRenamedRepository(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersionFunc := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersionFunc }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		if appIdentifier == "oldorg/repo" {
+			return "", &RepoMovedError{From: appIdentifier, To: "neworg/repo"}
+		}
+		return "2.0.0", nil
+	}
+
+	t.Run("WarnsWithoutRenaming", func(t *testing.T) {
+		if err := saveConfig(Config{"oldorg/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("oldorg/repo", checkOptions{}) }))
+		if !strings.Contains(output, "oldorg/repo now redirects to neworg/repo") {
+			t.Errorf("Expected redirect warning. Got: %s", output)
+		}
+		if !strings.Contains(output, "Current: 1.0.0, Latest: 2.0.0 (Update Available!)") {
+			t.Errorf("Expected result for the new name. Got: %s", output)
+		}
+		cfg, _ := loadConfig()
+		if _, exists := cfg["oldorg/repo"]; !exists {
+			t.Errorf("Config should be unchanged without -rename-moved, got %v", cfg)
+		}
+	})
+
+	t.Run("RenamesWithFlag", func(t *testing.T) {
+		if err := saveConfig(Config{"oldorg/repo": "1.0.0", "other/app": "2.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{renameMoved: true}) }))
+		if !strings.Contains(output, "Renamed 'oldorg/repo' to 'neworg/repo'.") {
+			t.Errorf("Expected rename message. Got: %s", output)
+		}
+		cfg, _ := loadConfig()
+		if cfg["neworg/repo"] != "1.0.0" || cfg["other/app"] != "2.0.0" || len(cfg) != 2 {
+			t.Errorf("Expected oldorg/repo renamed to neworg/repo, got %v", cfg)
+		}
+	})
+}
+
+// TestHandleCheckCompact tests that -compact prints exactly one summary line and sets the exit code.
+func TestHandleCheckCompact(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersionFunc := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersionFunc }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		if appIdentifier == "owner/broken" {
+			return "", fmt.Errorf("mock network error")
+		}
+		return "1.1.0", nil
+	}
+
+	tests := []struct {
+		name         string
+		config       Config
+		expectedLine string
+		expectedCode int
+	}{
+		{"Empty", Config{}, "0 updates, 0 up-to-date, 0 errors", exitOK},
+		{"AllCurrent", Config{"owner/a": "1.1.0", "owner/b": "1.1.0"}, "0 updates, 2 up-to-date, 0 errors", exitOK},
+		{"UpdatesPending", Config{"owner/a": "1.0.0", "owner/b": "1.1.0"}, "1 update, 1 up-to-date, 0 errors", exitUpdatesAvailable},
+		{"WithError", Config{"owner/a": "1.0.0", "owner/b": "1.0.0", "owner/broken": "1.0.0"}, "2 updates, 0 up-to-date, 1 error", exitCheckFailed},
+		{"WithSkippedAndDiscrepancy", Config{"owner/a": "1.2.0", "myapp": "1.0.0"}, "0 updates, 0 up-to-date, 0 errors, 1 discrepancy, 1 skipped", exitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := saveConfig(tt.config); err != nil {
+				t.Fatalf("Failed to save config: %v", err)
+			}
+			var exitCode int
+			var output string
+			errOutput := captureStderr(t, func() {
+				output = stripAnsiCodes(captureOutput(func() { exitCode = handleCheckCmd("", checkOptions{compact: true}) }))
+			})
+			if output != tt.expectedLine+"\n" {
+				t.Errorf("Expected exactly one line %q, got %q", tt.expectedLine, output)
+			}
+			if errOutput != "" {
+				t.Errorf("Expected nothing on stderr, got %q", errOutput)
+			}
+			if exitCode != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d", tt.expectedCode, exitCode)
+			}
+		})
+	}
+}
+
+// TestCheckAssets tests that -assets lists the latest release's download URLs for outdated apps.
+func TestCheckAssets(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersionFunc, originalGetLatestReleaseFunc := getLatestVersion, getLatestRelease
+	defer func() { getLatestVersion, getLatestRelease = originalGetLatestVersionFunc, originalGetLatestReleaseFunc }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.1.0", nil
+	}
+	getLatestRelease = func(ctx context.Context, appIdentifier string, apiBaseURL string) (*GitHubReleaseInfo, error) {
+		return &GitHubReleaseInfo{TagName: "v1.1.0", Assets: []GitHubReleaseAsset{
+			{Name: "tool-linux.tar.gz", BrowserDownloadURL: "https://example.com/tool-linux.tar.gz"},
+			{Name: "tool-macos.zip", BrowserDownloadURL: "https://example.com/tool-macos.zip"},
+		}}, nil
+	}
+
+	if err := saveConfig(Config{"owner/outdated": "1.0.0", "owner/current": "1.1.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{assets: true}) }))
+	for _, expected := range []string{
+		"    - tool-linux.tar.gz: https://example.com/tool-linux.tar.gz",
+		"    - tool-macos.zip: https://example.com/tool-macos.zip",
+	} {
+		if strings.Count(output, expected) != 1 {
+			t.Errorf("Expected %q exactly once (only for the outdated app). Got:\n%s", expected, output)
+		}
+	}
+
+	output = stripAnsiCodes(captureOutput(func() { handleCheckCmd("owner/outdated", checkOptions{}) }))
+	if strings.Contains(output, "https://example.com") {
+		t.Errorf("Expected no assets without -assets. Got:\n%s", output)
+	}
+}
+
+// TestParseArgs tests that flags are accepted before and after positional arguments.
+func TestParseArgs(t *testing.T) {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	assets := fs.Bool("assets", false, "")
+	format := fs.String("format", "", "")
+
+	args := parseArgs(fs, []string{"-format", "text", "owner/repo", "-assets"})
+	if len(args) != 1 || args[0] != "owner/repo" {
+		t.Errorf("Expected positional [owner/repo], got %v", args)
+	}
+	if !*assets || *format != "text" {
+		t.Errorf("Expected both flags to be parsed, got assets=%v format=%q", *assets, *format)
+	}
+}
+
+// TestHandleResetCommand tests clearing the whole config with and without confirmation.
+func TestHandleResetCommand(t *testing.T) {
+	useTestConfigFile(t)
+	seed := Config{"owner/a": "1.0.0", "owner/b": "2.0.0", "owner/c": "3.0.0"}
+
+	t.Run("Confirmed", func(t *testing.T) {
+		if err := saveConfig(seed); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		useScriptedInput(t, "y\n", true)
+		output := stripAnsiCodes(captureOutput(func() { handleResetCmd(false) }))
+		if !strings.Contains(output, "Remove all 3 tracked applications? [y/N]") || !strings.Contains(output, "Success: Removed 3 applications.") {
+			t.Errorf("Expected prompt and success message. Got: %s", output)
+		}
+		if cfg, _ := loadConfig(); len(cfg) != 0 {
+			t.Errorf("Expected empty config after reset, got %v", cfg)
+		}
+	})
+
+	t.Run("Aborted", func(t *testing.T) {
+		if err := saveConfig(seed); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		useScriptedInput(t, "n\n", true)
+		output := stripAnsiCodes(captureOutput(func() { handleResetCmd(false) }))
+		if !strings.Contains(output, "Reset aborted.") {
+			t.Errorf("Expected abort message. Got: %s", output)
+		}
+		if cfg, _ := loadConfig(); len(cfg) != 3 {
+			t.Errorf("Expected config to be untouched, got %v", cfg)
+		}
+	})
+
+	t.Run("SkipConfirmation", func(t *testing.T) {
+		if err := saveConfig(Config{"owner/a": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		useScriptedInput(t, "", false)
+		output := stripAnsiCodes(captureOutput(func() { handleResetCmd(true) }))
+		if strings.Contains(output, "[y/N]") || !strings.Contains(output, "Success: Removed 1 application.") {
+			t.Errorf("Expected no prompt and a success message. Got: %s", output)
+		}
+		if cfg, _ := loadConfig(); len(cfg) != 0 {
+			t.Errorf("Expected empty config after reset, got %v", cfg)
+		}
+	})
+
+	t.Run("UndoneByRollback", func(t *testing.T) {
+		if err := saveConfig(seed); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		output := stripAnsiCodes(captureOutput(func() { handleResetCmd(true) }))
+		if !strings.Contains(output, "Backed up the previous configuration to ") {
+			t.Errorf("Expected the backup path to be printed. Got: %s", output)
+		}
+		var code int
+		captureOutput(func() { code = handleRollbackCmd("", false, true) })
+		if code != 0 {
+			t.Fatalf("Expected rollback to succeed, got exit code %d", code)
+		}
+		if cfg, _ := loadConfig(); !reflect.DeepEqual(cfg, seed) {
+			t.Errorf("Expected rollback to restore the config from before the reset, got %v", cfg)
+		}
+	})
+}
+
+// TestHandleCheckPlainStatus tests that -plain-status prints nothing and reports only through the exit code.
+func TestHandleCheckPlainStatus(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersionFunc := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersionFunc }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		if appIdentifier == "owner/broken" {
+			return "", fmt.Errorf("mock network error")
+		}
+		return "1.1.0", nil
+	}
+
+	tests := []struct {
+		name         string
+		config       Config
+		specificApp  string
+		expectedCode int
+	}{
+		{"AllCurrent", Config{"owner/a": "1.1.0"}, "", exitOK},
+		{"UpdatesPending", Config{"owner/a": "1.0.0", "owner/b": "1.1.0"}, "", exitUpdatesAvailable},
+		{"FetchError", Config{"owner/a": "1.0.0", "owner/broken": "1.0.0"}, "", exitCheckFailed},
+		{"UnknownApp", Config{"owner/a": "1.1.0"}, "owner/unknown", exitCheckFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := saveConfig(tt.config); err != nil {
+				t.Fatalf("Failed to save config: %v", err)
+			}
+			var exitCode int
+			var output, logOutput string
+			errOutput := captureStderr(t, func() {
+				logOutput = captureLog(func() {
+					output = captureOutput(func() { exitCode = handleCheckCmd(tt.specificApp, checkOptions{plainStatus: true}) })
+				})
+			})
+			if output != "" || errOutput != "" || logOutput != "" {
+				t.Errorf("Expected no output at all, got stdout=%q stderr=%q log=%q", output, errOutput, logOutput)
+			}
+			if exitCode != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d", tt.expectedCode, exitCode)
+			}
+		})
+	}
+}
+
+func TestParseAppsFlag(t *testing.T) {
+	got := parseAppsFlag(" owner/b, owner/a,,owner/b ")
+	if strings.Join(got, " ") != "owner/a owner/b" {
+		t.Errorf("Expected sorted, distinct names, got %q", got)
+	}
+	if got := parseAppsFlag(""); len(got) != 0 {
+		t.Errorf("Expected no names for an empty value, got %q", got)
+	}
+}
+
+func TestHandleCheckAppsSubset(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersionFunc := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersionFunc }()
+	var looked []string
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		looked = append(looked, appIdentifier)
+		return "1.1.0", nil
+	}
+	if err := saveConfig(Config{"owner/a": "1.0.0", "owner/b": "1.1.0", "owner/c": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	var exitCode int
+	var output string
+	errOutput := captureStderr(t, func() {
+		output = stripAnsiCodes(captureOutput(func() {
+			exitCode = handleCheckCmd("", checkOptions{apps: []string{"owner/a", "owner/b", "owner/missing"}})
+		}))
+	})
+	if strings.Join(looked, " ") != "owner/a owner/b" {
+		t.Errorf("Expected only the tracked names in the subset to be looked up, got %v", looked)
+	}
+	if !strings.Contains(output, "Checking 3 applications for updates...") ||
+		!strings.Contains(output, "Checking owner/a...  Current: 1.0.0, Latest: 1.1.0 (Update Available!)") ||
+		!strings.Contains(output, "Checking owner/b...  Current: 1.1.0, Latest: 1.1.0 (Up to date)") {
+		t.Errorf("Expected results for the tracked names, got:\n%s", output)
+	}
+	if strings.Contains(output, "owner/c") {
+		t.Errorf("Expected apps outside the subset to be left out, got:\n%s", output)
+	}
+	if !strings.Contains(errOutput, "Failed to check owner/missing: not found in your managed list") {
+		t.Errorf("Expected a per-name error for the untracked name, got stderr:\n%s", errOutput)
+	}
+	if exitCode != exitCheckFailed {
+		t.Errorf("Expected exit code %d for the untracked name, got %d", exitCheckFailed, exitCode)
+	}
+}
+
+func TestAppAlias(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersionFunc := getLatestVersion
+	defer func() {
+		getLatestVersion = originalGetLatestVersionFunc
+		appOptions = make(map[string]AppOptions)
+	}()
+	var looked []string
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		looked = append(looked, appIdentifier)
+		return "0.25.0", nil
+	}
+
+	captureOutput(func() {
+		handleAddCmd("sharkdp/bat", "0.24.0", addOptions{alias: "bat"})
+		handleAddCmd("owner/other", "1.0.0", addOptions{})
+	})
+	if _, err := loadConfig(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if appOptions["sharkdp/bat"].Alias != "bat" {
+		t.Fatalf("Expected the alias to be saved, got %+v", appOptions["sharkdp/bat"])
+	}
+
+	t.Run("ListShowsAlias", func(t *testing.T) {
+		output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{}) }))
+		if !strings.Contains(output, "  - Application: bat (sharkdp/bat), Version: 0.24.0") ||
+			!strings.Contains(output, "  - Application: owner/other, Version: 1.0.0") {
+			t.Errorf("Expected the alias alongside the identifier, got:\n%s", output)
+		}
+	})
+
+	t.Run("CheckByAlias", func(t *testing.T) {
+		looked = nil
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("bat", checkOptions{}) }))
+		if strings.Join(looked, " ") != "sharkdp/bat" {
+			t.Errorf("Expected the alias to resolve to the real identifier, got lookups %v", looked)
+		}
+		if !strings.Contains(output, "Checking bat (sharkdp/bat)...  Current: 0.24.0, Latest: 0.25.0") {
+			t.Errorf("Expected the result under the alias, got:\n%s", output)
+		}
+	})
+
+	t.Run("AliasInUse", func(t *testing.T) {
+		errOutput := captureStderr(t, func() { captureOutput(func() { handleAddCmd("owner/other", "1.0.0", addOptions{alias: "bat"}) }) })
+		if !strings.Contains(errOutput, "'bat' already refers to 'sharkdp/bat'") {
+			t.Errorf("Expected an error for an alias already in use, got: %q", errOutput)
+		}
+		if _, err := loadConfig(); err != nil || appOptions["owner/other"].Alias != "" {
+			t.Errorf("Expected the alias not to be saved, got %+v (%v)", appOptions["owner/other"], err)
+		}
+	})
+}
+
+func TestAppNotes(t *testing.T) {
+	path := useTestConfigFile(t)
+	defer func() { appOptions = make(map[string]AppOptions) }()
+
+	captureOutput(func() {
+		handleAddCmd("owner/noted", "1.0.0", addOptions{note: "used by project X"})
+		handleAddCmd("owner/plain", "2.0.0", addOptions{})
+	})
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if !strings.Contains(string(content), `note = "used by project X"`) || strings.Contains(string(content), "[\"owner/plain\"]") {
+		t.Errorf("Expected only the noted app to be saved as a table, got:\n%s", content)
+	}
+
+	// Updating the version keeps the note.
+	captureOutput(func() { handleAddCmd("owner/noted", "1.1.0", addOptions{}) })
+	if _, err := loadConfig(); err != nil || appOptions["owner/noted"].Note != "used by project X" {
+		t.Fatalf("Expected the note to survive a reload, got %+v (%v)", appOptions["owner/noted"], err)
+	}
+
+	output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{notes: true}) }))
+	expected := "  - Application: owner/noted, Version: 1.1.0\n      Note: used by project X\n  - Application: owner/plain, Version: 2.0.0\n"
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected the note under its app only, got:\n%s", output)
+	}
+	output = stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{}) }))
+	if strings.Contains(output, "Note:") {
+		t.Errorf("Expected notes to be hidden without -notes, got:\n%s", output)
+	}
+}
+
+// BenchmarkHandleList lists 1000 apps sorted by version, writing to a real file so each write
+// costs a syscall as it would on a terminal. About half the time is spent parsing the TOML.
+//
+// Before buffering the output, parsing each version once for sorting and pre-sizing maps:
+//
+//	BenchmarkHandleList 	     272	   8453015 ns/op
+//
+// After:
+//
+//	BenchmarkHandleList 	     595	   4190779 ns/op
+func BenchmarkHandleList(b *testing.B) {
+	useTestConfigFile(b)
+	config := make(Config, 1000)
+	for i := 0; i < 1000; i++ {
+		config[fmt.Sprintf("owner%d/app%d", i%50, i)] = fmt.Sprintf("%d.%d.%d", i%7, i%13, i)
+	}
+	if err := saveConfig(config); err != nil {
+		b.Fatalf("Failed to save config: %v", err)
+	}
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+	originalOutput := output
+	output = devNull
+	defer func() { output = originalOutput }()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handleListCmd(listOptions{sortBy: sortByVersion})
+	}
+}
+
+func TestLatestSentinel(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	looked := 0
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		looked++
+		return "2.3.0", nil
+	}
+	if err := saveConfig(Config{"owner/follow": "latest", "owner/pinned": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{}) }))
+	if !strings.Contains(output, "  - Application: owner/follow, Version: latest (tracking)\n") {
+		t.Errorf("Expected the app to be listed as tracking the latest version, got:\n%s", output)
+	}
+
+	var exitCode int
+	output = stripAnsiCodes(captureOutput(func() { exitCode = handleCheckCmd("owner/follow", checkOptions{}) }))
+	if !strings.Contains(output, "Current: latest, Latest: 2.3.0 (Up to date)") || exitCode != exitOK {
+		t.Errorf("Expected the real latest version without an update (exit %d), got:\n%s", exitCode, output)
+	}
+	output = captureOutput(func() { handleCheckCmd("", checkOptions{onlyUpdates: true}) })
+	if output != "owner/pinned\n" {
+		t.Errorf("Expected only the pinned app to have an update, got %q", output)
+	}
+
+	// Re-recording the latest versions leaves the sentinel alone.
+	listFile := t.TempDir() + "/repos.txt"
+	writeTestFile(t, listFile, "owner/follow\nowner/pinned\n")
+	looked = 0
+	output = stripAnsiCodes(captureOutput(func() { handleAddFromFileCmd(listFile, true) }))
+	if !strings.Contains(output, "Skipping 'owner/follow': tracking the latest version.") || looked != 1 {
+		t.Errorf("Expected the tracking app to be skipped without a lookup (%d lookups), got:\n%s", looked, output)
+	}
+	if cfg, _ := loadConfig(); cfg["owner/follow"] != "latest" || cfg["owner/pinned"] != "2.3.0" {
+		t.Errorf("Expected the sentinel to be kept, got %v", cfg)
+	}
+	if problems := validateConfig(Config{"owner/follow": "latest"}); len(problems) != 0 {
+		t.Errorf("Expected the sentinel to be valid, got %v", problems)
+	}
+}
+
+func TestAddMonotonic(t *testing.T) {
+	path := useTestConfigFile(t)
+	defer func() { appOptions = make(map[string]AppOptions) }()
+	content := "\"owner/free\" = \"2.0.0\"\n\n[\"owner/pinned\"]\nversion = \"2.0.0\"\nmonotonic = true\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	versionOf := func(appName string) string {
+		config, err := loadConfig()
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		return config[appName]
+	}
+
+	t.Run("RejectedDowngrade", func(t *testing.T) {
+		errOutput := captureStderr(t, func() { captureOutput(func() { handleAddCmd("owner/pinned", "1.9.0", addOptions{}) }) })
+		if !strings.Contains(errOutput, "Refusing to downgrade 'owner/pinned' from version '2.0.0' to '1.9.0'") {
+			t.Errorf("Expected the downgrade to be refused, got: %q", errOutput)
+		}
+		if version := versionOf("owner/pinned"); version != "2.0.0" {
+			t.Errorf("Expected the version to stay 2.0.0, got %s", version)
+		}
+		// -monotonic applies to apps without the option too.
+		captureStderr(t, func() { captureOutput(func() { handleAddCmd("owner/free", "1.0.0", addOptions{monotonic: true}) }) })
+		if version := versionOf("owner/free"); version != "2.0.0" {
+			t.Errorf("Expected -monotonic to refuse the downgrade, got %s", version)
+		}
+	})
+
+	t.Run("NormalUpgrade", func(t *testing.T) {
+		output := stripAnsiCodes(captureOutput(func() { handleAddCmd("owner/pinned", "2.1.0", addOptions{monotonic: true}) }))
+		if !strings.Contains(output, "updated from version '2.0.0' to '2.1.0'") || versionOf("owner/pinned") != "2.1.0" {
+			t.Errorf("Expected the upgrade to be recorded, got:\n%s", output)
+		}
+	})
+
+	t.Run("ForcedDowngrade", func(t *testing.T) {
+		captureOutput(func() { handleAddCmd("owner/pinned", "1.9.0", addOptions{force: true}) })
+		if version := versionOf("owner/pinned"); version != "1.9.0" {
+			t.Errorf("Expected -force to record the downgrade, got %s", version)
+		}
+		if !appOptions["owner/pinned"].Monotonic {
+			t.Errorf("Expected the monotonic option to be kept")
+		}
+	})
+
+	t.Run("OtherAppsUnaffected", func(t *testing.T) {
+		captureOutput(func() { handleAddCmd("owner/free", "1.0.0", addOptions{}) })
+		if version := versionOf("owner/free"); version != "1.0.0" {
+			t.Errorf("Expected a downgrade of an app without monotonic to be recorded, got %s", version)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// formatMetrics renders results in the Prometheus text exposition format, for node_exporter's
+// textfile collector. Skipped apps are left out; apps that failed to check only count
+// towards shouldupdate_check_errors_total.
+func formatMetrics(results []checkResult) string {
+	var b strings.Builder
+	b.WriteString("# HELP shouldupdate_update_available Whether a newer release than the tracked version is available.\n")
+	b.WriteString("# TYPE shouldupdate_update_available gauge\n")
+	failed := 0
+	for _, result := range results {
+		switch result.status {
+		case statusError:
+			failed++
+			continue
+		case statusSkipped:
+			continue
+		}
+		value := 0
+		if result.status == statusUpdateAvailable {
+			value = 1
+		}
+		fmt.Fprintf(&b, "shouldupdate_update_available{app=\"%s\"} %d\n", escapeLabelValue(result.appName), value)
+	}
+	b.WriteString("# HELP shouldupdate_check_errors_total Number of apps that could not be checked in the last run.\n")
+	b.WriteString("# TYPE shouldupdate_check_errors_total gauge\n")
+	fmt.Fprintf(&b, "shouldupdate_check_errors_total %d\n", failed)
+	return b.String()
+}
+
+// escapeLabelValue escapes a Prometheus label value.
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// writeMetricsFile writes the metrics for results to path atomically, so a scrape never
+// sees a partial file.
+func writeMetricsFile(path string, results []checkResult) error {
+	if err := writeFileAtomic(path, []byte(formatMetrics(results)), 0644); err != nil {
+		return fmt.Errorf("could not write metrics file: %w", err)
+	}
+	return nil
+}
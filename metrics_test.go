@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatMetrics(t *testing.T) {
+	results := []checkResult{
+		{appName: "owner/a", currentVersion: "1.0.0", latestVersion: "1.1.0", status: statusUpdateAvailable},
+		{appName: "owner/b", currentVersion: "1.1.0", latestVersion: "1.1.0", status: statusUpToDate},
+		{appName: "owner/c", currentVersion: "1.2.0", latestVersion: "1.1.0", status: statusDiscrepancy},
+		{appName: "myapp", currentVersion: "1.0.0", status: statusSkipped},
+		{appName: "owner/broken", currentVersion: "1.0.0", status: statusError, err: errors.New("boom")},
+		{appName: `odd"name`, currentVersion: "1.0.0", latestVersion: "2.0.0", status: statusUpdateAvailable},
+	}
+	expected := `# HELP shouldupdate_update_available Whether a newer release than the tracked version is available.
+# TYPE shouldupdate_update_available gauge
+shouldupdate_update_available{app="owner/a"} 1
+shouldupdate_update_available{app="owner/b"} 0
+shouldupdate_update_available{app="owner/c"} 0
+shouldupdate_update_available{app="odd\"name"} 1
+# HELP shouldupdate_check_errors_total Number of apps that could not be checked in the last run.
+# TYPE shouldupdate_check_errors_total gauge
+shouldupdate_check_errors_total 1
+`
+	if got := formatMetrics(results); got != expected {
+		t.Errorf("Unexpected metrics.\nGot:\n%s\nExpected:\n%s", got, expected)
+	}
+}
+
+func TestHandleCheckMetricsFile(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.1.0", nil
+	}
+	if err := saveConfig(Config{"owner/a": "1.0.0", "owner/b": "1.1.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	dir := t.TempDir()
+	metricsPath := filepath.Join(dir, "updates.prom")
+	if err := os.WriteFile(metricsPath, []byte("stale"), 0644); err != nil {
+		t.Fatalf("Failed to write stale metrics: %v", err)
+	}
+	handleCheckCmd("", checkOptions{plainStatus: true, metricsFile: metricsPath})
+
+	data, err := os.ReadFile(metricsPath)
+	if err != nil {
+		t.Fatalf("Expected a metrics file: %v", err)
+	}
+	for _, line := range []string{`shouldupdate_update_available{app="owner/a"} 1`, `shouldupdate_update_available{app="owner/b"} 0`, "shouldupdate_check_errors_total 0"} {
+		if !strings.Contains(string(data), line+"\n") {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, data)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, got %v", entries)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// limitRate caps, in KB/s, how fast the bodies of body-heavy responses are read, set by the
+// global -limit-rate flag. 0 means no limit.
+var limitRate int
+
+// bodyHeavyKey marks a context whose requests fetch large bodies, e.g. release notes.
+type bodyHeavyKey struct{}
+
+// withBodyRateLimit returns a copy of ctx whose responses are read no faster than -limit-rate.
+// Only requests for release notes, assets and release history use it; the small JSON of a
+// latest version lookup isn't worth slowing down.
+func withBodyRateLimit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bodyHeavyKey{}, true)
+}
+
+// rateLimitedReader reads from r no faster than rate bytes per second on average, sleeping
+// between reads as needed.
+type rateLimitedReader struct {
+	r     io.ReadCloser
+	rate  int
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	start time.Time
+	read  int64
+}
+
+// newRateLimitedReader returns a reader limiting r to rate bytes per second using the real clock.
+func newRateLimitedReader(r io.ReadCloser, rate int) *rateLimitedReader {
+	return &rateLimitedReader{r: r, rate: rate, now: time.Now, sleep: time.Sleep}
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if r.start.IsZero() {
+		r.start = r.now()
+	}
+	// Read at most a second's worth at a time, so the pace stays even.
+	if len(p) > r.rate {
+		p = p[:r.rate]
+	}
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	due := r.start.Add(time.Duration(r.read) * time.Second / time.Duration(r.rate))
+	if wait := due.Sub(r.now()); wait > 0 {
+		r.sleep(wait)
+	}
+	return n, err
+}
+
+func (r *rateLimitedReader) Close() error {
+	return r.r.Close()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedReader(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	body := strings.Repeat("x", 4096)
+	reader := newRateLimitedReader(io.NopCloser(strings.NewReader(body)), 1024)
+	reader.now = clock.Now
+	reader.sleep = clock.Sleep
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if string(data) != body {
+		t.Errorf("Expected the whole body to be read, got %d bytes", len(data))
+	}
+	if elapsed := clock.Now().Sub(start); elapsed != 4*time.Second {
+		t.Errorf("Expected 4KB at 1KB/s to take 4s, got %v", elapsed)
+	}
+	for _, sleep := range clock.sleeps {
+		if sleep > time.Second {
+			t.Errorf("Expected reads to be paced a second at a time, got sleeps %v", clock.sleeps)
+			break
+		}
+	}
+}
+
+func TestDoRequestLimitsBodyHeavyRequests(t *testing.T) {
+	defer func() { limitRate = 0 }()
+	limitRate = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"tag_name": "v1.0.0"}`)
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name    string
+		ctx     context.Context
+		limited bool
+	}{
+		{"Plain", context.Background(), false},
+		{"BodyHeavy", withBodyRateLimit(context.Background()), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(tc.ctx, "GET", server.URL, nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			resp, err := doRequest(req)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			defer resp.Body.Close()
+			if _, limited := resp.Body.(*rateLimitedReader); limited != tc.limited {
+				t.Errorf("Expected rate limiting %v, got %v", tc.limited, limited)
+			}
+		})
+	}
+}
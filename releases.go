@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultReleasesLimit is how many releases 'releases' prints unless -limit says otherwise.
+const defaultReleasesLimit = 10
+
+// handleReleasesCmd prints the most recent releases of appName, newest first, up to limit.
+// They are in GitHub's order unless sortBy is one of the keys of sortReleases.
+// appName may be a tracked app or alias, or any GitHub owner/repo. It returns the process exit code.
+func handleReleasesCmd(appName string, limit int, sortBy string) int {
+	if config, err := loadConfig(); err == nil {
+		appName = resolveAppName(config, appName)
+	}
+	source, identifier, err := resolveSource(appName)
+	if err != nil {
+		PrintError("%v", err)
+		return 1
+	}
+	if _, isGitHub := source.(githubSource); !isGitHub {
+		PrintError("Listing releases is only supported for GitHub repositories.")
+		return 1
+	}
+	if !source.ValidIdentifier(identifier) {
+		PrintError("'%s' is not in '%s' format.", identifier, source.IdentifierFormat())
+		return 1
+	}
+
+	// Fetch only as many pages as the limit needs. Sorted, the newest releases may be on
+	// any page, so look through as many as for -include-drafts, or the limit if that's more.
+	originalMaxPages := maxReleasePages
+	pages := (limit + releasesPageSize - 1) / releasesPageSize
+	if sortBy == "" || pages > maxReleasePages {
+		maxReleasePages = pages
+	}
+	releases, err := listReleasesGitHub(withBodyRateLimit(requestContext), identifier, githubAPIBase)
+	maxReleasePages = originalMaxPages
+	if err != nil {
+		PrintError("Could not list releases of %s: %v", Colorize(identifier, colorMagentaFg), err)
+		return 1
+	}
+	if len(releases) == 0 {
+		PrintInfo("%s has no releases.", identifier)
+		return 0
+	}
+	sortReleases(releases, sortBy)
+	if len(releases) > limit {
+		releases = releases[:limit]
+	}
+
+	PrintHeader("Releases of %s", displayName(appName))
+	for _, release := range releases {
+		printRelease(release)
+	}
+	return 0
+}
+
+// printRelease prints one release of the 'releases' output: its tag, any markers, its name if
+// it differs from the tag, when it was published and its URL.
+func printRelease(release GitHubReleaseInfo) {
+	line := fmt.Sprintf("  - %s", Colorize(release.TagName, colorYellowFg))
+	var markers []string
+	if release.Draft {
+		markers = append(markers, "draft")
+	}
+	if release.Prerelease {
+		markers = append(markers, "pre-release")
+	}
+	if len(markers) > 0 {
+		line += " " + Colorize("["+strings.Join(markers, ", ")+"]", colorMagentaFg)
+	}
+	if name := strings.TrimSpace(release.Name); name != "" && name != release.TagName {
+		line += fmt.Sprintf(" %q", name)
+	}
+	if !release.PublishedAt.IsZero() {
+		line += " " + Colorize(release.PublishedAt.Format("2006-01-02"), colorCyanFg)
+	}
+	if release.HTMLURL != "" {
+		line += " " + Colorize(release.HTMLURL, colorBlueFg)
+	}
+	PrintMessage("%s", line)
+}
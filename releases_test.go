@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHandleReleasesCmd(t *testing.T) {
+	useTestConfigFile(t)
+	requests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		start := 0
+		if r.URL.Query().Get("page") == "2" {
+			start = releasesPageSize
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/releases?per_page=30&page=2>; rel="next"`, server.URL))
+		}
+		var releases []string
+		for i := start; i < start+releasesPageSize && i < 40; i++ {
+			releases = append(releases, fmt.Sprintf(`{"tag_name": "v1.%d.0", "name": "Release 1.%d", "published_at": "2024-03-%02dT10:00:00Z", "html_url": "https://github.com/owner/repo/releases/tag/v1.%d.0"}`,
+				40-i, 40-i, i%28+1, 40-i))
+		}
+		if start == 0 {
+			releases[0] = `{"tag_name": "v2.0.0-rc1", "name": "v2.0.0-rc1", "prerelease": true, "published_at": "2024-04-01T00:00:00Z", "html_url": "https://github.com/owner/repo/releases/tag/v2.0.0-rc1"}`
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(releases, ","))
+	}))
+	defer server.Close()
+	originalAPIBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalAPIBase }()
+
+	t.Run("Formatting", func(t *testing.T) {
+		requests = 0
+		var exitCode int
+		output := stripAnsiCodes(captureOutput(func() { exitCode = handleReleasesCmd("owner/repo", 2, "") }))
+		expected := "== Releases of owner/repo ==\n" +
+			"  - v2.0.0-rc1 [pre-release] 2024-04-01 https://github.com/owner/repo/releases/tag/v2.0.0-rc1\n" +
+			"  - v1.39.0 \"Release 1.39\" 2024-03-02 https://github.com/owner/repo/releases/tag/v1.39.0\n"
+		if output != expected {
+			t.Errorf("Unexpected output.\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+		if exitCode != 0 || requests != 1 {
+			t.Errorf("Expected exit code 0 after a single request, got %d after %d", exitCode, requests)
+		}
+	})
+
+	t.Run("LimitSpansPages", func(t *testing.T) {
+		requests = 0
+		output := stripAnsiCodes(captureOutput(func() { handleReleasesCmd("owner/repo", 35, "") }))
+		if lines := strings.Count(output, "  - "); lines != 35 {
+			t.Errorf("Expected 35 releases, got %d:\n%s", lines, output)
+		}
+		if requests != 2 {
+			t.Errorf("Expected 2 page requests, got %d", requests)
+		}
+		if maxReleasePages != defaultMaxReleasePages {
+			t.Errorf("Expected the page cap to be restored, got %d", maxReleasePages)
+		}
+	})
+
+	t.Run("LimitAboveAvailable", func(t *testing.T) {
+		output := stripAnsiCodes(captureOutput(func() { handleReleasesCmd("owner/repo", 100, "") }))
+		if lines := strings.Count(output, "  - "); lines != 40 {
+			t.Errorf("Expected all 40 releases, got %d", lines)
+		}
+	})
+
+	t.Run("OnlyGitHub", func(t *testing.T) {
+		stderr := stripAnsiCodes(captureStderr(t, func() {
+			if code := handleReleasesCmd("gitlab:group/project", 5, ""); code != 1 {
+				t.Errorf("Expected exit code 1, got %d", code)
+			}
+		}))
+		if !strings.Contains(stderr, "only supported for GitHub") {
+			t.Errorf("Expected a GitHub-only error, got: %s", stderr)
+		}
+	})
+}
+
+func TestHandleReleasesCmdSort(t *testing.T) {
+	useTestConfigFile(t)
+	// In creation order, as GitHub lists them: a backported patch came after the newer minor,
+	// and an old release was published late.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `[
+			{"tag_name": "v1.9.1", "published_at": "2024-05-01T00:00:00Z"},
+			{"tag_name": "v2.0.0", "published_at": "2024-04-01T00:00:00Z"},
+			{"tag_name": "v1.10.0", "published_at": "2024-03-01T00:00:00Z"},
+			{"tag_name": "v1.2.0", "published_at": "2024-06-01T00:00:00Z"},
+			{"tag_name": "v3.0.0", "draft": true}
+		]`)
+	}))
+	defer server.Close()
+	originalAPIBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalAPIBase }()
+
+	tags := func(output string) []string {
+		var tags []string
+		for _, line := range strings.Split(stripAnsiCodes(output), "\n") {
+			if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "-" {
+				tags = append(tags, fields[1])
+			}
+		}
+		return tags
+	}
+
+	tests := []struct {
+		sortBy   string
+		expected []string
+	}{
+		{"", []string{"v1.9.1", "v2.0.0", "v1.10.0", "v1.2.0", "v3.0.0"}},
+		{sortByDate, []string{"v3.0.0", "v1.2.0", "v1.9.1", "v2.0.0", "v1.10.0"}},
+		{sortBySemver, []string{"v3.0.0", "v2.0.0", "v1.10.0", "v1.9.1", "v1.2.0"}},
+	}
+	for _, tt := range tests {
+		got := tags(captureOutput(func() { handleReleasesCmd("owner/repo", 10, tt.sortBy) }))
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Sorted by %q: expected %v, got %v", tt.sortBy, tt.expected, got)
+		}
+	}
+
+	// The limit applies after sorting.
+	if got := tags(captureOutput(func() { handleReleasesCmd("owner/repo", 2, sortBySemver) })); !reflect.DeepEqual(got, []string{"v3.0.0", "v2.0.0"}) {
+		t.Errorf("Expected the two highest versions, got %v", got)
+	}
+}
@@ -0,0 +1,505 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Output formats supported by the check command.
+const (
+	formatText          = "text"
+	formatGitHubActions = "github-actions"
+	formatPorcelain     = "porcelain"
+	formatNDJSON        = "ndjson"
+)
+
+// CheckStatus is the outcome of checking an application.
+type CheckStatus int
+
+// Statuses an application can end up in after a check. The zero value is statusError, so a
+// result that never got a status counts as failed.
+const (
+	statusError CheckStatus = iota
+	statusUpToDate
+	statusUpdateAvailable
+	statusDiscrepancy
+	statusSkipped
+)
+
+// statusNames are the human-readable names of the statuses, as shown in text output.
+var statusNames = map[CheckStatus]string{
+	statusError:           "Error",
+	statusUpToDate:        "Up to date",
+	statusUpdateAvailable: "Update Available!",
+	statusDiscrepancy:     "Version discrepancy",
+	statusSkipped:         "Skipped",
+}
+
+func (s CheckStatus) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("CheckStatus(%d)", int(s))
+}
+
+// color returns the color a status is shown in.
+func (s CheckStatus) color() string {
+	switch s {
+	case statusUpToDate:
+		return colorGreenFg
+	case statusDiscrepancy:
+		return colorYellowFg
+	case statusSkipped:
+		return colorMagentaFg
+	}
+	return colorRedFg // Updates and errors both need attention.
+}
+
+// Exit codes returned by the check command.
+const (
+	exitOK               = 0
+	exitUpdatesAvailable = 1
+	exitCheckFailed      = 2
+)
+
+// checkOptions holds the flags that affect how 'check' reports its results.
+type checkOptions struct {
+	format      string // One of the format* constants; empty means formatText.
+	renameMoved bool   // Rename config entries for repositories that now redirect elsewhere.
+	compact     bool   // Suppress per-app output and print a single summary line instead.
+	assets      bool   // List release assets for apps with an update available.
+	plainStatus bool   // Print nothing at all; only the exit code reports the outcome.
+
+	onlyUpdates        bool     // Print just the names of apps with an update available.
+	runVersionCommands bool     // Run apps' version_command to find their installed version.
+	sortBy             string   // One of the sortBy* keys; empty means by name.
+	metricsFile        string   // If set, write Prometheus metrics for the results to this file.
+	includeDrafts      bool     // Consider draft releases on GitHub, too.
+	apps               []string // If set, check only these apps, in name order, instead of all.
+	quietErrors        bool     // Don't print failed checks; they still count towards the summary and exit code.
+	minBump            string   // If set, updates smaller than this bump size are reported as up to date.
+	auditLog           string   // If set, append a line summarizing the run to this file.
+	onUpdate           string   // If set, a command to run for each app with an update available.
+	verify             bool     // Confirm the GitHub release of each update still exists before reporting it.
+	preflight          bool     // Before checking all apps, stop if the GitHub API is unreachable or rate limited.
+	changedOnly        bool     // Print only apps whose status changed since the previous check.
+	commitsBehind      bool     // Count the commits between the current and latest tags of apps with an update on GitHub.
+	parallelSources    bool     // When checking several apps, check different hosts concurrently; see scheduleByHost.
+	badgeFile          string   // If set, write a shields.io badge JSON for the results to this file.
+	groupOutput        bool     // Print text results in sections by status, after all apps are checked.
+	descriptions       bool     // Show the description of each app's GitHub repository.
+}
+
+// textOutput reports whether results are printed as human-readable text, as opposed
+// to a machine-oriented format or a summary line.
+func (opts checkOptions) textOutput() bool {
+	return (opts.format == "" || opts.format == formatText) && !opts.compact && !opts.onlyUpdates
+}
+
+// checkResult is the outcome of checking a single application.
+type checkResult struct {
+	appName        string
+	currentVersion string
+	latestVersion  string
+	status         CheckStatus
+	skipReason     string // Set when status is statusSkipped.
+	movedTo        string // Set when the repository now redirects to a different owner/repo.
+	draft          bool   // Set when latestVersion is an unpublished draft release.
+	previousLatest string // The latest version seen by the previous check, if it has moved since.
+	source         string // The entry of the app's sources list that answered, if it has one.
+	previousStatus string // The status of the previous check, as in ndjson output, if it has changed since.
+	commitsBehind  int    // Commits the latest tag is ahead of the current one by, when requested and known.
+	statusChanged  bool   // Set when the status differs from the previous check's, or the app was never checked before.
+	cached         bool   // Set when latestVersion was read from the cache by 'check -offline'.
+	description    string // The description of the app's GitHub repository, when requested and known.
+	err            error  // Set when status is statusError.
+
+	assets    []GitHubReleaseAsset // Assets of the latest release, when requested.
+	assetsErr error                // Set if the assets were requested but couldn't be fetched.
+}
+
+// sourceRef returns the app reference the latest version was looked up with: the entry of the
+// app's sources list that answered, or else the app name itself.
+func (r checkResult) sourceRef() string {
+	if r.source != "" {
+		return r.source
+	}
+	return r.appName
+}
+
+// sourceChainError is returned when every entry of an app's sources list failed.
+type sourceChainError struct {
+	errs []error // One per source, in order.
+}
+
+func (e *sourceChainError) Error() string {
+	messages := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		messages[i] = err.Error()
+	}
+	return "all sources failed: " + strings.Join(messages, "; ")
+}
+
+func (e *sourceChainError) Unwrap() []error { return e.errs }
+
+// checkSummary counts check results by status.
+type checkSummary struct {
+	updates       int
+	upToDate      int
+	discrepancies int
+	skipped       int
+	errors        int
+}
+
+// summarizeResults tallies results by status.
+func summarizeResults(results []checkResult) checkSummary {
+	var summary checkSummary
+	for _, result := range results {
+		switch result.status {
+		case statusUpdateAvailable:
+			summary.updates++
+		case statusUpToDate:
+			summary.upToDate++
+		case statusDiscrepancy:
+			summary.discrepancies++
+		case statusSkipped:
+			summary.skipped++
+		default:
+			summary.errors++
+		}
+	}
+	return summary
+}
+
+// exitCode returns exitCheckFailed if anything failed, exitUpdatesAvailable if
+// any updates were found, and exitOK otherwise.
+func (s checkSummary) exitCode() int {
+	switch {
+	case s.errors > 0:
+		return exitCheckFailed
+	case s.updates > 0:
+		return exitUpdatesAvailable
+	}
+	return exitOK
+}
+
+// compactLine renders the summary as a single line, e.g. "3 updates, 7 up-to-date, 1 error".
+// Discrepancies and skipped apps are only mentioned when there are any.
+func (s checkSummary) compactLine() string {
+	parts := []string{
+		pluralize(s.updates, "update", "updates"),
+		fmt.Sprintf("%d up-to-date", s.upToDate),
+		pluralize(s.errors, "error", "errors"),
+	}
+	if s.discrepancies > 0 {
+		parts = append(parts, pluralize(s.discrepancies, "discrepancy", "discrepancies"))
+	}
+	if s.skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", s.skipped))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// pluralize formats n followed by the singular or plural noun.
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// printCheckResult renders a single result in the format selected by opts.
+func printCheckResult(result checkResult, opts checkOptions) {
+	if opts.compact {
+		return
+	}
+	if opts.changedOnly && !result.statusChanged {
+		return
+	}
+	if opts.onlyUpdates {
+		// Bare names only, for piping into other tools.
+		if result.status == statusUpdateAvailable {
+			fmt.Fprintln(outputWriter(), result.appName)
+		}
+		return
+	}
+	if opts.format == formatPorcelain {
+		printCheckResultPorcelain(result)
+		return
+	}
+	if opts.format == formatNDJSON {
+		printCheckResultNDJSON(result)
+		return
+	}
+	if result.status == statusError && opts.quietErrors {
+		return
+	}
+	if result.movedTo != "" && !opts.renameMoved {
+		printMovedWarning(result, opts)
+	}
+	switch opts.format {
+	case formatGitHubActions:
+		printCheckResultGitHubActions(result)
+	default:
+		printCheckResultText(result)
+	}
+}
+
+// printCheckResultText renders a result for humans, using colors.
+func printCheckResultText(result checkResult) {
+	if result.status == statusSkipped {
+		PrintInfo("Skipping %s: %s", Colorize(result.appName, colorMagentaFg), result.skipReason)
+		return
+	}
+
+	// Using fmt.Fprintf directly for more control over the line ending and formatting
+	fmt.Fprintf(outputWriter(), "%sChecking %s... %s", ansi(colorFgDefault), Colorize(displayName(result.appName), colorYellowFg), ansi(colorReset))
+	if result.status == statusError {
+		// PrintError already adds a newline, but the "Checking..." line needs one first.
+		fmt.Fprintln(outputWriter())
+		PrintError("Failed to check %s: %v", Colorize(result.appName, colorMagentaFg), result.err)
+		return
+	}
+
+	latestColor := result.status.color()
+	current, latest := versionDiff(result.currentVersion, result.latestVersion, colorCyanFg, latestColor)
+	if result.draft {
+		latest += Colorize(" [draft]", latestColor)
+	}
+	if result.cached {
+		latest += " (cached)"
+	}
+	fmt.Fprintf(outputWriter(), "%s Current: %s, Latest: %s (%s)%s\n",
+		ansi(colorFgDefault),
+		current,
+		latest,
+		Colorize(result.status.String(), latestColor),
+		ansi(colorReset))
+	if result.source != "" {
+		PrintMessage("    via %s", Colorize(result.source, colorBlueFg))
+	}
+	if result.description != "" {
+		PrintMessage("    %s", result.description)
+	}
+	if result.commitsBehind > 0 {
+		PrintMessage("    %s behind", pluralize(result.commitsBehind, "commit", "commits"))
+	}
+	if result.previousLatest != "" {
+		previous, latest := versionDiff(result.previousLatest, result.latestVersion, colorCyanFg, colorCyanFg)
+		PrintMessage("    latest moved %s → %s since last check", previous, latest)
+	}
+	printAssets(result)
+}
+
+// printAssets lists the release assets attached to a result, if any were requested.
+func printAssets(result checkResult) {
+	if result.assetsErr != nil {
+		PrintError("Could not list assets for %s: %v", Colorize(result.appName, colorMagentaFg), result.assetsErr)
+		return
+	}
+	for _, asset := range result.assets {
+		PrintMessage("    - %s: %s", Colorize(asset.Name, colorYellowFg), Colorize(asset.BrowserDownloadURL, colorBlueFg))
+	}
+}
+
+// printCheckResultGitHubActions renders a result as a GitHub Actions workflow command,
+// so findings show up as annotations in the Actions UI. Colors are never used here.
+func printCheckResultGitHubActions(result checkResult) {
+	var level, message string
+	switch result.status {
+	case statusUpdateAvailable:
+		level = "warning"
+		message = fmt.Sprintf("%s has an update: %s -> %s", result.appName, result.currentVersion, result.latestVersion)
+		if result.draft {
+			message += " (draft release)"
+		}
+	case statusDiscrepancy:
+		level = "warning"
+		message = fmt.Sprintf("%s has a version discrepancy: current %s, latest %s", result.appName, result.currentVersion, result.latestVersion)
+	case statusUpToDate:
+		level = "notice"
+		message = fmt.Sprintf("%s is up to date: %s", result.appName, result.currentVersion)
+	case statusSkipped:
+		level = "notice"
+		message = fmt.Sprintf("Skipping %s: %s", result.appName, result.skipReason)
+	default:
+		level = "error"
+		message = fmt.Sprintf("Failed to check %s: %v", result.appName, result.err)
+	}
+	fmt.Fprintf(outputWriter(), "::%s ::%s\n", level, escapeActionsMessage(message))
+}
+
+// porcelainStatusCodes maps statuses to the single-character codes of the porcelain format.
+var porcelainStatusCodes = map[CheckStatus]string{
+	statusUpdateAvailable: "U",
+	statusUpToDate:        "=",
+	statusDiscrepancy:     "D",
+	statusSkipped:         "S",
+	statusError:           "E",
+}
+
+// printCheckResultPorcelain renders a result as "<code> <app> <current> <latest>", a format meant
+// for scripts that is kept stable across versions. Missing values are printed as "-".
+// Colors, warnings and error details are never included.
+func printCheckResultPorcelain(result checkResult) {
+	code, ok := porcelainStatusCodes[result.status]
+	if !ok {
+		code = "E"
+	}
+	fmt.Fprintf(outputWriter(), "%s %s %s %s\n", code, porcelainField(result.appName),
+		porcelainField(result.currentVersion), porcelainField(result.latestVersion))
+}
+
+// porcelainField returns value, or "-" if it is empty, so every line has the same number of fields.
+func porcelainField(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// ndjsonStatuses maps statuses to the identifiers used in ndjson output.
+var ndjsonStatuses = map[CheckStatus]string{
+	statusUpdateAvailable: "update_available",
+	statusUpToDate:        "up_to_date",
+	statusDiscrepancy:     "discrepancy",
+	statusSkipped:         "skipped",
+	statusError:           "error",
+}
+
+// ndjsonResult is the JSON object written for each result in ndjson output.
+type ndjsonResult struct {
+	App         string   `json:"app"`
+	Alias       string   `json:"alias,omitempty"`
+	Current     string   `json:"current"`
+	Latest      string   `json:"latest,omitempty"`
+	Status      string   `json:"status"`
+	Previous    string   `json:"previous_latest,omitempty"` // Set when the latest version moved since the last check.
+	Behind      int      `json:"commits_behind,omitempty"`  // Set with -commits-behind, when known.
+	Draft       bool     `json:"draft,omitempty"`
+	Cached      bool     `json:"cached,omitempty"` // Set when the latest version comes from the cache, offline.
+	MovedTo     string   `json:"moved_to,omitempty"`
+	Source      string   `json:"source,omitempty"`      // The entry of the app's sources list that answered.
+	Description string   `json:"description,omitempty"` // Set with -descriptions, when known.
+	SkipReason  string   `json:"skip_reason,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	ErrorKind   string   `json:"error_kind,omitempty"` // See errorKind.
+	Assets      []string `json:"assets,omitempty"`     // Download URLs, when requested.
+}
+
+// errorKind returns an identifier for the kind of err, for scripts to act on without parsing the
+// message: "not_found", "rate_limited", "invalid_identifier" or "network", or "" for other errors.
+func errorKind(err error) string {
+	var netErr *NetworkError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrInvalidIdentifier):
+		return "invalid_identifier"
+	case errors.As(err, &netErr):
+		return "network"
+	}
+	return ""
+}
+
+// printCheckResultNDJSON renders a result as a single line of JSON, so that streaming tools can
+// process each result as soon as it's printed.
+func printCheckResultNDJSON(result checkResult) {
+	status, ok := ndjsonStatuses[result.status]
+	if !ok {
+		status = "error"
+	}
+	line := ndjsonResult{
+		App:         result.appName,
+		Alias:       appOptions[result.appName].Alias,
+		Current:     result.currentVersion,
+		Latest:      result.latestVersion,
+		Status:      status,
+		Previous:    result.previousLatest,
+		Behind:      result.commitsBehind,
+		Draft:       result.draft,
+		Cached:      result.cached,
+		MovedTo:     result.movedTo,
+		Source:      result.source,
+		Description: result.description,
+		SkipReason:  result.skipReason,
+	}
+	if result.err != nil {
+		line.Error = result.err.Error()
+		line.ErrorKind = errorKind(result.err)
+	}
+	for _, asset := range result.assets {
+		line.Assets = append(line.Assets, asset.BrowserDownloadURL)
+	}
+	data, _ := json.Marshal(line) // Plain strings, ints, bools and a string slice, which always marshal.
+	fmt.Fprintf(outputWriter(), "%s\n", data)
+}
+
+// statusSections are the sections of 'check -group-output', in order.
+var statusSections = []struct {
+	title  string
+	status CheckStatus
+}{
+	{"Updates Available", statusUpdateAvailable},
+	{"Up to date", statusUpToDate},
+	{"Version discrepancies", statusDiscrepancy},
+	{"Errors", statusError},
+	{"Skipped", statusSkipped},
+}
+
+// printGroupedResults prints results in a section per status, each under a header with its
+// count. Empty sections are left out, and within a section results keep their order.
+func printGroupedResults(results []checkResult, opts checkOptions) {
+	for _, section := range statusSections {
+		var members []checkResult
+		for _, result := range results {
+			if result.status == section.status && !(opts.changedOnly && !result.statusChanged) {
+				members = append(members, result)
+			}
+		}
+		if len(members) == 0 || (section.status == statusError && opts.quietErrors) {
+			continue
+		}
+		PrintHeader("%s (%d)", section.title, len(members))
+		for _, result := range members {
+			printCheckResult(result, opts)
+		}
+	}
+}
+
+// printFailures lists every app whose check failed, with its error, so failures aren't lost
+// among the other results. Nothing is printed if all checks succeeded.
+func printFailures(results []checkResult) {
+	var failed []checkResult
+	for _, result := range results {
+		if result.status == statusError {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	PrintMessage("\n%sFailures:%s", ansi(colorRedFg), ansi(colorReset))
+	for _, result := range failed {
+		PrintMessage("  - %s: %v", Colorize(result.appName, colorMagentaFg), result.err)
+	}
+}
+
+// printMovedWarning tells the user that a tracked repository redirects to a new name.
+func printMovedWarning(result checkResult, opts checkOptions) {
+	if opts.format == formatGitHubActions {
+		fmt.Fprintf(outputWriter(), "::warning ::%s\n", escapeActionsMessage(fmt.Sprintf("%s now redirects to %s", result.appName, result.movedTo)))
+		return
+	}
+	PrintInfo("%s now redirects to %s. Use 'check -rename-moved' to rename it in your configuration.",
+		Colorize(result.appName, colorMagentaFg), Colorize(result.movedTo, colorYellowFg))
+}
+
+// escapeActionsMessage escapes the characters that would otherwise terminate a workflow command.
+func escapeActionsMessage(message string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(message)
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrintCheckResultGitHubActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   checkResult
+		expected string
+	}{
+		{
+			name:     "UpdateAvailable",
+			result:   checkResult{appName: "owner/repo", currentVersion: "1.0.0", latestVersion: "1.1.0", status: statusUpdateAvailable},
+			expected: "::warning ::owner/repo has an update: 1.0.0 -> 1.1.0\n",
+		},
+		{
+			name:     "UpToDate",
+			result:   checkResult{appName: "owner/repo", currentVersion: "1.0.0", latestVersion: "1.0.0", status: statusUpToDate},
+			expected: "::notice ::owner/repo is up to date: 1.0.0\n",
+		},
+		{
+			name:     "Discrepancy",
+			result:   checkResult{appName: "owner/repo", currentVersion: "1.2.0", latestVersion: "1.1.0", status: statusDiscrepancy},
+			expected: "::warning ::owner/repo has a version discrepancy: current 1.2.0, latest 1.1.0\n",
+		},
+		{
+			name:     "Skipped",
+			result:   checkResult{appName: "myapp", currentVersion: "1.0.0", status: statusSkipped, skipReason: "Not in 'owner/repo' format."},
+			expected: "::notice ::Skipping myapp: Not in 'owner/repo' format.\n",
+		},
+		{
+			name:     "Error",
+			result:   checkResult{appName: "owner/repo", currentVersion: "1.0.0", status: statusError, err: errors.New("boom")},
+			expected: "::error ::Failed to check owner/repo: boom\n",
+		},
+		{
+			name:     "ErrorIsEscaped",
+			result:   checkResult{appName: "owner/repo", currentVersion: "1.0.0", status: statusError, err: errors.New("100% broken\nsecond line")},
+			expected: "::error ::Failed to check owner/repo: 100%25 broken%0Asecond line\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := captureOutput(func() { printCheckResultGitHubActions(tt.result) })
+			if output != tt.expected {
+				t.Errorf("Unexpected annotation.\nGot     : %q\nExpected: %q", output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHandleCheckCommandGitHubActionsFormat(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.1.0", nil
+	}
+
+	if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	output := captureOutput(func() { handleCheckCmd("", checkOptions{format: formatGitHubActions}) })
+	if output != "::warning ::owner/repo has an update: 1.0.0 -> 1.1.0\n" {
+		t.Errorf("Expected only the annotation line, got: %q", output)
+	}
+	if strings.Contains(output, "\033[") {
+		t.Errorf("Annotations must not contain color codes, got: %q", output)
+	}
+}
+
+func TestPrintCheckResultPorcelain(t *testing.T) {
+	results := []checkResult{
+		{appName: "owner/a", currentVersion: "1.0.0", latestVersion: "1.1.0", status: statusUpdateAvailable},
+		{appName: "owner/b", currentVersion: "2.0.0", latestVersion: "2.0.0", status: statusUpToDate},
+		{appName: "owner/c", currentVersion: "3.1.0", latestVersion: "3.0.0", status: statusDiscrepancy},
+		{appName: "myapp", currentVersion: "1.0.0", status: statusSkipped, skipReason: "Not in 'owner/repo' format."},
+		{appName: "owner/d", currentVersion: "4.0.0", status: statusError, err: errors.New("boom")},
+		{appName: "owner/e", currentVersion: "5.0.0", latestVersion: "5.1.0", status: statusUpdateAvailable, movedTo: "neworg/e"},
+	}
+	output := captureOutput(func() {
+		for _, result := range results {
+			printCheckResult(result, checkOptions{format: formatPorcelain})
+		}
+	})
+	expected := "U owner/a 1.0.0 1.1.0\n" +
+		"= owner/b 2.0.0 2.0.0\n" +
+		"D owner/c 3.1.0 3.0.0\n" +
+		"S myapp 1.0.0 -\n" +
+		"E owner/d 4.0.0 -\n" +
+		"U owner/e 5.0.0 5.1.0\n"
+	if output != expected {
+		t.Errorf("Unexpected porcelain output.\nGot     : %q\nExpected: %q", output, expected)
+	}
+}
+
+func TestHandleCheckCommandPorcelainFormat(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.1.0", nil
+	}
+
+	if err := saveConfig(Config{"owner/a": "1.0.0", "owner/b": "1.1.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	output := captureOutput(func() { handleCheckCmd("", checkOptions{format: formatPorcelain}) })
+	if output != "U owner/a 1.0.0 1.1.0\n= owner/b 1.1.0 1.1.0\n" {
+		t.Errorf("Expected only porcelain lines, got: %q", output)
+	}
+}
+
+func TestHandleCheckCommandNDJSONFormat(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		if appIdentifier == "owner/broken" {
+			return "", errors.New("boom")
+		}
+		return "1.1.0", nil
+	}
+
+	config := Config{"owner/a": "1.0.0", "owner/b": "1.1.0", "owner/broken": "1.0.0", "notarepo": "1.0.0"}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	output := captureOutput(func() { handleCheckCmd("", checkOptions{format: formatNDJSON}) })
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != len(config) {
+		t.Fatalf("Expected one line per app, got %d:\n%s", len(lines), output)
+	}
+	got := make(map[string]ndjsonResult)
+	for _, line := range lines {
+		var result ndjsonResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("Line is not valid JSON: %q (%v)", line, err)
+		}
+		got[result.App] = result
+	}
+	for appName := range config {
+		if _, ok := got[appName]; !ok {
+			t.Errorf("Expected a line for %s", appName)
+		}
+	}
+	if r := got["owner/a"]; r.Status != "update_available" || r.Current != "1.0.0" || r.Latest != "1.1.0" {
+		t.Errorf("Unexpected result for owner/a: %+v", r)
+	}
+	if r := got["owner/b"]; r.Status != "up_to_date" {
+		t.Errorf("Unexpected result for owner/b: %+v", r)
+	}
+	if r := got["owner/broken"]; r.Status != "error" || r.Error != "boom" || r.Latest != "" {
+		t.Errorf("Unexpected result for owner/broken: %+v", r)
+	}
+	if r := got["notarepo"]; r.Status != "skipped" || r.SkipReason == "" {
+		t.Errorf("Unexpected result for notarepo: %+v", r)
+	}
+}
+
+func TestCheckAllFailuresSection(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		switch appIdentifier {
+		case "owner/broken":
+			return "", errors.New("GitHub API error for owner/broken (status 404)")
+		case "owner/flaky":
+			return "", errors.New("network error fetching release info for owner/flaky")
+		}
+		return "1.1.0", nil
+	}
+
+	t.Run("MixedResults", func(t *testing.T) {
+		config := Config{"owner/a": "1.0.0", "owner/broken": "1.0.0", "owner/current": "1.1.0", "owner/flaky": "1.0.0"}
+		if err := saveConfig(config); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		var exitCode int
+		output := stripAnsiCodes(captureOutput(func() {
+			captureStderr(t, func() { exitCode = handleCheckCmd("", checkOptions{}) })
+		}))
+
+		expected := "\nFailures:\n" +
+			"  - owner/broken: GitHub API error for owner/broken (status 404)\n" +
+			"  - owner/flaky: network error fetching release info for owner/flaky\n"
+		if !strings.HasSuffix(output, expected) {
+			t.Errorf("Expected the output to end with the failures section.\nGot:\n%s\nExpected suffix:\n%s", output, expected)
+		}
+		if exitCode != exitCheckFailed {
+			t.Errorf("Expected exit code %d, got %d", exitCheckFailed, exitCode)
+		}
+	})
+
+	t.Run("QuietErrors", func(t *testing.T) {
+		config := Config{"owner/a": "1.0.0", "owner/broken": "1.0.0", "owner/flaky": "1.0.0"}
+		if err := saveConfig(config); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		var exitCode int
+		var output string
+		errOutput := captureStderr(t, func() {
+			output = stripAnsiCodes(captureOutput(func() { exitCode = handleCheckCmd("", checkOptions{quietErrors: true}) }))
+		})
+		if errOutput != "" {
+			t.Errorf("Expected nothing on stderr, got:\n%s", errOutput)
+		}
+		if strings.Contains(output, "owner/broken") || strings.Contains(output, "Failures:") {
+			t.Errorf("Expected failed apps to be left out of the output, got:\n%s", output)
+		}
+		if !strings.HasSuffix(output, "2 applications could not be checked.\n") {
+			t.Errorf("Expected the error count at the end, got:\n%s", output)
+		}
+		if exitCode != exitCheckFailed {
+			t.Errorf("Expected exit code %d, got %d", exitCheckFailed, exitCode)
+		}
+	})
+
+	t.Run("NoFailures", func(t *testing.T) {
+		if err := saveConfig(Config{"owner/a": "1.0.0", "owner/current": "1.1.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		var exitCode int
+		output := stripAnsiCodes(captureOutput(func() { exitCode = handleCheckCmd("", checkOptions{}) }))
+		if strings.Contains(output, "Failures:") {
+			t.Errorf("Expected no failures section. Got:\n%s", output)
+		}
+		if exitCode != exitOK {
+			t.Errorf("Expected exit code %d, got %d", exitOK, exitCode)
+		}
+	})
+}
+
+func TestHandleCheckOnlyUpdates(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		if appIdentifier == "owner/broken" {
+			return "", errors.New("boom")
+		}
+		return "1.1.0", nil
+	}
+
+	config := Config{"owner/b": "1.0.0", "owner/a": "1.0.0", "owner/current": "1.1.0", "owner/ahead": "2.0.0", "owner/broken": "1.0.0", "myapp": "1.0.0"}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	var output string
+	captureStderr(t, func() {
+		output = captureOutput(func() { handleCheckCmd("", checkOptions{onlyUpdates: true}) })
+	})
+	if output != "owner/a\nowner/b\n" {
+		t.Errorf("Expected only the names of apps with updates, got: %q", output)
+	}
+}
+
+func TestCheckStatusStringAndColor(t *testing.T) {
+	tests := []struct {
+		status CheckStatus
+		name   string
+		color  string
+	}{
+		{statusUpToDate, "Up to date", colorGreenFg},
+		{statusUpdateAvailable, "Update Available!", colorRedFg},
+		{statusDiscrepancy, "Version discrepancy", colorYellowFg},
+		{statusSkipped, "Skipped", colorMagentaFg},
+		{statusError, "Error", colorRedFg},
+	}
+	for _, tt := range tests {
+		if got := tt.status.String(); got != tt.name {
+			t.Errorf("CheckStatus(%d).String() = %q, expected %q", int(tt.status), got, tt.name)
+		}
+		if got := tt.status.color(); got != tt.color {
+			t.Errorf("%s: expected color %q, got %q", tt.name, tt.color, got)
+		}
+	}
+	if got := CheckStatus(99).String(); got != "CheckStatus(99)" {
+		t.Errorf("Expected unknown statuses to show their number, got %q", got)
+	}
+	var unset checkResult
+	if unset.status != statusError {
+		t.Errorf("Expected a result without a status to count as an error, got %s", unset.status)
+	}
+}
+
+func TestNDJSONErrorObject(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		switch appIdentifier {
+		case "owner/limited":
+			return "", tagError(ErrRateLimited, "GitHub API error for owner/limited (status 403): API rate limit exceeded")
+		case "owner/offline":
+			return "", &NetworkError{Identifier: appIdentifier, URL: "https://api.github.com", Err: errors.New("connection refused")}
+		}
+		return "", errors.New("boom")
+	}
+	if err := saveConfig(Config{"owner/limited": "1.0.0", "owner/offline": "1.0.0", "owner/other": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	expected := map[string]string{
+		"owner/limited": `{"app":"owner/limited","current":"1.0.0","status":"error","error":"GitHub API error for owner/limited (status 403): API rate limit exceeded","error_kind":"rate_limited"}`,
+		"owner/offline": `{"app":"owner/offline","current":"1.0.0","status":"error","error":"network error fetching release info for owner/offline from https://api.github.com: connection refused","error_kind":"network"}`,
+		"owner/other":   `{"app":"owner/other","current":"1.0.0","status":"error","error":"boom"}`,
+		"owner/missing": `{"app":"owner/missing","current":"","status":"error","error":"not found in your managed list"}`,
+	}
+	for _, appName := range []string{"owner/limited", "owner/offline", "owner/other", "owner/missing"} {
+		t.Run(appName, func(t *testing.T) {
+			var output string
+			errOutput := captureStderr(t, func() {
+				output = captureOutput(func() { handleCheckCmd(appName, checkOptions{format: formatNDJSON}) })
+			})
+			if strings.TrimSuffix(output, "\n") != expected[appName] {
+				t.Errorf("Expected the error object\n%s\ngot\n%s", expected[appName], output)
+			}
+			if appName == "owner/missing" && !strings.Contains(errOutput, "not found in your managed list") {
+				t.Errorf("Expected the error on stderr too, got %q", errOutput)
+			}
+		})
+	}
+}
+
+func TestRunChecksReturnsResults(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		if appIdentifier == "owner/broken" {
+			return "", errors.New("connection reset")
+		}
+		return "2.0.0", nil
+	}
+	config := Config{"owner/old": "1.0.0", "owner/current": "2.0.0", "owner/broken": "1.0.0"}
+
+	var emitted []string
+	var results []checkResult
+	output := captureOutput(func() {
+		results = runChecks(config, "", checkOptions{}, func(result checkResult) { emitted = append(emitted, result.appName) })
+	})
+	if output != "" {
+		t.Errorf("Expected runChecks to print nothing, got:\n%s", output)
+	}
+
+	expected := []struct {
+		appName, current, latest string
+		status                   CheckStatus
+		failed                   bool
+	}{
+		{"owner/broken", "1.0.0", "", statusError, true},
+		{"owner/current", "2.0.0", "2.0.0", statusUpToDate, false},
+		{"owner/old", "1.0.0", "2.0.0", statusUpdateAvailable, false},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d: %+v", len(expected), len(results), results)
+	}
+	for i, want := range expected {
+		got := results[i]
+		if got.appName != want.appName || got.currentVersion != want.current || got.latestVersion != want.latest ||
+			got.status != want.status || (got.err != nil) != want.failed {
+			t.Errorf("Expected result %d to be %+v, got %+v", i, want, got)
+		}
+	}
+	if !reflect.DeepEqual(emitted, []string{"owner/broken", "owner/current", "owner/old"}) {
+		t.Errorf("Expected each result to be emitted in order, got %v", emitted)
+	}
+
+	t.Run("SpecificApp", func(t *testing.T) {
+		results := runChecks(config, "owner/old", checkOptions{}, func(checkResult) {})
+		if len(results) != 1 || results[0].appName != "owner/old" || results[0].status != statusUpdateAvailable {
+			t.Errorf("Expected a single update for owner/old, got %+v", results)
+		}
+	})
+
+	t.Run("Sorted", func(t *testing.T) {
+		var emitted []string
+		results := runChecks(config, "", checkOptions{sortBy: sortByStatus}, func(result checkResult) { emitted = append(emitted, result.appName) })
+		for i, result := range results {
+			if emitted[i] != result.appName {
+				t.Errorf("Expected results to be emitted in their sorted order, got %v", emitted)
+				break
+			}
+		}
+	})
+}
+
+func TestHandleCheckGroupOutput(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		if appIdentifier == "owner/broken" {
+			return "", errors.New("connection reset")
+		}
+		return "2.0.0", nil
+	}
+	if err := saveConfig(Config{
+		"owner/a-old": "1.0.0", "owner/b-current": "2.0.0", "owner/broken": "1.0.0",
+		"owner/c-old": "1.5.0", "notarepo": "1.0.0",
+	}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	var output string
+	errOutput := captureStderr(t, func() {
+		output = stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{groupOutput: true}) }))
+	})
+
+	headers := []string{"== Updates Available (2) ==", "== Up to date (1) ==", "== Errors (1) ==", "== Skipped (1) =="}
+	position := -1
+	for _, header := range headers {
+		index := strings.Index(output, header)
+		if index < position {
+			t.Errorf("Expected %q after the previous section, got:\n%s", header, output)
+		}
+		position = index
+	}
+	if strings.Contains(output, "Version discrepancies") {
+		t.Errorf("Expected empty sections to be left out, got:\n%s", output)
+	}
+	// sectionOf returns the header of the section a line containing text is under.
+	sectionOf := func(text string) string {
+		index := strings.Index(output, text)
+		if index < 0 {
+			return ""
+		}
+		section := ""
+		for _, header := range headers {
+			if at := strings.Index(output, header); at >= 0 && at < index {
+				section = header
+			}
+		}
+		return section
+	}
+	for text, header := range map[string]string{
+		"Checking owner/a-old...":     headers[0],
+		"Checking owner/c-old...":     headers[0],
+		"Checking owner/b-current...": headers[1],
+		"Checking owner/broken...":    headers[2],
+		"Skipping notarepo":           headers[3],
+	} {
+		if section := sectionOf(text); section != header {
+			t.Errorf("Expected %q under %q, got %q in:\n%s", text, header, section, output)
+		}
+	}
+	if strings.Contains(output, "Failures:") {
+		t.Errorf("Expected no separate failures list, got:\n%s", output)
+	}
+	if !strings.Contains(errOutput, "Failed to check owner/broken: connection reset") {
+		t.Errorf("Expected the error to be shown in its section, got: %q", errOutput)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// handleRollbackCmd restores the config file from one of its backups. choice picks the backup by
+// its number in the list, newest first from 1, or by file name; an empty choice picks the newest.
+// With listOnly, the backups are only listed. The current config is backed up before it is
+// replaced, so a rollback can itself be rolled back. It returns the process exit code.
+func handleRollbackCmd(choice string, listOnly, skipConfirm bool) int {
+	backups, err := listConfigBackups()
+	if err != nil {
+		PrintError("Could not list backups: %v", err)
+		return 1
+	}
+	if len(backups) == 0 {
+		PrintInfo("No backups of '%s' found.", configFile)
+		return 1
+	}
+
+	PrintHeader("Backups of %s", configFile)
+	for i, backup := range backups {
+		PrintMessage("  %d. %s", i+1, Colorize(filepath.Base(backup), colorCyanFg))
+	}
+	if listOnly {
+		return 0
+	}
+
+	backup := findBackup(backups, choice)
+	if backup == "" {
+		PrintError("No backup '%s'; give its number or file name from the list above.", choice)
+		return 1
+	}
+	data, err := os.ReadFile(backup)
+	if err != nil {
+		PrintError("Could not read backup: %v", err)
+		return 1
+	}
+	restored, err := readAppsFile(backup)
+	if err != nil {
+		PrintError("Backup '%s' is not a valid config file: %v", filepath.Base(backup), err)
+		return 1
+	}
+	if !skipConfirm && !Confirm("Restore %s with %s, replacing the current config?", filepath.Base(backup), pluralize(len(restored), "application", "applications")) {
+		PrintInfo("Rollback aborted. No changes made.")
+		return 1
+	}
+
+	previous, err := backupConfig()
+	if err != nil {
+		PrintError("%v", err)
+		return 1
+	}
+	if previous != "" {
+		PrintInfo("Backed up the current configuration to %s.", previous)
+	}
+	if err := writeFileAtomic(configFile, data, configFilePerm); err != nil {
+		PrintError("Could not restore backup: %v", err)
+		return 1
+	}
+	PrintSuccess("Restored %s.", filepath.Base(backup))
+	return 0
+}
+
+// findBackup returns the backup in backups that choice names, by number or file name, or the
+// newest for an empty choice. It returns "" if there is no such backup.
+func findBackup(backups []string, choice string) string {
+	if choice == "" {
+		return backups[0]
+	}
+	if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= len(backups) {
+		return backups[n-1]
+	}
+	for _, backup := range backups {
+		if filepath.Base(backup) == filepath.Base(choice) {
+			return backup
+		}
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleRollbackCmd(t *testing.T) {
+	// setup seeds two backups, an older and a newer one, and a current config.
+	setup := func(t *testing.T) (string, string) {
+		useTestConfigFile(t)
+		older := configBackupFile(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+		newer := configBackupFile(time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC))
+		writeTestFile(t, older, "\"owner/a\" = \"1.0.0\"\n")
+		writeTestFile(t, newer, "\"owner/a\" = \"1.1.0\"\n\"owner/b\" = \"2.0.0\"\n")
+		if err := saveConfig(Config{"owner/a": "9.9.9"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		return older, newer
+	}
+
+	t.Run("List", func(t *testing.T) {
+		older, newer := setup(t)
+		output := stripAnsiCodes(captureOutput(func() { handleRollbackCmd("", true, false) }))
+		expected := "  1. " + filepath.Base(newer) + "\n  2. " + filepath.Base(older) + "\n"
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected the backups newest first:\n%s\ngot:\n%s", expected, output)
+		}
+		if cfg, _ := loadConfig(); cfg["owner/a"] != "9.9.9" {
+			t.Errorf("Expected -list to change nothing, got %v", cfg)
+		}
+	})
+
+	t.Run("RestoreNewest", func(t *testing.T) {
+		setup(t)
+		var exitCode int
+		captureOutput(func() { exitCode = handleRollbackCmd("", false, true) })
+		if exitCode != 0 {
+			t.Fatalf("Expected exit code 0, got %d", exitCode)
+		}
+		if cfg, _ := loadConfig(); !reflect.DeepEqual(cfg, Config{"owner/a": "1.1.0", "owner/b": "2.0.0"}) {
+			t.Errorf("Expected the newest backup to be restored, got %v", cfg)
+		}
+
+		// The config as it was before the rollback is now the newest backup.
+		backups, _ := listConfigBackups()
+		if len(backups) != 3 {
+			t.Fatalf("Expected the pre-rollback config to be backed up too, got %v", backups)
+		}
+		data, err := os.ReadFile(backups[0])
+		if err != nil || !strings.Contains(string(data), "9.9.9") {
+			t.Errorf("Expected the newest backup to hold the pre-rollback config, got %q (%v)", data, err)
+		}
+	})
+
+	t.Run("RestoreChosen", func(t *testing.T) {
+		_, _ = setup(t)
+		useScriptedInput(t, "y\n", true)
+		output := stripAnsiCodes(captureOutput(func() { handleRollbackCmd("2", false, false) }))
+		if !strings.Contains(output, "with 1 application, replacing the current config? [y/N]") {
+			t.Errorf("Expected a confirmation prompt, got:\n%s", output)
+		}
+		if cfg, _ := loadConfig(); !reflect.DeepEqual(cfg, Config{"owner/a": "1.0.0"}) {
+			t.Errorf("Expected the second backup to be restored, got %v", cfg)
+		}
+	})
+
+	t.Run("Aborted", func(t *testing.T) {
+		setup(t)
+		useScriptedInput(t, "n\n", true)
+		var exitCode int
+		captureOutput(func() { exitCode = handleRollbackCmd("", false, false) })
+		if cfg, _ := loadConfig(); exitCode != 1 || cfg["owner/a"] != "9.9.9" {
+			t.Errorf("Expected an aborted rollback to change nothing, got exit %d and %v", exitCode, cfg)
+		}
+		if backups, _ := listConfigBackups(); len(backups) != 2 {
+			t.Errorf("Expected no new backup when aborted, got %v", backups)
+		}
+	})
+
+	t.Run("UnknownChoice", func(t *testing.T) {
+		setup(t)
+		errOutput := captureStderr(t, func() { captureOutput(func() { handleRollbackCmd("7", false, true) }) })
+		if !strings.Contains(errOutput, "No backup '7'") {
+			t.Errorf("Expected an error for a backup that doesn't exist, got %q", errOutput)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// hostConcurrency caps the number of hosts 'check -parallel-sources' sends requests to at once.
+const hostConcurrency = 8
+
+// scheduleByHost runs check for each of appNames and returns the results in the same order.
+// Apps are grouped by hostOf: each host's apps are checked one after another, in order, while
+// different hosts are checked concurrently. This keeps a run fast without hammering any one API.
+func scheduleByHost(appNames []string, hostOf func(appName string) string, check func(appName string) checkResult) []checkResult {
+	var hosts []string
+	queues := make(map[string][]int)
+	for i, appName := range appNames {
+		host := hostOf(appName)
+		if _, ok := queues[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		queues[host] = append(queues[host], i)
+	}
+
+	results := make([]checkResult, len(appNames))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, hostConcurrency)
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(queue []int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			for _, i := range queue {
+				results[i] = check(appNames[i])
+			}
+		}(queues[host])
+	}
+	wg.Wait()
+	return results
+}
+
+// sourceHost returns the host that checking appName sends its requests to: that of the first
+// entry of its sources list, if it has one, or else of the app name itself. Apps whose source is
+// unknown share the host "".
+func sourceHost(appName string) string {
+	ref := appName
+	if chain := appOptions[appName].Sources; len(chain) > 0 {
+		ref = chain[0]
+	}
+	source, identifier, err := resolveSource(ref)
+	if err != nil {
+		return ""
+	}
+	var baseURL string
+	switch s := source.(type) {
+	case githubSource:
+		baseURL = defaultGitHubAPIBase
+		if githubAPIBase != "" {
+			baseURL = githubAPIBase
+		}
+	case gitlabSource:
+		baseURL = s.apiBase()
+	case giteaSource:
+		host, _, _ := strings.Cut(identifier, "/")
+		return host
+	case homebrewSource:
+		baseURL = s.apiBase()
+	default:
+		return ""
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduleByHost(t *testing.T) {
+	// Each check is an interval on a logical clock that ticks at every start and end. The first
+	// check on each host waits until the first checks on all hosts have started, which they
+	// only can if hosts run concurrently.
+	hosts := map[string]string{"a1": "a.example", "a2": "a.example", "a3": "a.example", "b1": "b.example", "b2": "b.example", "c1": "c.example"}
+	var clock atomic.Int64
+	var mu sync.Mutex
+	intervals := make(map[string][2]int64)
+	allStarted := make(chan struct{})
+	var firstStarts sync.WaitGroup
+	firstStarts.Add(3)
+	go func() {
+		firstStarts.Wait()
+		close(allStarted)
+	}()
+
+	check := func(appName string) checkResult {
+		start := clock.Add(1)
+		if strings.HasSuffix(appName, "1") {
+			firstStarts.Done()
+			select {
+			case <-allStarted:
+			case <-time.After(2 * time.Second):
+				t.Errorf("Expected %s to run alongside the first checks of the other hosts", appName)
+			}
+		}
+		end := clock.Add(1)
+		mu.Lock()
+		intervals[appName] = [2]int64{start, end}
+		mu.Unlock()
+		return checkResult{appName: appName, latestVersion: hosts[appName]}
+	}
+
+	appNames := []string{"a1", "b1", "a2", "c1", "a3", "b2"}
+	results := scheduleByHost(appNames, func(appName string) string { return hosts[appName] }, check)
+
+	for i, appName := range appNames {
+		if results[i].appName != appName {
+			t.Errorf("Expected result %d to be for %s, got %s", i, appName, results[i].appName)
+		}
+	}
+	// Same-host checks never overlap, and run in the given order.
+	for _, queue := range [][]string{{"a1", "a2", "a3"}, {"b1", "b2"}} {
+		for i := 1; i < len(queue); i++ {
+			previous, next := intervals[queue[i-1]], intervals[queue[i]]
+			if next[0] < previous[1] {
+				t.Errorf("Expected %s to start after %s ended, got %v and %v", queue[i], queue[i-1], previous, next)
+			}
+		}
+	}
+	// Different hosts do overlap.
+	a1, b1 := intervals["a1"], intervals["b1"]
+	if a1[0] > b1[1] || b1[0] > a1[1] {
+		t.Errorf("Expected checks on different hosts to overlap, got %v and %v", a1, b1)
+	}
+}
+
+func TestSourceHost(t *testing.T) {
+	defer func() { appOptions = make(map[string]AppOptions) }()
+	appOptions["owner/chained"] = AppOptions{Sources: []string{"brew:tool", "owner/tool"}}
+	tests := []struct {
+		appName, expected string
+	}{
+		{"owner/repo", "api.github.com"},
+		{"gitlab:group/project", "gitlab.com"},
+		{"gitea:git.example.com:3000/owner/repo", "git.example.com:3000"},
+		{"cask:firefox", "formulae.brew.sh"},
+		{"owner/chained", "formulae.brew.sh"},
+		{"nosuch:thing", ""},
+	}
+	for _, tt := range tests {
+		if got := sourceHost(tt.appName); got != tt.expected {
+			t.Errorf("sourceHost(%q): expected %q, got %q", tt.appName, tt.expected, got)
+		}
+	}
+}
+
+func TestCheckParallelSources(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "2.0.0", nil
+	}
+	if err := saveConfig(Config{"owner/b": "2.0.0", "owner/a": "1.0.0", "owner/c": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	output := captureOutput(func() { handleCheckCmd("", checkOptions{parallelSources: true, format: formatPorcelain}) })
+	if output != "U owner/a 1.0.0 2.0.0\n= owner/b 2.0.0 2.0.0\nU owner/c 1.0.0 2.0.0\n" {
+		t.Errorf("Expected the results in name order, got:\n%s", output)
+	}
+	if state := loadState(); state["owner/a"].LastLatest != "2.0.0" {
+		t.Errorf("Expected the checks to be recorded, got %+v", state)
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+)
+
+// semVersion is a parsed version. Build metadata is dropped as it doesn't affect precedence.
+type semVersion struct {
+	components []string // Dot-separated numbers, digits only, e.g. ["1", "2", "3"] or ["2024", "03", "1", "2"].
+	prerelease []string // Dot-separated pre-release identifiers, e.g. ["rc", "2"].
+}
+
+// parseSemver parses versions like "1.2.3", "v1.2.3-rc.1" or "1.2", as well as calendar versions
+// with any number of components such as "2024.03.1.2" or "20240301". It reports false if the
+// string is not a recognizable version.
+func parseSemver(version string) (semVersion, bool) {
+	var v semVersion
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexByte(version, '+'); i >= 0 {
+		version = version[:i]
+	}
+	core := version
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		core = version[:i]
+		if version[i+1:] == "" {
+			return v, false
+		}
+		v.prerelease = strings.Split(version[i+1:], ".")
+	}
+
+	v.components = strings.Split(core, ".")
+	for _, part := range v.components {
+		if part == "" || strings.TrimLeft(part, "0123456789") != "" {
+			return semVersion{}, false
+		}
+	}
+	return v, true
+}
+
+// component returns the i-th number of v, counting from 0; missing components are "0".
+func (v semVersion) component(i int) string {
+	if i < len(v.components) {
+		return v.components[i]
+	}
+	return "0"
+}
+
+// Modes for deciding whether a latest version is an update, set globally with
+// 'check -compare-mode' or per app with compare_mode.
+const (
+	compareSemver = "semver" // Compare by semver precedence; an older latest is a discrepancy.
+	compareExact  = "exact"  // Any difference at all is an update.
+)
+
+// compareMode is the mode used for apps without their own compare_mode.
+var compareMode = compareSemver
+
+// compareModeFor returns the compare mode for appName.
+func compareModeFor(appName string) string {
+	if mode := appOptions[appName].CompareMode; mode != "" {
+		return mode
+	}
+	return compareMode
+}
+
+// latestSentinel recorded as an app's version means it always follows the latest release: the
+// real latest version is still looked up and shown, but never reported as an update.
+const latestSentinel = "latest"
+
+// versionStatus decides the status of currentVersion against latestVersion under mode.
+// In semver mode versions are compared after parsing, so formatting is not a difference:
+// a recorded "1.2" is up to date with a latest "1.2.0", and "1.2.0" with a "v1.2.0" kept by
+// -keep-v-prefix. Versions that don't parse are compared as strings, ignoring a "v" prefix.
+// A currentVersion of latestSentinel is always up to date.
+func versionStatus(currentVersion, latestVersion, mode string) CheckStatus {
+	if latestVersion == currentVersion || currentVersion == latestSentinel {
+		return statusUpToDate
+	}
+	if mode == compareExact {
+		return statusUpdateAvailable
+	}
+	if strings.TrimPrefix(latestVersion, "v") == strings.TrimPrefix(currentVersion, "v") {
+		return statusUpToDate
+	}
+	switch compareVersions(latestVersion, currentVersion) {
+	case 0:
+		return statusUpToDate
+	case 1:
+		return statusUpdateAvailable
+	}
+	return statusDiscrepancy
+}
+
+// Sizes of a version bump accepted by 'check -min-bump', smallest first.
+const (
+	bumpPatch = "patch"
+	bumpMinor = "minor"
+	bumpMajor = "major"
+)
+
+// bumpRanks orders the bump sizes.
+var bumpRanks = map[string]int{bumpPatch: 1, bumpMinor: 2, bumpMajor: 3}
+
+// bumpSize returns the size of the bump from currentVersion to latestVersion: the most significant
+// component that changed. A change in pre-release only counts as a patch. If either side isn't
+// a version, the size can't be told and the bump counts as major, so it's never hidden.
+func bumpSize(currentVersion, latestVersion string) string {
+	current, okCurrent := parseSemver(currentVersion)
+	latest, okLatest := parseSemver(latestVersion)
+	switch {
+	case !okCurrent || !okLatest || compareDigits(current.component(0), latest.component(0)) != 0:
+		return bumpMajor
+	case compareDigits(current.component(1), latest.component(1)) != 0:
+		return bumpMinor
+	}
+	return bumpPatch
+}
+
+// belowMinBump reports whether the bump from currentVersion to latestVersion is smaller than minBump.
+// An empty minBump means every bump counts.
+func belowMinBump(currentVersion, latestVersion, minBump string) bool {
+	if minBump == "" {
+		return false
+	}
+	return bumpRanks[bumpSize(currentVersion, latestVersion)] < bumpRanks[minBump]
+}
+
+// isDowngrade reports whether going from version from to version to goes back to an older
+// version. Nothing is older than following every release with latestSentinel.
+func isDowngrade(from, to string) bool {
+	return from != latestSentinel && to != latestSentinel && compareVersions(to, from) < 0
+}
+
+// compareVersions compares two version strings, returning -1, 0 or 1.
+// Versions are compared by semver precedence, so a pre-release sorts before its release
+// (1.2.0-rc1 < 1.2.0-rc2 < 1.2.0), with any number of numeric components compared one by one,
+// so calendar versions such as "2024.3.1.10" > "2024.3.1.2" work too. Strings that aren't versions
+// sort before all versions and among themselves as strings, so the order is always consistent.
+func compareVersions(a, b string) int {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	switch {
+	case okA && okB:
+		return compareSemVersions(va, vb)
+	case okA:
+		return 1
+	case okB:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareDigits compares two non-empty strings of digits as numbers of any size: ignoring
+// leading zeros, the longer one is larger, and ones of the same length compare as strings.
+func compareDigits(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if c := cmp.Compare(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// compareSemVersions compares two parsed versions by semver precedence, returning -1, 0 or 1.
+// Components are compared numerically, so "2024.10" > "2024.9", and missing trailing ones
+// count as 0, so "1.2" == "1.2.0".
+func compareSemVersions(va, vb semVersion) int {
+	for i := 0; i < len(va.components) || i < len(vb.components); i++ {
+		if c := compareDigits(va.component(i), vb.component(i)); c != 0 {
+			return c
+		}
+	}
+	return comparePrerelease(va.prerelease, vb.prerelease)
+}
+
+// comparePrerelease applies semver's pre-release precedence rules.
+func comparePrerelease(a, b []string) int {
+	// A version without a pre-release has higher precedence than one with.
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePrereleaseIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+// comparePrereleaseIdentifier compares a single pre-release identifier. Numeric identifiers (digits
+// only) compare numerically and sort before alphanumeric ones. Alphanumeric identifiers compare by
+// their leading letters and then by any trailing number, so "rc" < "rc2" < "rc10" as most projects
+// intend.
+func comparePrereleaseIdentifier(a, b string) int {
+	na, numericA := parseNumericIdentifier(a)
+	nb, numericB := parseNumericIdentifier(b)
+	switch {
+	case numericA && numericB:
+		return cmp.Compare(na, nb)
+	case numericA:
+		return -1
+	case numericB:
+		return 1
+	}
+
+	prefixA, numA, hasNumA := splitTrailingNumber(a)
+	prefixB, numB, hasNumB := splitTrailingNumber(b)
+	if c := strings.Compare(prefixA, prefixB); c != 0 {
+		return c
+	}
+	switch {
+	case hasNumA && hasNumB:
+		if c := cmp.Compare(numA, numB); c != 0 {
+			return c
+		}
+	case hasNumA:
+		return 1
+	case hasNumB:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// parseNumericIdentifier parses a pre-release identifier made of digits only. Signs aren't
+// accepted, so "-1" is alphanumeric as semver intends, and numbers too large for an int are
+// treated as alphanumeric rather than misordered.
+func parseNumericIdentifier(s string) (int, bool) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// splitTrailingNumber splits "rc12" into ("rc", 12, true).
+func splitTrailingNumber(s string) (string, int, bool) {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	if i == len(s) {
+		return s, 0, false
+	}
+	n, err := strconv.Atoi(s[i:])
+	if err != nil {
+		return s, 0, false
+	}
+	return s[:i], n, true
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.1", "1.0.0", 1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "10.0.0", -1},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.0-rc1", "1.2.0-rc2", -1},
+		{"1.2.0-rc2", "1.2.0", -1},
+		{"1.2.0", "1.2.0-rc2", 1},
+		{"1.2.0-rc2", "1.2.0-rc10", -1},
+		{"1.2.0-alpha", "1.2.0-beta", -1},
+		{"1.2.0-alpha.1", "1.2.0-alpha", 1},
+		{"1.2.0-1", "1.2.0-alpha", -1},
+		{"1.2.0-rc.2", "1.2.0-rc.11", -1},
+		{"1.2.0-rc2", "1.1.9", 1},
+		{"1.2.3+build5", "1.2.3", 0},
+		{"1.0.0--9223372036854775807", "1.0.0-9223372036854775807", 1},
+		{"1.0.0-99999999999999999999", "1.0.0-1", 1},
+		{"nightly", "stable", -1},
+		{"nightly", "1.0.0", -1},
+		{"1.2.0-rc2", "1.2.0-rc", 1},
+		{"1.2.0-rc2", "1.2.0-rc1a", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+		if got := compareVersions(tt.b, tt.a); got != -tt.expected {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", tt.b, tt.a, got, -tt.expected)
+		}
+	}
+}
+
+func TestCompareCalendarVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"2024.10", "2024.9", 1},
+		{"2024.03.1", "2024.3.1", 0},
+		{"2024.03.10", "2024.03.9", 1},
+		{"2024.3.1.2", "2024.3.1.10", -1},
+		{"2024.3.1.2", "2024.3.1", 1},
+		{"2024.3.0.0", "2024.3", 0},
+		{"2025.1.1.1", "2024.12.31.9", 1},
+		{"20240301", "20240229", 1},
+		{"20240301", "2024.03.01", 1},
+		{"v2024.10.1.1", "2024.10.1.0", 1},
+		{"202403011200000000000001", "202403011200000000000000", 1},
+		{"000123", "122", 1},
+		{"2024.3.1.2", "2024.3.1.2-rc1", 1},
+		{"1.10.0.0", "1.2.0-rc", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+		if got := compareVersions(tt.b, tt.a); got != -tt.expected {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", tt.b, tt.a, got, -tt.expected)
+		}
+	}
+
+	if status := versionStatus("2024.3.1.2", "2024.3.1.10", compareSemver); status != statusUpdateAvailable {
+		t.Errorf("Expected an update from 2024.3.1.2 to 2024.3.1.10, got %v", status)
+	}
+	config := Config{"owner/a": "2024.3.1.2", "owner/b": "2024.3.1.10", "owner/c": "2024.12"}
+	if names := sortAppNames(config, sortedAppNames(config), sortByVersion); strings.Join(names, " ") != "owner/c owner/b owner/a" {
+		t.Errorf("Expected calendar versions sorted newest first, got %v", names)
+	}
+}
+
+func TestCheckAppPrereleaseToRelease(t *testing.T) {
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.2.0", nil
+	}
+
+	if result := checkApp("owner/repo", "1.2.0-rc2"); result.status != statusUpdateAvailable {
+		t.Errorf("Expected 1.2.0-rc2 -> 1.2.0 to be %q, got %q", statusUpdateAvailable, result.status)
+	}
+	if result := checkApp("owner/repo", "1.10.0"); result.status != statusDiscrepancy {
+		t.Errorf("Expected 1.10.0 -> 1.2.0 to be %q, got %q", statusDiscrepancy, result.status)
+	}
+}
+
+func TestCompareModes(t *testing.T) {
+	originalGetLatestVersion := getLatestVersion
+	defer func() {
+		getLatestVersion = originalGetLatestVersion
+		compareMode = compareSemver
+		appOptions = make(map[string]AppOptions)
+	}()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.2.0", nil
+	}
+
+	tests := []struct {
+		current string
+		semver  CheckStatus
+		exact   CheckStatus
+	}{
+		{current: "1.2.0", semver: statusUpToDate, exact: statusUpToDate},
+		{current: "1.1.0", semver: statusUpdateAvailable, exact: statusUpdateAvailable},
+		{current: "1.10.0", semver: statusDiscrepancy, exact: statusUpdateAvailable},
+	}
+	for _, tt := range tests {
+		for mode, expected := range map[string]CheckStatus{compareSemver: tt.semver, compareExact: tt.exact} {
+			compareMode = mode
+			if result := checkApp("owner/repo", tt.current); result.status != expected {
+				t.Errorf("mode %s: %s -> 1.2.0 expected %q, got %q", mode, tt.current, expected, result.status)
+			}
+		}
+	}
+
+	t.Run("PerAppOverride", func(t *testing.T) {
+		compareMode = compareSemver
+		appOptions = map[string]AppOptions{"owner/exact": {CompareMode: compareExact}}
+		if result := checkApp("owner/exact", "1.10.0"); result.status != statusUpdateAvailable {
+			t.Errorf("Expected the per-app exact mode to report an update, got %q", result.status)
+		}
+		if result := checkApp("owner/repo", "1.10.0"); result.status != statusDiscrepancy {
+			t.Errorf("Expected other apps to keep the global semver mode, got %q", result.status)
+		}
+	})
+}
+
+func TestVersionStatusWithVPrefix(t *testing.T) {
+	tests := []struct {
+		current, latest, mode string
+		expected              CheckStatus
+	}{
+		{"1.2.3", "v1.2.3", compareSemver, statusUpToDate},
+		{"v1.2.3", "v1.2.3", compareSemver, statusUpToDate},
+		{"1.2.2", "v1.2.3", compareSemver, statusUpdateAvailable},
+		{"v1.2.4", "v1.2.3", compareSemver, statusDiscrepancy},
+		{"1.2.3", "v1.2.3", compareExact, statusUpdateAvailable},
+	}
+	for _, tt := range tests {
+		if got := versionStatus(tt.current, tt.latest, tt.mode); got != tt.expected {
+			t.Errorf("versionStatus(%q, %q, %s) = %q, expected %q", tt.current, tt.latest, tt.mode, got, tt.expected)
+		}
+	}
+}
+
+func TestVersionStatusNormalizesFormatting(t *testing.T) {
+	tests := []struct {
+		current, latest, mode string
+		expected              CheckStatus
+	}{
+		{"1.2", "1.2.0", compareSemver, statusUpToDate},
+		{"1.2.0", "1.2", compareSemver, statusUpToDate},
+		{"1.0", "1.0.0", compareSemver, statusUpToDate},
+		{"1", "v1.0.0", compareSemver, statusUpToDate},
+		{"1.2", "1.2.1", compareSemver, statusUpdateAvailable},
+		{"1.3", "1.2.0", compareSemver, statusDiscrepancy},
+		{"1.2", "1.2.0-rc1", compareSemver, statusDiscrepancy},
+		{"nightly", "nightly-2", compareSemver, statusUpdateAvailable},
+		{"1.2", "1.2.0", compareExact, statusUpdateAvailable},
+	}
+	for _, tt := range tests {
+		if got := versionStatus(tt.current, tt.latest, tt.mode); got != tt.expected {
+			t.Errorf("versionStatus(%q, %q, %s) = %q, expected %q", tt.current, tt.latest, tt.mode, got, tt.expected)
+		}
+	}
+}
+
+func TestMinBump(t *testing.T) {
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	latest := ""
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return latest, nil
+	}
+
+	tests := []struct {
+		current, latest string
+		bump            string
+		reported        map[string]bool // By -min-bump threshold.
+	}{
+		{"1.2.0", "1.2.1", bumpPatch, map[string]bool{"": true, bumpPatch: true, bumpMinor: false, bumpMajor: false}},
+		{"1.2.0", "1.3.0", bumpMinor, map[string]bool{"": true, bumpPatch: true, bumpMinor: true, bumpMajor: false}},
+		{"1.2.0", "2.0.0", bumpMajor, map[string]bool{"": true, bumpPatch: true, bumpMinor: true, bumpMajor: true}},
+		{"1.2.0-rc1", "1.2.0", bumpPatch, map[string]bool{"": true, bumpPatch: true, bumpMinor: false, bumpMajor: false}},
+		{"nightly-1", "nightly-2", bumpMajor, map[string]bool{"": true, bumpPatch: true, bumpMinor: true, bumpMajor: true}},
+	}
+	for _, tt := range tests {
+		if got := bumpSize(tt.current, tt.latest); got != tt.bump {
+			t.Errorf("bumpSize(%q, %q) = %q, expected %q", tt.current, tt.latest, got, tt.bump)
+		}
+		latest = tt.latest
+		for minBump, reported := range tt.reported {
+			expected := statusUpToDate
+			if reported {
+				expected = statusUpdateAvailable
+			}
+			result := checkAppVersion("owner/repo", tt.current, checkOptions{minBump: minBump})
+			if result.status != expected {
+				t.Errorf("-min-bump %q: %s -> %s expected %s, got %s", minBump, tt.current, tt.latest, expected, result.status)
+			}
+		}
+	}
+}
+
+// versionSeeds are realistic release tags for the fuzz targets.
+var versionSeeds = []string{
+	"1.2.3", "v1.2.3", "1.2", "1", "v0.0.1", "2.0.0-rc1", "2.0.0-rc.10", "1.0.0-alpha.beta",
+	"1.0.0+build.5", "v1.0.0-beta+exp.sha.5114f85", "nightly", "nightly-20240601", "stable-1.2.3",
+	"release-2024.01.15", "24.0.7+incompatible", "1.2.3.4", "", "v", "1.0.0-", "1.0.0--1",
+	"1.0.0-9223372036854775807", "1.0.0-rc99999999999999999999",
+}
+
+func FuzzCompareVersions(f *testing.F) {
+	for _, a := range versionSeeds {
+		for i, b := range versionSeeds[:5] {
+			f.Add(a, b, versionSeeds[len(versionSeeds)-1-i])
+		}
+	}
+	f.Add("1.10.0.0", "1.9.0", "1.2.0-rc")
+	f.Fuzz(func(t *testing.T, a, b, c string) {
+		if same := compareVersions(a, a); same != 0 {
+			t.Errorf("compareVersions(%q, %q) = %d, expected 0", a, a, same)
+		}
+		ab, ba := compareVersions(a, b), compareVersions(b, a)
+		if ab < -1 || ab > 1 {
+			t.Errorf("compareVersions(%q, %q) = %d, expected -1, 0 or 1", a, b, ab)
+		}
+		if ab != -ba {
+			t.Errorf("compareVersions(%q, %q) = %d but compareVersions(%q, %q) = %d", a, b, ab, b, a, ba)
+		}
+		if status := versionStatus(a, b, compareSemver); (ab == 0) != (status == statusUpToDate) && strings.TrimPrefix(a, "v") != strings.TrimPrefix(b, "v") {
+			t.Errorf("versionStatus(%q, %q) = %s, inconsistent with compareVersions = %d", a, b, status, ab)
+		}
+		// Sorting relies on the order being transitive: a <= b <= c must mean a <= c.
+		if bc, ac := compareVersions(b, c), compareVersions(a, c); ab <= 0 && bc <= 0 && ac > 0 || ab >= 0 && bc >= 0 && ac < 0 {
+			t.Errorf("compareVersions isn't transitive for %q, %q, %q: %d, %d, but %d", a, b, c, ab, bc, ac)
+		}
+	})
+}
+
+func FuzzTagVersion(f *testing.F) {
+	for _, tag := range versionSeeds {
+		f.Add(tag)
+	}
+	f.Fuzz(func(t *testing.T, tag string) {
+		version := tagVersion(tag)
+		parsedTag, okTag := parseSemver(tag)
+		parsedVersion, okVersion := parseSemver(version)
+		// Stripping the prefix may turn a non-version into one ("vv1"), but never the reverse.
+		if okTag && (!okVersion || compareSemVersions(parsedTag, parsedVersion) != 0) {
+			t.Errorf("parseSemver(%q) and parseSemver(tagVersion(%q) = %q) disagree", tag, tag, version)
+		}
+		for _, component := range parsedVersion.components {
+			if strings.TrimLeft(component, "0123456789") != "" {
+				t.Errorf("parseSemver(%q) returned a non-numeric component: %+v", version, parsedVersion)
+			}
+		}
+		bumpSize(tag, version)
+	})
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// settingsTableKey is the reserved table holding defaults for command-line flags. Like
+// metaTableKey, it is never treated as an application.
+//
+//	[settings]
+//	color = "never"
+//	throttle = "500ms"
+//	compare_mode = "exact"
+//	default_source = "gitlab"
+//
+//	[settings.check]
+//	sort = "status"
+//
+// A table named after a command holds settings for that command only, taking precedence over
+// the ones above.
+const settingsTableKey = "settings"
+
+// configSettings is the [settings] table of the last loaded config, as decoded. saveConfig
+// writes it back unchanged.
+var configSettings map[string]interface{}
+
+// unsettableFlags are flags that decide which config file is read, so they can't come from it,
+// and those that must only ever be turned on deliberately on the command line: insecure-skip-verify,
+// and -y and -force, which skip the confirmations and checks guarding destructive commands such as
+// reset and 'import -replace-all'.
+var unsettableFlags = map[string]bool{"config": true, "env-prefix": true, "insecure-skip-verify": true, "y": true, "force": true}
+
+// commandScopedFlags are flags whose accepted values differ between the commands that have them,
+// like -sort, so they can only be set in a command's own table.
+var commandScopedFlags = map[string]bool{"sort": true}
+
+// readSettingsFile returns the [settings] table of the config file at path, or nil if the file
+// doesn't exist or has no such table.
+func readSettingsFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := codecFor(path).unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	settings, _ := raw[settingsTableKey].(map[string]interface{})
+	return settings, nil
+}
+
+// settingFlagName returns the name of the flag a setting provides the default for, e.g.
+// "max-release-pages" for max_release_pages.
+func settingFlagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// applySettings makes each setting the value of the flags of the same name in flagSets, except
+// for flags named in explicit, which were given on the command line. A table named after one of
+// flagSets applies to it alone, after the others. Flags parsed afterwards override the settings
+// as usual. It returns a warning for each setting that is unknown or invalid. default_source is
+// not a flag and is left to defaultSourceName.
+func applySettings(settings map[string]interface{}, explicit map[string]bool, flagSets ...*flag.FlagSet) []string {
+	var warnings []string
+	var commandTables []string
+	for _, key := range sortedSettingKeys(settings) {
+		if _, isTable := settings[key].(map[string]interface{}); isTable {
+			commandTables = append(commandTables, key)
+			continue
+		}
+		if key == "default_source" {
+			continue
+		}
+		if commandScopedFlags[settingFlagName(key)] {
+			warnings = append(warnings, fmt.Sprintf("setting '%s' differs between commands and is ignored; set it in a table like [%s.check]", key, settingsTableKey))
+			continue
+		}
+		warnings = append(warnings, applySetting(key, settings[key], explicit, flagSets)...)
+	}
+
+	for _, command := range commandTables {
+		var commandFlags []*flag.FlagSet
+		for _, fs := range flagSets {
+			if fs.Name() == command {
+				commandFlags = append(commandFlags, fs)
+			}
+		}
+		if len(commandFlags) == 0 {
+			warnings = append(warnings, fmt.Sprintf("unknown command '%s' in [%s.%s]", command, settingsTableKey, command))
+			continue
+		}
+		table := settings[command].(map[string]interface{})
+		for _, key := range sortedSettingKeys(table) {
+			for _, warning := range applySetting(key, table[key], explicit, commandFlags) {
+				warnings = append(warnings, fmt.Sprintf("[%s.%s]: %s", settingsTableKey, command, warning))
+			}
+		}
+	}
+	return warnings
+}
+
+// sortedSettingKeys returns the keys of settings in order, so warnings come out the same each run.
+func sortedSettingKeys(settings map[string]interface{}) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// applySetting sets the flags of flagSets that key provides the default for to value, returning
+// a warning if there are none or the value is invalid.
+func applySetting(key string, value interface{}, explicit map[string]bool, flagSets []*flag.FlagSet) []string {
+	name := settingFlagName(key)
+	if unsettableFlags[name] {
+		return []string{fmt.Sprintf("setting '%s' can't be set in the config file and is ignored", key)}
+	}
+	text := fmt.Sprint(value)
+	known := false
+	for _, fs := range flagSets {
+		if fs.Lookup(name) == nil {
+			continue
+		}
+		known = true
+		if explicit[name] {
+			continue
+		}
+		if err := fs.Set(name, text); err != nil {
+			return []string{fmt.Sprintf("invalid value %q for setting '%s': %v", text, key, err)}
+		}
+	}
+	if !known {
+		return []string{fmt.Sprintf("unknown setting '%s'", key)}
+	}
+	return nil
+}
+
+// settingsDefaultSource returns the default_source from the [settings] table, if any.
+func settingsDefaultSource() string {
+	source, _ := configSettings["default_source"].(string)
+	return source
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplySettings(t *testing.T) {
+	newCheckFlags := func() (*flag.FlagSet, *string, *time.Duration) {
+		fs := flag.NewFlagSet("check", flag.ContinueOnError)
+		compare := fs.String("compare-mode", compareSemver, "")
+		throttle := fs.Duration("throttle", 0, "")
+		return fs, compare, throttle
+	}
+	settings := map[string]interface{}{"compare_mode": "exact", "throttle": "500ms"}
+
+	t.Run("UsedWhenFlagAbsent", func(t *testing.T) {
+		fs, compare, throttle := newCheckFlags()
+		if warnings := applySettings(settings, nil, fs); len(warnings) != 0 {
+			t.Fatalf("Expected no warnings, got %v", warnings)
+		}
+		if err := fs.Parse(nil); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if *compare != compareExact || *throttle != 500*time.Millisecond {
+			t.Errorf("Expected the settings to apply, got compare-mode %q, throttle %v", *compare, *throttle)
+		}
+	})
+
+	t.Run("OverriddenByFlag", func(t *testing.T) {
+		fs, compare, throttle := newCheckFlags()
+		applySettings(settings, nil, fs)
+		if err := fs.Parse([]string{"-compare-mode", compareSemver}); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if *compare != compareSemver || *throttle != 500*time.Millisecond {
+			t.Errorf("Expected the flag to win over its setting only, got compare-mode %q, throttle %v", *compare, *throttle)
+		}
+	})
+
+	t.Run("ExplicitAlreadyParsedFlagWins", func(t *testing.T) {
+		fs := flag.NewFlagSet("global", flag.ContinueOnError)
+		color := fs.String("color", colorAuto, "")
+		fs.Parse([]string{"-color", colorAlways})
+		applySettings(map[string]interface{}{"color": colorNever}, map[string]bool{"color": true}, fs)
+		if *color != colorAlways {
+			t.Errorf("Expected the command-line color to win, got %q", *color)
+		}
+	})
+
+	t.Run("Warnings", func(t *testing.T) {
+		fs, _, _ := newCheckFlags()
+		warnings := applySettings(map[string]interface{}{"throttle": "soon", "no_such": true, "config": "other.toml", "default_source": "gitlab"}, nil, fs)
+		joined := strings.Join(warnings, "\n")
+		for _, expected := range []string{"setting 'config' can't be set", `invalid value "soon" for setting 'throttle'`, "unknown setting 'no_such'"} {
+			if !strings.Contains(joined, expected) {
+				t.Errorf("Expected a warning containing %q, got:\n%s", expected, joined)
+			}
+		}
+		if len(warnings) != 3 {
+			t.Errorf("Expected 3 warnings, got %v", warnings)
+		}
+	})
+}
+
+func TestConfirmationFlagsAreUnsettable(t *testing.T) {
+	fs := flag.NewFlagSet("reset", flag.ContinueOnError)
+	yes := fs.Bool("y", false, "")
+	force := fs.Bool("force", false, "")
+	warnings := applySettings(map[string]interface{}{"y": true, "force": true}, nil, fs)
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if *yes || *force {
+		t.Errorf("Expected -y and -force not to be set from the config, got y=%v force=%v", *yes, *force)
+	}
+	joined := strings.Join(warnings, "\n")
+	if !strings.Contains(joined, "setting 'y' can't be set") || !strings.Contains(joined, "setting 'force' can't be set") {
+		t.Errorf("Expected warnings for both settings, got:\n%s", joined)
+	}
+}
+
+func TestSettingsTable(t *testing.T) {
+	path := useTestConfigFile(t)
+	t.Setenv("SHOULDUPDATE_DEFAULT_SOURCE", "")
+	defer func() { configSettings = nil }()
+	content := "\"owner/repo\" = \"1.0.0\"\n\n[settings]\ncolor = \"never\"\nmax_release_pages = 5\ndefault_source = \"gitlab\"\n"
+	writeTestFile(t, path, content)
+
+	settings, err := readSettingsFile(path)
+	if err != nil || settings["color"] != "never" || settings["max_release_pages"] != int64(5) {
+		t.Fatalf("Expected the settings table to be read, got %v (%v)", settings, err)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if _, ok := config[settingsTableKey]; ok || len(config) != 1 {
+		t.Errorf("Expected [settings] not to be treated as an application, got %v", config)
+	}
+	if got := defaultSourceName(); got != "gitlab" {
+		t.Errorf("Expected default_source from [settings], got %q", got)
+	}
+
+	config["owner/other"] = "2.0.0"
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, line := range []string{"[settings]", `color = "never"`, "max_release_pages = 5", `default_source = "gitlab"`} {
+		if !strings.Contains(string(data), line) {
+			t.Errorf("Expected the saved config to keep %q, got:\n%s", line, data)
+		}
+	}
+}
+
+func TestSortSettingIsScopedToCommand(t *testing.T) {
+	path := useTestConfigFile(t)
+	defer func() { configSettings = nil }()
+	writeTestFile(t, path, "\"owner/a\" = \"1.0.0\"\n\"owner/b\" = \"2.0.0\"\n\n[settings]\nsort = \"status\"\n\n[settings.check]\nsort = \"status\"\n\n[settings.list]\nsort = \"version\"\n\n[settings.nope]\nsort = \"name\"\n")
+
+	settings, err := readSettingsFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read settings: %v", err)
+	}
+	listCmd := flag.NewFlagSet("list", flag.ContinueOnError)
+	listSort := listCmd.String("sort", sortByName, "")
+	checkCmd := flag.NewFlagSet("check", flag.ContinueOnError)
+	checkSort := checkCmd.String("sort", sortByName, "")
+	releasesCmd := flag.NewFlagSet("releases", flag.ContinueOnError)
+	releasesSort := releasesCmd.String("sort", "", "")
+
+	warnings := applySettings(settings, nil, listCmd, checkCmd, releasesCmd)
+	joined := strings.Join(warnings, "\n")
+	for _, expected := range []string{"setting 'sort' differs between commands", "unknown command 'nope' in [settings.nope]"} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("Expected a warning containing %q, got:\n%s", expected, joined)
+		}
+	}
+	if len(warnings) != 2 {
+		t.Errorf("Expected 2 warnings, got %v", warnings)
+	}
+	if *checkSort != sortByStatus || *listSort != sortByVersion || *releasesSort != "" {
+		t.Fatalf("Expected each command's own sort, got check %q, list %q, releases %q", *checkSort, *listSort, *releasesSort)
+	}
+
+	// 'list' runs with its own valid sort key rather than check's.
+	if err := listCmd.Parse(nil); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{sortBy: *listSort}) }))
+	if order := appOrder(output, []string{"owner/a", "owner/b"}); order != "owner/b,owner/a" {
+		t.Errorf("Expected list sorted by version, got %s. Output:\n%s", order, output)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Keys accepted by the -sort flag of 'list' and 'check'.
+const (
+	sortByName    = "name"
+	sortByVersion = "version"
+	sortByStatus  = "status" // 'check' only.
+)
+
+// Keys accepted by the -sort flag of 'releases'.
+const (
+	sortByDate   = "date"   // Published date, newest first.
+	sortBySemver = "semver" // Tag parsed as a version, newest first.
+)
+
+// statusSortOrder ranks statuses for sortByStatus: the ones needing attention come first.
+var statusSortOrder = map[CheckStatus]int{
+	statusUpdateAvailable: 0,
+	statusDiscrepancy:     1,
+	statusError:           2,
+	statusSkipped:         3,
+	statusUpToDate:        4,
+}
+
+// sortAppNames sorts appNames, which must already be in name order, by key. Names of
+// apps with equal sort values keep their name order.
+func sortAppNames(config Config, appNames []string, key string) []string {
+	if key != sortByVersion {
+		return appNames
+	}
+
+	// Parse each version once up front rather than twice per comparison. Ties are broken by
+	// name instead of with a stable sort, which is much slower on large configs.
+	type entry struct {
+		name    string
+		version string
+		parsed  semVersion
+		ok      bool
+	}
+	entries := make([]*entry, len(appNames))
+	for i, appName := range appNames {
+		version := config[appName]
+		parsed, ok := parseSemver(version)
+		entries[i] = &entry{appName, version, parsed, ok}
+	}
+	// Compared as compareVersions does.
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		c := 0
+		switch {
+		case a.ok && b.ok:
+			c = compareSemVersions(a.parsed, b.parsed)
+		case a.ok:
+			c = 1
+		case b.ok:
+			c = -1
+		default:
+			c = strings.Compare(a.version, b.version)
+		}
+		if c != 0 {
+			return c > 0
+		}
+		return a.name < b.name
+	})
+	for i, e := range entries {
+		appNames[i] = e.name
+	}
+	return appNames
+}
+
+// sortResults sorts check results, which must already be in name order, by key.
+// sortByVersion orders by the current version, newest first.
+func sortResults(results []checkResult, key string) {
+	switch key {
+	case sortByVersion:
+		sort.SliceStable(results, func(i, j int) bool {
+			return compareVersions(results[i].currentVersion, results[j].currentVersion) > 0
+		})
+	case sortByStatus:
+		sort.SliceStable(results, func(i, j int) bool {
+			return statusSortOrder[results[i].status] < statusSortOrder[results[j].status]
+		})
+	}
+}
+
+// sortReleases sorts releases, newest first, by key; an empty key keeps GitHub's order, which is
+// by creation. Drafts have no published date and sort before all published releases by date.
+func sortReleases(releases []GitHubReleaseInfo, key string) {
+	switch key {
+	case sortByDate:
+		sort.SliceStable(releases, func(i, j int) bool {
+			a, b := releases[i].PublishedAt, releases[j].PublishedAt
+			if a.IsZero() || b.IsZero() {
+				return a.IsZero() && !b.IsZero()
+			}
+			return a.After(b)
+		})
+	case sortBySemver:
+		sort.SliceStable(releases, func(i, j int) bool {
+			return compareVersions(tagVersion(releases[i].TagName), tagVersion(releases[j].TagName)) > 0
+		})
+	}
+}
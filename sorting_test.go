@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestListSort(t *testing.T) {
+	useTestConfigFile(t)
+	config := Config{"owner/a": "1.10.0", "owner/b": "2.0.0", "owner/c": "1.2.0", "owner/d": "2.0.0"}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	tests := map[string][]string{
+		sortByName:    {"owner/a", "owner/b", "owner/c", "owner/d"},
+		sortByVersion: {"owner/b", "owner/d", "owner/a", "owner/c"},
+	}
+	for key, expected := range tests {
+		t.Run(key, func(t *testing.T) {
+			output := stripAnsiCodes(captureOutput(func() { handleListCmd(listOptions{sortBy: key}) }))
+			if order := appOrder(output, expected); order != strings.Join(expected, ",") {
+				t.Errorf("Expected order %v, got %s. Output:\n%s", expected, order, output)
+			}
+		})
+	}
+}
+
+func TestCheckSort(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	latest := map[string]string{"owner/current": "1.0.0", "owner/newer": "3.0.0", "owner/ahead": "1.0.0", "owner/old": "0.9.0"}
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return latest[appIdentifier], nil
+	}
+	config := Config{"owner/current": "1.0.0", "owner/newer": "2.0.0", "owner/ahead": "1.5.0", "owner/old": "0.5.0", "myapp": "9.9.9"}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	tests := map[string][]string{
+		sortByName:    {"myapp", "owner/ahead", "owner/current", "owner/newer", "owner/old"},
+		sortByVersion: {"myapp", "owner/newer", "owner/ahead", "owner/current", "owner/old"},
+		sortByStatus:  {"owner/newer", "owner/old", "owner/ahead", "myapp", "owner/current"},
+	}
+	for key, expected := range tests {
+		t.Run(key, func(t *testing.T) {
+			output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{sortBy: key}) }))
+			if order := appOrder(output, expected); order != strings.Join(expected, ",") {
+				t.Errorf("Expected order %v, got %s. Output:\n%s", expected, order, output)
+			}
+		})
+	}
+}
+
+// appOrder returns the names in appNames in the order their lines appear in output,
+// as printed by 'list' ("Application: x,") and 'check' ("Checking x..." or "Skipping x:").
+func appOrder(output string, appNames []string) string {
+	var ordered []string
+	for _, line := range strings.Split(output, "\n") {
+		for _, appName := range appNames {
+			for _, suffix := range []string{",", "...", ":"} {
+				if strings.Contains(line, " "+appName+suffix) {
+					ordered = append(ordered, appName)
+					break
+				}
+			}
+		}
+	}
+	return strings.Join(ordered, ",")
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// VersionSource looks up the latest released version of an application on some hosting service.
+// App names select a source with a "<source>:" prefix, e.g. "gitlab:group/project".
+type VersionSource interface {
+	// DisplayName is the human-readable name of the service, e.g. "GitHub".
+	DisplayName() string
+	// IdentifierFormat describes the identifiers the source accepts, e.g. "owner/repo".
+	IdentifierFormat() string
+	// ValidIdentifier reports whether identifier (the app name without its prefix) is usable.
+	ValidIdentifier(identifier string) bool
+	// LatestVersion returns the latest released version for identifier. Cancelling ctx, or its
+	// deadline passing, aborts the lookup.
+	LatestVersion(ctx context.Context, identifier string) (string, error)
+}
+
+// sources maps source prefixes (without the colon) to their implementations.
+var sources = map[string]VersionSource{
+	"github":  githubSource{},
+	"gitlab":  gitlabSource{},
+	"gitea":   giteaSource{},
+	"forgejo": giteaSource{forgejo: true},
+	"brew":    homebrewSource{},
+	"cask":    homebrewSource{cask: true},
+}
+
+// configDefaultSource is the default_source read from the config's [_meta] table, if any.
+var configDefaultSource string
+
+// defaultSourceName returns the source used for app names without a prefix. The
+// SHOULDUPDATE_DEFAULT_SOURCE environment variable wins over the config's default_source, from
+// [settings] or else [_meta]; if none names a known source, GitHub is used.
+func defaultSourceName() string {
+	for _, name := range []string{envConfig().DefaultSource, settingsDefaultSource(), configDefaultSource} {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := sources[name]; ok {
+			return name
+		}
+	}
+	return "github"
+}
+
+// splitSourcePrefix splits "gitlab:group/project" into ("gitlab", "group/project").
+// Names without a prefix return the default source's name.
+func splitSourcePrefix(appName string) (string, string) {
+	if prefix, identifier, ok := strings.Cut(appName, ":"); ok && !strings.Contains(prefix, "/") {
+		return strings.ToLower(prefix), identifier
+	}
+	return defaultSourceName(), appName
+}
+
+// resolveSource returns the source for appName along with the identifier to pass to it.
+func resolveSource(appName string) (VersionSource, string, error) {
+	name, identifier := splitSourcePrefix(appName)
+	source, ok := sources[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown source '%s' in '%s'", name, appName)
+	}
+	return source, identifier, nil
+}
+
+// lookupLatestVersion resolves appName's source and fetches its latest version.
+func lookupLatestVersion(ctx context.Context, appName string) (string, error) {
+	source, identifier, err := resolveSource(appName)
+	if err != nil {
+		return "", err
+	}
+	if !source.ValidIdentifier(identifier) {
+		return "", tagError(ErrInvalidIdentifier, "not in '%s' format", source.IdentifierFormat())
+	}
+	return source.LatestVersion(ctx, identifier)
+}
+
+// githubSource looks up releases on GitHub through getLatestVersion, so tests can mock it.
+type githubSource struct{}
+
+func (githubSource) DisplayName() string      { return "GitHub" }
+func (githubSource) IdentifierFormat() string { return "owner/repo" }
+
+func (githubSource) ValidIdentifier(identifier string) bool {
+	return strings.Contains(identifier, "/")
+}
+
+func (githubSource) LatestVersion(ctx context.Context, identifier string) (string, error) {
+	return getLatestVersion(ctx, identifier, githubAPIBase)
+}
+
+// gitlabSource looks up releases on GitLab. baseURL defaults to https://gitlab.com.
+// A SHOULDUPDATE_GITLAB_TOKEN or GITLAB_TOKEN environment variable is sent for private projects.
+type gitlabSource struct {
+	baseURL string
+}
+
+func (gitlabSource) DisplayName() string      { return "GitLab" }
+func (gitlabSource) IdentifierFormat() string { return "group/project" }
+
+func (gitlabSource) ValidIdentifier(identifier string) bool {
+	return strings.Contains(identifier, "/")
+}
+
+// apiBase returns the base URL of the GitLab instance.
+func (s gitlabSource) apiBase() string {
+	if s.baseURL != "" {
+		return s.baseURL
+	}
+	return "https://gitlab.com"
+}
+
+func (s gitlabSource) LatestVersion(ctx context.Context, identifier string) (string, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s/releases/permalink/latest", s.apiBase(), url.PathEscape(identifier))
+
+	headers := map[string]string{}
+	if token := envConfig().GitLabToken; token != "" {
+		headers["PRIVATE-TOKEN"] = token
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := fetchJSON(ctx, apiURL, headers, &release); err != nil {
+		return "", fmt.Errorf("GitLab lookup for %s failed: %w", identifier, err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("no version tag (tag_name) found in the latest release for %s (URL: %s)", identifier, apiURL)
+	}
+	return tagVersion(release.TagName), nil
+}
+
+// giteaSource looks up releases on a self-hosted Gitea instance ("gitea:host/owner/repo"), or
+// with forgejo set, a Forgejo one ("forgejo:host/owner/repo"), which has the same API. The host
+// may include a port. For private instances, a SHOULDUPDATE_GITEA_TOKEN or GITEA_TOKEN environment
+// variable is sent, or else the token resolveToken found for the command (see giteaToken).
+// scheme defaults to https.
+type giteaSource struct {
+	forgejo bool
+	scheme  string
+}
+
+func (s giteaSource) DisplayName() string {
+	if s.forgejo {
+		return "Forgejo"
+	}
+	return "Gitea"
+}
+
+func (giteaSource) IdentifierFormat() string { return "host/owner/repo" }
+
+func (giteaSource) ValidIdentifier(identifier string) bool {
+	parts := strings.Split(identifier, "/")
+	return len(parts) == 3 && parts[0] != "" && parts[1] != "" && parts[2] != ""
+}
+
+// giteaToken returns the token sent to Gitea and Forgejo instances: a Gitea-specific one from the
+// environment if set, or else githubToken, which commands fill in with resolveToken from their
+// -token flag, the environment or the token file.
+func giteaToken() string {
+	if token := envConfig().GiteaToken; token != "" {
+		return token
+	}
+	return githubToken
+}
+
+func (s giteaSource) LatestVersion(ctx context.Context, identifier string) (string, error) {
+	scheme := "https"
+	if s.scheme != "" {
+		scheme = s.scheme
+	}
+	host, repo, _ := strings.Cut(identifier, "/")
+	apiURL := fmt.Sprintf("%s://%s/api/v1/repos/%s/releases/latest", scheme, host, repo)
+
+	headers := map[string]string{}
+	if token := giteaToken(); token != "" {
+		headers["Authorization"] = "token " + token
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := fetchJSON(ctx, apiURL, headers, &release); err != nil {
+		return "", fmt.Errorf("%s lookup for %s failed: %w", s.DisplayName(), identifier, err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("no version tag (tag_name) found in the latest release for %s (URL: %s)", identifier, apiURL)
+	}
+	return tagVersion(release.TagName), nil
+}
+
+// homebrewSource looks up versions in the Homebrew formulae API, either of a formula
+// ("brew:ripgrep") or, with cask set, of a cask ("cask:firefox"). baseURL defaults to
+// https://formulae.brew.sh.
+type homebrewSource struct {
+	cask    bool
+	baseURL string
+}
+
+func (s homebrewSource) DisplayName() string {
+	if s.cask {
+		return "Homebrew Cask"
+	}
+	return "Homebrew"
+}
+
+func (s homebrewSource) IdentifierFormat() string {
+	if s.cask {
+		return "cask"
+	}
+	return "formula"
+}
+
+// ValidIdentifier accepts core formula and cask names; tapped names like "user/tap/name"
+// aren't available from the API.
+func (homebrewSource) ValidIdentifier(identifier string) bool {
+	return identifier != "" && !strings.Contains(identifier, "/")
+}
+
+// apiBase returns the base URL of the formulae API.
+func (s homebrewSource) apiBase() string {
+	if s.baseURL != "" {
+		return s.baseURL
+	}
+	return "https://formulae.brew.sh"
+}
+
+func (s homebrewSource) LatestVersion(ctx context.Context, identifier string) (string, error) {
+	kind := "formula"
+	if s.cask {
+		kind = "cask"
+	}
+	apiURL := fmt.Sprintf("%s/api/%s/%s.json", s.apiBase(), kind, url.PathEscape(identifier))
+
+	var info struct {
+		Version  string `json:"version"` // Casks only.
+		Versions struct {
+			Stable string `json:"stable"`
+		} `json:"versions"` // Formulae only.
+	}
+	if err := fetchJSON(ctx, apiURL, nil, &info); err != nil {
+		return "", fmt.Errorf("%s lookup for %s failed: %w", s.DisplayName(), identifier, err)
+	}
+
+	version := info.Versions.Stable
+	if s.cask {
+		// Cask versions may carry a build suffix after a comma, e.g. "4.2.1,20240101".
+		version, _, _ = strings.Cut(info.Version, ",")
+	}
+	if version == "" {
+		return "", fmt.Errorf("no version found for %s %s (URL: %s)", kind, identifier, apiURL)
+	}
+	return version, nil
+}
+
+// fetchJSON GETs apiURL and decodes a JSON response into v. It's the shared request path
+// for the simpler, non-GitHub sources. Cancelling ctx, or its deadline passing, aborts the request.
+func fetchJSON(ctx context.Context, apiURL string, headers map[string]string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("internal error creating request for %s: %w", apiURL, err)
+	}
+	req.Header.Set("User-Agent", envConfig().UserAgent)
+	req.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := doRequest(req)
+	if err != nil {
+		return fmt.Errorf("network error fetching %s: %w", apiURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if sentinel := statusSentinel(resp); sentinel != nil {
+			hint := ""
+			if sentinel == ErrRateLimited {
+				hint = retryAfterHint(resp)
+			}
+			return tagError(sentinel, "API error (status %d) for %s%s", resp.StatusCode, apiURL, hint)
+		}
+		return fmt.Errorf("API error (status %d) for %s", resp.StatusCode, apiURL)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding JSON response from %s: %w", apiURL, err)
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeSource is a VersionSource returning canned versions and recording lookups.
+type fakeSource struct {
+	name      string
+	versions  map[string]string
+	requested []string
+}
+
+func (s *fakeSource) DisplayName() string      { return s.name }
+func (s *fakeSource) IdentifierFormat() string { return "owner/repo" }
+
+func (s *fakeSource) ValidIdentifier(identifier string) bool {
+	return strings.Contains(identifier, "/")
+}
+
+func (s *fakeSource) LatestVersion(ctx context.Context, identifier string) (string, error) {
+	s.requested = append(s.requested, identifier)
+	if version, ok := s.versions[identifier]; ok {
+		return version, nil
+	}
+	return "", fmt.Errorf("%s: %s not found", s.name, identifier)
+}
+
+// useFakeSources replaces the github and gitlab sources for the duration of the test.
+func useFakeSources(t *testing.T, github, gitlab *fakeSource) {
+	t.Helper()
+	originalSources := sources
+	sources = map[string]VersionSource{"github": github, "gitlab": gitlab}
+	t.Cleanup(func() { sources = originalSources })
+}
+
+func TestDefaultSource(t *testing.T) {
+	github := &fakeSource{name: "GitHub", versions: map[string]string{"owner/repo": "1.0.0"}}
+	gitlab := &fakeSource{name: "GitLab", versions: map[string]string{"owner/repo": "2.0.0"}}
+	useFakeSources(t, github, gitlab)
+
+	t.Run("GitHubByDefault", func(t *testing.T) {
+		useTestConfigFile(t)
+		t.Setenv("SHOULDUPDATE_DEFAULT_SOURCE", "")
+		if result := checkApp("owner/repo", "1.0.0"); result.latestVersion != "1.0.0" {
+			t.Errorf("Expected the GitHub version, got %+v", result)
+		}
+	})
+
+	t.Run("EnvSelectsDefault", func(t *testing.T) {
+		useTestConfigFile(t)
+		t.Setenv("SHOULDUPDATE_DEFAULT_SOURCE", "gitlab")
+		if result := checkApp("owner/repo", "1.0.0"); result.latestVersion != "2.0.0" {
+			t.Errorf("Expected the GitLab version, got %+v", result)
+		}
+	})
+
+	t.Run("ConfigSelectsDefault", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		t.Setenv("SHOULDUPDATE_DEFAULT_SOURCE", "")
+		content := "\"owner/repo\" = \"1.0.0\"\n\n[_meta]\nschema_version = 1\ndefault_source = \"gitlab\"\n"
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		defer func() { configDefaultSource = "" }()
+
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("owner/repo", checkOptions{}) }))
+		if !strings.Contains(output, "Latest: 2.0.0") {
+			t.Errorf("Expected the GitLab version via config default_source. Got: %s", output)
+		}
+
+		// The setting survives a save.
+		if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if !strings.Contains(string(data), `default_source = "gitlab"`) {
+			t.Errorf("Expected default_source to be preserved on save, got:\n%s", data)
+		}
+	})
+
+	t.Run("ExplicitPrefixOverridesDefault", func(t *testing.T) {
+		useTestConfigFile(t)
+		t.Setenv("SHOULDUPDATE_DEFAULT_SOURCE", "gitlab")
+		if result := checkApp("github:owner/repo", "1.0.0"); result.latestVersion != "1.0.0" {
+			t.Errorf("Expected the GitHub version for an explicit github: prefix, got %+v", result)
+		}
+		if got := canonicalKey("gitlab:owner/repo"); got != "owner/repo" {
+			t.Errorf("Expected the default source's prefix to be dropped from the canonical key, got %q", got)
+		}
+	})
+
+	t.Run("UnknownSource", func(t *testing.T) {
+		useTestConfigFile(t)
+		result := checkApp("nosuch:owner/repo", "1.0.0")
+		if result.status != statusError || !strings.Contains(result.err.Error(), "unknown source 'nosuch'") {
+			t.Errorf("Expected an unknown source error, got %+v", result)
+		}
+	})
+}
+
+func TestSourceFallbackChain(t *testing.T) {
+	github := &fakeSource{name: "GitHub", versions: map[string]string{}}
+	gitlab := &fakeSource{name: "GitLab", versions: map[string]string{"group/tool": "2.0.0"}}
+	useFakeSources(t, github, gitlab)
+	defer func() { appOptions = make(map[string]AppOptions) }()
+
+	t.Run("FirstErrorsSecondAnswers", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		content := "[\"owner/tool\"]\nversion = \"1.0.0\"\nsources = [\"github:owner/tool\", \"gitlab:group/tool\"]\n"
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("owner/tool", checkOptions{}) }))
+		if !strings.Contains(output, "Latest: 2.0.0") || !strings.Contains(output, "via gitlab:group/tool") {
+			t.Errorf("Expected the GitLab version, noting its source. Got: %s", output)
+		}
+		if len(github.requested) == 0 {
+			t.Errorf("Expected GitHub to be tried first")
+		}
+
+		output = captureOutput(func() { handleCheckCmd("owner/tool", checkOptions{format: formatNDJSON}) })
+		if !strings.Contains(output, `"source":"gitlab:group/tool"`) {
+			t.Errorf("Expected the answering source in ndjson output, got: %s", output)
+		}
+	})
+
+	t.Run("AllFail", func(t *testing.T) {
+		useTestConfigFile(t)
+		appOptions["owner/tool"] = AppOptions{Sources: []string{"github:owner/tool", "gitlab:group/missing"}}
+		result := checkApp("owner/tool", "1.0.0")
+		var chainErr *sourceChainError
+		if result.status != statusError || !errors.As(result.err, &chainErr) || len(chainErr.errs) != 2 {
+			t.Fatalf("Expected an error for each source, got %+v", result)
+		}
+		if !strings.Contains(result.err.Error(), "gitlab:group/missing") {
+			t.Errorf("Expected the error to name each source, got %v", result.err)
+		}
+	})
+
+	t.Run("SourcesSurviveSave", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		appOptions["owner/tool"] = AppOptions{Sources: []string{"github:owner/tool", "brew:tool"}}
+		if err := saveConfig(Config{"owner/tool": "1.0.0"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		if _, err := loadConfig(); err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if got := appOptions["owner/tool"].Sources; len(got) != 2 || got[1] != "brew:tool" {
+			data, _ := os.ReadFile(path)
+			t.Errorf("Expected sources to round-trip, got %v from:\n%s", got, data)
+		}
+	})
+}
+
+func TestGitLabSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/releases/permalink/latest" {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		fmt.Fprintln(w, `{"tag_name": "v4.5.6"}`)
+	}))
+	defer server.Close()
+
+	source := gitlabSource{baseURL: server.URL}
+	version, err := source.LatestVersion(context.Background(), "group/project")
+	if err != nil || version != "4.5.6" {
+		t.Errorf("Expected 4.5.6, got '%s' (err: %v)", version, err)
+	}
+	if _, err := source.LatestVersion(context.Background(), "group/missing"); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("Expected a 404 error, got: %v", err)
+	}
+}
+
+func TestGiteaSource(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if r.URL.Path != "/api/v1/repos/owner/repo/releases/latest" {
+			http.Error(w, `{"message": "not found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprintln(w, `{"tag_name": "v1.21.4", "name": "1.21.4"}`)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	t.Setenv("GITEA_TOKEN", "secret")
+
+	source := giteaSource{scheme: "http"}
+	version, err := source.LatestVersion(context.Background(), host+"/owner/repo")
+	if err != nil || version != "1.21.4" {
+		t.Errorf("Expected 1.21.4, got '%s' (err: %v)", version, err)
+	}
+	if authorization != "token secret" {
+		t.Errorf("Expected the Gitea token to be sent, got Authorization %q", authorization)
+	}
+	if _, err := source.LatestVersion(context.Background(), host+"/owner/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a repository without releases, got: %v", err)
+	}
+	if source.ValidIdentifier("owner/repo") || !source.ValidIdentifier("git.example.com:3000/owner/repo") {
+		t.Errorf("Unexpected identifier validation for Gitea names")
+	}
+
+	t.Run("ResolvedToken", func(t *testing.T) {
+		t.Setenv("GITEA_TOKEN", "")
+		t.Setenv("SHOULDUPDATE_GITEA_TOKEN", "")
+		t.Setenv("SHOULDUPDATE_TOKEN", "")
+		t.Setenv("GITHUB_TOKEN", "")
+		t.Setenv("HOME", t.TempDir())
+		originalToken := githubToken
+		defer func() { githubToken = originalToken }()
+		githubToken = resolveToken("from-flag")
+
+		if _, err := source.LatestVersion(context.Background(), host+"/owner/repo"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if authorization != "token from-flag" {
+			t.Errorf("Expected the resolved token to be sent, got Authorization %q", authorization)
+		}
+
+		t.Setenv("GITEA_TOKEN", "gitea-only")
+		source.LatestVersion(context.Background(), host+"/owner/repo")
+		if authorization != "token gitea-only" {
+			t.Errorf("Expected GITEA_TOKEN to win over the resolved token, got Authorization %q", authorization)
+		}
+	})
+
+	t.Run("SelectedByPrefix", func(t *testing.T) {
+		useTestConfigFile(t)
+		originalSources := sources
+		defer func() { sources = originalSources }()
+		sources = map[string]VersionSource{"github": githubSource{}, "gitea": source, "forgejo": giteaSource{forgejo: true, scheme: "http"}}
+
+		for _, appName := range []string{"gitea:" + host + "/owner/repo", "forgejo:" + host + "/owner/repo"} {
+			if result := checkApp(appName, "1.21.0"); result.status != statusUpdateAvailable || result.latestVersion != "1.21.4" {
+				t.Errorf("Expected an update via %s, got %+v", appName, result)
+			}
+		}
+	})
+}
+
+func TestHomebrewSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/formula/ripgrep.json":
+			fmt.Fprintln(w, `{"name": "ripgrep", "versions": {"stable": "14.1.0", "head": "HEAD", "bottle": true}}`)
+		case "/api/cask/firefox.json":
+			fmt.Fprintln(w, `{"token": "firefox", "version": "125.0.2"}`)
+		case "/api/cask/someapp.json":
+			fmt.Fprintln(w, `{"token": "someapp", "version": "4.2.1,20240101"}`)
+		default:
+			http.Error(w, "Not Found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	formula := homebrewSource{baseURL: server.URL}
+	cask := homebrewSource{cask: true, baseURL: server.URL}
+	tests := []struct {
+		source     homebrewSource
+		identifier string
+		expected   string
+	}{
+		{source: formula, identifier: "ripgrep", expected: "14.1.0"},
+		{source: cask, identifier: "firefox", expected: "125.0.2"},
+		{source: cask, identifier: "someapp", expected: "4.2.1"},
+	}
+	for _, tt := range tests {
+		version, err := tt.source.LatestVersion(context.Background(), tt.identifier)
+		if err != nil || version != tt.expected {
+			t.Errorf("%s %s: expected %s, got '%s' (err: %v)", tt.source.DisplayName(), tt.identifier, tt.expected, version, err)
+		}
+	}
+
+	if _, err := formula.LatestVersion(context.Background(), "nosuchformula"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing formula, got: %v", err)
+	}
+	if formula.ValidIdentifier("user/tap/formula") || !formula.ValidIdentifier("python@3.12") {
+		t.Errorf("Unexpected identifier validation for Homebrew names")
+	}
+
+	t.Run("SelectedByPrefix", func(t *testing.T) {
+		useTestConfigFile(t)
+		originalSources := sources
+		defer func() { sources = originalSources }()
+		sources = map[string]VersionSource{"github": githubSource{}, "brew": formula, "cask": cask}
+
+		if result := checkApp("brew:ripgrep", "14.0.0"); result.status != statusUpdateAvailable || result.latestVersion != "14.1.0" {
+			t.Errorf("Expected an update via brew:, got %+v", result)
+		}
+		if result := checkApp("cask:firefox", "125.0.2"); result.status != statusUpToDate {
+			t.Errorf("Expected up to date via cask:, got %+v", result)
+		}
+	})
+}
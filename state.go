@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// appState is what shepherd remembers about an application between runs. Unlike the config,
+// it is written by shepherd alone and never meant to be edited.
+type appState struct {
+	LastChecked time.Time `json:"last_checked"`          // When the app was last checked successfully.
+	LastLatest  string    `json:"last_latest,omitempty"` // The latest version seen by that check.
+	LastStatus  string    `json:"last_status,omitempty"` // The outcome of the last check, failed or not, as in ndjson output.
+}
+
+// stateFile returns the path of the state file kept next to the config file, e.g.
+// versions.state.json for versions.toml.
+func stateFile() string {
+	return strings.TrimSuffix(configFile, filepath.Ext(configFile)) + ".state.json"
+}
+
+// loadState reads the state file. A missing file yields an empty state; an unreadable one
+// is reported and also treated as empty, as the state can always be rebuilt.
+func loadState() map[string]appState {
+	state := make(map[string]appState)
+	data, err := os.ReadFile(stateFile())
+	if os.IsNotExist(err) {
+		return state
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &state)
+	}
+	if err != nil {
+		log.Printf("Warning: Could not read state file '%s': %v. Starting with an empty state.", stateFile(), err)
+		return make(map[string]appState)
+	}
+	return state
+}
+
+// saveState writes the state file atomically.
+func saveState(state map[string]appState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not format state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(stateFile()), configDirPerm); err != nil {
+		return fmt.Errorf("could not create state directory: %w", err)
+	}
+	return writeFileAtomic(stateFile(), append(data, '\n'), configFilePerm)
+}
+
+// parseInterval parses a check_every value: a Go duration such as "12h", or a number of
+// days such as "7d".
+func parseInterval(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid interval '%s'", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid interval '%s'", value)
+	}
+	return d, nil
+}
+
+// notDueReason returns why appName should be skipped in this check-all run because it was
+// checked more recently than its check_every interval, or "" if it is due.
+func notDueReason(appName string, state map[string]appState, now time.Time) string {
+	checkEvery := appOptions[appName].CheckEvery
+	if checkEvery == "" {
+		return ""
+	}
+	interval, err := parseInterval(checkEvery)
+	if err != nil {
+		return "" // Reported by 'validate'; check as usual rather than never.
+	}
+	lastChecked := state[appName].LastChecked
+	if lastChecked.IsZero() || now.Sub(lastChecked) >= interval {
+		return ""
+	}
+	return fmt.Sprintf("Checked %s ago; due again in %s (check_every = %s).",
+		now.Sub(lastChecked).Round(time.Minute), lastChecked.Add(interval).Sub(now).Round(time.Minute), checkEvery)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"12h", 12 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"weekly", 0, true},
+		{"-1h", 0, true},
+		{"1.5d", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseInterval(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseInterval(%q) = %v, %v; want %v (error: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckEvery(t *testing.T) {
+	originalGetLatestVersion := getLatestVersion
+	defer func() {
+		getLatestVersion = originalGetLatestVersion
+		appOptions = make(map[string]AppOptions)
+	}()
+	var looked []string
+	recordLookups := func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		looked = append(looked, appIdentifier)
+		return "1.0.0", nil
+	}
+	getLatestVersion = recordLookups
+
+	content := `"owner/plain" = "1.0.0"
+
+["owner/weekly"]
+version = "1.0.0"
+check_every = "7d"
+
+["owner/hourly"]
+version = "1.0.0"
+check_every = "1h"
+
+["owner/never-checked"]
+version = "1.0.0"
+check_every = "7d"
+`
+	setup := func(t *testing.T) {
+		path := useTestConfigFile(t)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		now := time.Now()
+		err := saveState(map[string]appState{
+			"owner/plain":  {LastChecked: now.Add(-time.Minute)},
+			"owner/weekly": {LastChecked: now.Add(-2 * 24 * time.Hour)},
+			"owner/hourly": {LastChecked: now.Add(-2 * time.Hour)},
+		})
+		if err != nil {
+			t.Fatalf("Failed to seed state: %v", err)
+		}
+		looked = nil
+	}
+
+	t.Run("CheckAllSkipsAppsNotDue", func(t *testing.T) {
+		setup(t)
+		var exitCode int
+		out := captureOutput(func() { exitCode = handleCheckCmd("", checkOptions{}) })
+		if exitCode != exitOK {
+			t.Errorf("Expected exit code %d, got %d", exitOK, exitCode)
+		}
+		if strings.Join(looked, ",") != "owner/hourly,owner/never-checked,owner/plain" {
+			t.Errorf("Expected only due apps to be looked up, got %v", looked)
+		}
+		plain := stripAnsiCodes(out)
+		if !strings.Contains(plain, "Info: Skipping owner/weekly: Checked 48h0m0s ago; due again in 120h0m0s (check_every = 7d).") {
+			t.Errorf("Expected an Info note for the skipped app, got:\n%s", plain)
+		}
+
+		state := loadState()
+		if time.Since(state["owner/hourly"].LastChecked) > time.Minute {
+			t.Errorf("Expected owner/hourly's last check to be updated, got %v", state["owner/hourly"].LastChecked)
+		}
+		if time.Since(state["owner/weekly"].LastChecked) < 24*time.Hour {
+			t.Errorf("Expected the skipped app's last check to be kept, got %v", state["owner/weekly"].LastChecked)
+		}
+	})
+
+	t.Run("SingleAppAlwaysRuns", func(t *testing.T) {
+		setup(t)
+		captureOutput(func() { handleCheckCmd("owner/weekly", checkOptions{}) })
+		if strings.Join(looked, ",") != "owner/weekly" {
+			t.Errorf("Expected an explicit check to run regardless of check_every, got %v", looked)
+		}
+		if time.Since(loadState()["owner/weekly"].LastChecked) > time.Minute {
+			t.Error("Expected the explicit check to update the last-checked time")
+		}
+	})
+
+	t.Run("FailedChecksStayDue", func(t *testing.T) {
+		setup(t)
+		getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+			return "", tagError(ErrRateLimited, "rate limited")
+		}
+		defer func() { getLatestVersion = recordLookups }()
+		captureOutput(func() { handleCheckCmd("", checkOptions{}) })
+		if !loadState()["owner/never-checked"].LastChecked.IsZero() {
+			t.Error("Expected a failed check not to be recorded")
+		}
+	})
+
+	t.Run("CorruptStateIsIgnored", func(t *testing.T) {
+		setup(t)
+		if err := os.WriteFile(stateFile(), []byte("{not json"), 0600); err != nil {
+			t.Fatalf("Failed to write state: %v", err)
+		}
+		var logs string
+		captureOutput(func() { logs = captureLog(func() { handleCheckCmd("", checkOptions{}) }) })
+		if len(looked) != 4 {
+			t.Errorf("Expected every app to be checked with an unreadable state, got %v", looked)
+		}
+		if !strings.Contains(logs, "Warning: Could not read state file") {
+			t.Errorf("Expected a warning about the state file, got: %q", logs)
+		}
+	})
+}
+
+func TestCheckShowsLatestMovedSinceLastCheck(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	latest := "1.1.0"
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return latest, nil
+	}
+	if err := saveConfig(Config{"owner/repo": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	first := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{}) }))
+	if strings.Contains(first, "latest moved") {
+		t.Errorf("Expected no note on the first check, got:\n%s", first)
+	}
+	if got := loadState()["owner/repo"].LastLatest; got != "1.1.0" {
+		t.Errorf("Expected the latest version to be remembered, got %q", got)
+	}
+
+	latest = "1.2.0"
+	second := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{}) }))
+	if !strings.Contains(second, "latest moved 1.1.0 → 1.2.0 since last check") {
+		t.Errorf("Expected a note that the latest version moved, got:\n%s", second)
+	}
+	if got := loadState()["owner/repo"].LastLatest; got != "1.2.0" {
+		t.Errorf("Expected the remembered latest version to be updated, got %q", got)
+	}
+
+	third := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", checkOptions{}) }))
+	if strings.Contains(third, "latest moved") {
+		t.Errorf("Expected no note when the latest version is unchanged, got:\n%s", third)
+	}
+}
+
+func TestCheckChangedOnly(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	latest := map[string]string{"owner/stable": "1.0.0", "owner/moving": "1.0.0", "owner/flaky": "1.0.0"}
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		if latest[appIdentifier] == "" {
+			return "", errors.New("connection reset")
+		}
+		return latest[appIdentifier], nil
+	}
+	if err := saveConfig(Config{"owner/stable": "1.0.0", "owner/moving": "1.0.0", "owner/flaky": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	opts := checkOptions{changedOnly: true}
+
+	// Apps never checked before count as changed.
+	first := stripAnsiCodes(captureOutput(func() { handleCheckCmd("", opts) }))
+	for _, appName := range []string{"owner/stable", "owner/moving", "owner/flaky"} {
+		if !strings.Contains(first, appName) {
+			t.Errorf("Expected %s on the first run, got:\n%s", appName, first)
+		}
+	}
+
+	latest["owner/moving"] = "1.1.0"
+	latest["owner/flaky"] = ""
+	var second string
+	errOutput := captureStderr(t, func() { second = stripAnsiCodes(captureOutput(func() { handleCheckCmd("", opts) })) })
+	if !strings.Contains(second, "Checking owner/moving...  Current: 1.0.0, Latest: 1.1.0 (Update Available!)") {
+		t.Errorf("Expected the newly available update, got:\n%s", second)
+	}
+	if !strings.Contains(errOutput, "Failed to check owner/flaky") {
+		t.Errorf("Expected the newly failing check, got: %q", errOutput)
+	}
+	if strings.Contains(second, "owner/stable") || strings.Contains(second, "Checking all") {
+		t.Errorf("Expected nothing about apps whose status didn't change, got:\n%s", second)
+	}
+
+	// Nothing changed since the second run, so the third prints nothing at all.
+	var third string
+	errOutput = captureStderr(t, func() {
+		third = captureOutput(func() { handleCheckCmd("", opts) })
+	})
+	if third != "" || errOutput != "" {
+		t.Errorf("Expected no output when nothing changed, got %q and %q", third, errOutput)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// statusTableConcurrency caps the number of applications 'status' looks up at once.
+const statusTableConcurrency = 8
+
+// statusOptions holds the flags that affect what 'status' shows.
+type statusOptions struct {
+	noFetch    bool   // Look nothing up; show only what is recorded.
+	timeFormat string // How to show when apps were last checked; see formatTimestamp.
+}
+
+// handleStatusCmd prints every managed application in an aligned table of its recorded version,
+// when it was last checked and, unless opts.noFetch is set, its latest version and check status.
+// Latest versions are looked up concurrently; with noFetch, nothing is looked up.
+func handleStatusCmd(opts statusOptions) {
+	config, err := loadConfig()
+	if err != nil {
+		PrintError("Could not load configuration: %v", err)
+		return
+	}
+	if len(config) == 0 {
+		PrintInfo("No applications currently managed. Use the 'add' command to add some.")
+		return
+	}
+
+	appNames := sortedAppNames(config)
+	var results []checkResult
+	if !opts.noFetch {
+		results = make([]checkResult, len(appNames))
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, statusTableConcurrency)
+		for i, appName := range appNames {
+			wg.Add(1)
+			go func(i int, appName string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				results[i] = checkApp(appName, config[appName])
+			}(i, appName)
+		}
+		wg.Wait()
+	}
+
+	// The status is the last column, so its color codes don't throw off the alignment.
+	table := tabwriter.NewWriter(outputWriter(), 0, 0, 2, ' ', 0)
+	if opts.noFetch {
+		printTableRow(table, "APP", "RECORDED", "CHECKED")
+	} else {
+		printTableRow(table, "APP", "RECORDED", "CHECKED", "LATEST", "STATUS")
+	}
+	state, now := loadState(), time.Now()
+	for i, appName := range appNames {
+		checked := formatTimestamp(state[appName].LastChecked, opts.timeFormat, now)
+		if opts.noFetch {
+			printTableRow(table, displayName(appName), config[appName], checked)
+			continue
+		}
+		result := results[i]
+		latest, status := result.latestVersion, result.status.String()
+		if latest == "" {
+			latest = "-"
+		}
+		if result.err != nil {
+			status += ": " + result.err.Error()
+		}
+		printTableRow(table, displayName(appName), config[appName], checked, latest, Colorize(status, result.status.color()))
+	}
+	table.Flush()
+}
+
+// printTableRow writes cells as one tab-separated row of a tabwriter table.
+func printTableRow(w io.Writer, cells ...string) {
+	fmt.Fprintln(w, strings.Join(cells, "\t"))
+}
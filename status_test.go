@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHandleStatusCmd(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	lookups := 0
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		lookups++
+		switch appIdentifier {
+		case "owner/a":
+			return "1.1.0", nil
+		case "owner/long-name":
+			return "2.0.0", nil
+		}
+		return "", errors.New("boom")
+	}
+	if err := saveConfig(Config{"owner/a": "1.0.0", "owner/long-name": "2.0.0", "owner/broken": "0.1.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	checkedA, checkedLongName := time.Now().Add(-3*time.Hour).Truncate(time.Second), time.Now().Truncate(time.Second)
+	if err := saveState(map[string]appState{"owner/a": {LastChecked: checkedA}, "owner/long-name": {LastChecked: checkedLongName}}); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	t.Run("Fetch", func(t *testing.T) {
+		output := stripAnsiCodes(captureOutput(func() { handleStatusCmd(statusOptions{timeFormat: timeFormatRelative}) }))
+		expected := "" +
+			"APP              RECORDED  CHECKED   LATEST  STATUS\n" +
+			"owner/a          1.0.0     3h ago    1.1.0   Update Available!\n" +
+			"owner/broken     0.1.0     never     -       Error: boom\n" +
+			"owner/long-name  2.0.0     just now  2.0.0   Up to date\n"
+		if output != expected {
+			t.Errorf("Expected table:\n%s\ngot:\n%s", expected, output)
+		}
+	})
+
+	t.Run("NoFetch", func(t *testing.T) {
+		lookups = 0
+		output := stripAnsiCodes(captureOutput(func() { handleStatusCmd(statusOptions{noFetch: true, timeFormat: timeFormatRFC3339}) }))
+		expected := "" +
+			"APP              RECORDED  CHECKED\n" +
+			"owner/a          1.0.0     " + checkedA.Format(time.RFC3339) + "\n" +
+			"owner/broken     0.1.0     never\n" +
+			"owner/long-name  2.0.0     " + checkedLongName.Format(time.RFC3339) + "\n"
+		if output != expected {
+			t.Errorf("Expected table:\n%s\ngot:\n%s", expected, output)
+		}
+		if lookups != 0 {
+			t.Errorf("Expected no lookups with -no-fetch, got %d", lookups)
+		}
+	})
+}
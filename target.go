@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// handleCheckTargetCmd checks whether a specific version of appName was released on GitHub and
+// how it compares to the recorded version. It returns exitOK if the release exists and
+// exitCheckFailed otherwise.
+func handleCheckTargetCmd(appName, target string) int {
+	config, err := loadConfig()
+	if err != nil {
+		PrintError("Could not load configuration: %v", err)
+		return exitCheckFailed
+	}
+	appName = resolveAppName(config, appName)
+	currentVersion, exists := config[appName]
+	if !exists {
+		PrintError("Application '%s' not found in your managed list.", Colorize(appName, colorYellowFg))
+		return exitCheckFailed
+	}
+	source, identifier, err := resolveSource(appName)
+	if err != nil {
+		PrintError("%v", err)
+		return exitCheckFailed
+	}
+	if _, isGitHub := source.(githubSource); !isGitHub {
+		PrintError("Checking a specific release is only supported for GitHub apps, not %s.", source.DisplayName())
+		return exitCheckFailed
+	}
+
+	release, err := getReleaseByTag(requestContext, identifier, target, githubAPIBase)
+	if errors.Is(err, ErrNotFound) {
+		PrintError("No release tagged '%s' found for %s.", target, Colorize(appName, colorMagentaFg))
+		return exitCheckFailed
+	}
+	if err != nil {
+		PrintError("Failed to look up release '%s' of %s: %v", target, Colorize(appName, colorMagentaFg), err)
+		return exitCheckFailed
+	}
+
+	targetVersion := tagVersion(release.TagName)
+	relation := "same as current"
+	switch compareVersions(targetVersion, currentVersion) {
+	case 1:
+		relation = "newer than current"
+	case -1:
+		relation = "older than current"
+	}
+	label := ""
+	if release.Draft {
+		label = " [draft]"
+	} else if release.Prerelease {
+		label = " [pre-release]"
+	}
+	fmt.Fprintf(outputWriter(), "%sRelease %s of %s exists%s. Current: %s, Target: %s (%s)%s\n",
+		ansi(colorFgDefault),
+		Colorize(release.TagName, colorGreenFg),
+		Colorize(appName, colorYellowFg),
+		label,
+		Colorize(currentVersion, colorCyanFg),
+		Colorize(targetVersion, colorCyanFg),
+		relation,
+		ansi(colorReset))
+	if release.HTMLURL != "" {
+		PrintMessage("  %s", Colorize(release.HTMLURL, colorBlueFg))
+	}
+	return exitOK
+}
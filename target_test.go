@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetReleaseByTag(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		if r.URL.Path == "/repos/owner/repo/releases/tags/v2.0.0" {
+			fmt.Fprintln(w, `{"tag_name": "v2.0.0", "html_url": "https://github.com/owner/repo/releases/tag/v2.0.0"}`)
+			return
+		}
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	release, err := getReleaseByTagGitHubImpl(context.Background(), "owner/repo", "2.0.0", server.URL)
+	if err != nil || release.TagName != "v2.0.0" {
+		t.Fatalf("Expected the v2.0.0 release via the v-prefixed tag, got %+v (err: %v)", release, err)
+	}
+	if strings.Join(requested, ",") != "/repos/owner/repo/releases/tags/2.0.0,/repos/owner/repo/releases/tags/v2.0.0" {
+		t.Errorf("Expected the bare tag to be tried first, got %v", requested)
+	}
+
+	if _, err := getReleaseByTagGitHubImpl(context.Background(), "owner/repo", "9.9.9", server.URL); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing tag, got: %v", err)
+	}
+}
+
+func TestHandleCheckTargetCommand(t *testing.T) {
+	useTestConfigFile(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/releases/tags/v2.0.0":
+			fmt.Fprintln(w, `{"tag_name": "v2.0.0", "html_url": "https://example.com/v2.0.0"}`)
+		case "/repos/owner/repo/releases/tags/v1.0.0":
+			fmt.Fprintln(w, `{"tag_name": "v1.0.0"}`)
+		default:
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	originalAPIBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalAPIBase }()
+
+	if err := saveConfig(Config{"owner/repo": "1.5.0", "gitlab:group/project": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		appName      string
+		target       string
+		expectedCode int
+		expectedOut  string
+		expectedErr  string
+	}{
+		{"Newer", "owner/repo", "2.0.0", exitOK, "Release v2.0.0 of owner/repo exists. Current: 1.5.0, Target: 2.0.0 (newer than current)\n  https://example.com/v2.0.0\n", ""},
+		{"Older", "owner/repo", "v1.0.0", exitOK, "Release v1.0.0 of owner/repo exists. Current: 1.5.0, Target: 1.0.0 (older than current)\n", ""},
+		{"NotFound", "owner/repo", "3.0.0", exitCheckFailed, "", "No release tagged '3.0.0' found for owner/repo."},
+		{"NotTracked", "owner/other", "1.0.0", exitCheckFailed, "", "Application 'owner/other' not found in your managed list."},
+		{"NotGitHub", "gitlab:group/project", "1.0.0", exitCheckFailed, "", "only supported for GitHub apps, not GitLab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exitCode int
+			var output string
+			errOutput := captureStderr(t, func() {
+				output = stripAnsiCodes(captureOutput(func() { exitCode = handleCheckTargetCmd(tt.appName, tt.target) }))
+			})
+			if exitCode != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d", tt.expectedCode, exitCode)
+			}
+			if output != tt.expectedOut {
+				t.Errorf("Unexpected output.\nGot     : %q\nExpected: %q", output, tt.expectedOut)
+			}
+			if !strings.Contains(errOutput, tt.expectedErr) {
+				t.Errorf("Expected stderr to contain %q, got %q", tt.expectedErr, errOutput)
+			}
+		})
+	}
+}
+
+func TestCheckVerify(t *testing.T) {
+	useTestConfigFile(t)
+	var tagLookups []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/gone/releases/latest", "/repos/owner/kept/releases/latest":
+			fmt.Fprintln(w, `{"tag_name": "v1.2.0"}`)
+		case "/repos/owner/kept/releases/tags/v1.2.0":
+			tagLookups = append(tagLookups, r.URL.Path)
+			fmt.Fprintln(w, `{"tag_name": "v1.2.0"}`)
+		default:
+			if strings.Contains(r.URL.Path, "/tags/") {
+				tagLookups = append(tagLookups, r.URL.Path)
+			}
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	originalAPIBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalAPIBase }()
+	if err := saveConfig(Config{"owner/gone": "1.0.0", "owner/kept": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	var exitCode int
+	output := stripAnsiCodes(captureOutput(func() { exitCode = handleCheckCmd("", checkOptions{verify: true}) }))
+	for _, expected := range []string{
+		"Skipping owner/gone: The release of latest version 1.2.0 no longer exists; it may have been deleted.",
+		"Checking owner/kept...  Current: 1.0.0, Latest: 1.2.0 (Update Available!)",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+	if exitCode != exitOK {
+		t.Errorf("Expected exit code %d, as a deleted release is only a warning, got %d", exitOK, exitCode)
+	}
+	if len(tagLookups) != 4 {
+		t.Errorf("Expected the bare and v-prefixed tags to be looked up for both apps, got %v", tagLookups)
+	}
+
+	// Without -verify, the tag isn't looked up.
+	tagLookups = nil
+	output = stripAnsiCodes(captureOutput(func() { handleCheckCmd("owner/gone", checkOptions{}) }))
+	if !strings.Contains(output, "Latest: 1.2.0 (Update Available!)") || len(tagLookups) != 0 {
+		t.Errorf("Expected no verification without -verify, got lookups %v and:\n%s", tagLookups, output)
+	}
+}
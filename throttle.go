@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// hostThrottle enforces a minimum interval between requests to the same host.
+// Each call to Wait reserves the next free slot for that host, so concurrent
+// callers are spaced out rather than all waking at once.
+type hostThrottle struct {
+	interval time.Duration
+	now      func() time.Time
+	sleep    func(time.Duration)
+
+	mu   sync.Mutex
+	next map[string]time.Time // Earliest time the next request to a host may start.
+}
+
+// newHostThrottle returns a throttle using the real clock. An interval of 0 disables throttling.
+func newHostThrottle(interval time.Duration) *hostThrottle {
+	return &hostThrottle{
+		interval: interval,
+		now:      time.Now,
+		sleep:    time.Sleep,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Wait blocks until a request to host is allowed to start.
+func (t *hostThrottle) Wait(host string) {
+	if t == nil || t.interval <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	start := t.now()
+	if next, ok := t.next[host]; ok && next.After(start) {
+		start = next
+	}
+	wait := start.Sub(t.now())
+	t.next[host] = start.Add(t.interval)
+	t.mu.Unlock()
+
+	if wait > 0 {
+		t.sleep(wait)
+	}
+}
+
+// requestThrottle is the throttle applied to all outgoing API requests.
+// Tests can replace it with one driven by a fake clock.
+var requestThrottle = newHostThrottle(0)
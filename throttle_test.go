@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock. Sleeping advances it and records the duration.
+type fakeClock struct {
+	current time.Time
+	sleeps  []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{current: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.current }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.current = c.current.Add(d)
+}
+
+func newFakeThrottle(interval time.Duration, clock *fakeClock) *hostThrottle {
+	throttle := newHostThrottle(interval)
+	throttle.now = clock.Now
+	throttle.sleep = clock.Sleep
+	return throttle
+}
+
+func TestHostThrottle(t *testing.T) {
+	t.Run("SameHostIsSpaced", func(t *testing.T) {
+		clock := newFakeClock()
+		throttle := newFakeThrottle(time.Second, clock)
+		start := clock.Now()
+
+		throttle.Wait("api.github.com")
+		throttle.Wait("api.github.com")
+
+		if len(clock.sleeps) != 1 || clock.sleeps[0] != time.Second {
+			t.Errorf("Expected a single 1s sleep, got %v", clock.sleeps)
+		}
+		if elapsed := clock.Now().Sub(start); elapsed != time.Second {
+			t.Errorf("Expected second request 1s after the first, got %v", elapsed)
+		}
+	})
+
+	t.Run("DifferentHostsAreIndependent", func(t *testing.T) {
+		clock := newFakeClock()
+		throttle := newFakeThrottle(time.Second, clock)
+
+		throttle.Wait("api.github.com")
+		throttle.Wait("gitlab.com")
+
+		if len(clock.sleeps) != 0 {
+			t.Errorf("Expected no sleeps across hosts, got %v", clock.sleeps)
+		}
+	})
+
+	t.Run("ElapsedTimeIsCredited", func(t *testing.T) {
+		clock := newFakeClock()
+		throttle := newFakeThrottle(time.Second, clock)
+
+		throttle.Wait("api.github.com")
+		clock.current = clock.current.Add(400 * time.Millisecond)
+		throttle.Wait("api.github.com")
+
+		if len(clock.sleeps) != 1 || clock.sleeps[0] != 600*time.Millisecond {
+			t.Errorf("Expected a single 600ms sleep, got %v", clock.sleeps)
+		}
+	})
+
+	t.Run("ZeroIntervalNeverSleeps", func(t *testing.T) {
+		clock := newFakeClock()
+		throttle := newFakeThrottle(0, clock)
+
+		throttle.Wait("api.github.com")
+		throttle.Wait("api.github.com")
+
+		if len(clock.sleeps) != 0 {
+			t.Errorf("Expected no sleeps with a zero interval, got %v", clock.sleeps)
+		}
+	})
+}
+
+func TestGetLatestVersionIsThrottled(t *testing.T) {
+	clock := newFakeClock()
+	originalThrottle := requestThrottle
+	requestThrottle = newFakeThrottle(2*time.Second, clock)
+	defer func() { requestThrottle = originalThrottle }()
+
+	var requestTimes []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestTimes = append(requestTimes, clock.Now())
+		fmt.Fprintln(w, `{"tag_name": "v1.0.0"}`)
+	}))
+	defer server.Close()
+
+	for _, app := range []string{"owner/one", "owner/two"} {
+		if _, err := getLatestVersionGitHubImpl(context.Background(), app, server.URL); err != nil {
+			t.Fatalf("Expected no error for %s, got: %v", app, err)
+		}
+	}
+
+	if len(requestTimes) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requestTimes))
+	}
+	if gap := requestTimes[1].Sub(requestTimes[0]); gap != 2*time.Second {
+		t.Errorf("Expected requests to be spaced by 2s, got %v", gap)
+	}
+}
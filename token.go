@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// githubToken is the token sent with GitHub API requests, if any.
+// It is populated from resolveToken by the command handlers.
+var githubToken string
+
+// resolveToken looks up a GitHub token in order of precedence:
+// the -token flag, the SHOULDUPDATE_TOKEN or GITHUB_TOKEN environment variable, the GitHub CLI's
+// hosts.yml, and finally the ~/.config/shouldupdate/token file.
+// It returns an empty string if no token could be found.
+func resolveToken(flagToken string) string {
+	token, _ := resolveTokenSource(flagToken)
+	return token
+}
+
+// resolveTokenSource is like resolveToken, but also describes where the token was found.
+func resolveTokenSource(flagToken string) (string, string) {
+	if token := strings.TrimSpace(flagToken); token != "" {
+		return token, "the -token flag"
+	}
+	if token := envConfig().GitHubToken; token != "" {
+		return token, "the environment"
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", ""
+	}
+	if token := readGhHostsToken(filepath.Join(homeDir, ".config", "gh", "hosts.yml")); token != "" {
+		return token, "the GitHub CLI's hosts.yml"
+	}
+	if token := readTokenFile(tokenFile()); token != "" {
+		return token, "the token file"
+	}
+	return "", ""
+}
+
+// tokenFile returns the path of the token file written by 'token set', or "" if the home
+// directory is unknown.
+func tokenFile() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".config", "shouldupdate", "token")
+}
+
+// readGhHostsToken extracts the github.com oauth_token from a GitHub CLI hosts.yml file.
+// Only the small subset of YAML written by gh is understood; anything else yields "".
+func readGhHostsToken(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	inGitHubBlock := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		// Top-level keys are host names; only the github.com block is of interest.
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inGitHubBlock = strings.TrimSuffix(trimmed, ":") == "github.com"
+			continue
+		}
+		if !inGitHubBlock {
+			continue
+		}
+		if value, ok := strings.CutPrefix(trimmed, "oauth_token:"); ok {
+			return strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return ""
+}
+
+// readSecret prints prompt and reads a line without echoing it when stdin is a terminal.
+// Tests can override it.
+var readSecret = func(prompt string) (string, error) {
+	fmt.Fprintf(outputWriter(), "%s%s %s", ansi(colorCyanFg), prompt, ansi(colorReset))
+	if !stdinIsTerminal() {
+		if input == nil {
+			input = bufio.NewReader(os.Stdin)
+		}
+		line, err := input.ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return line, nil
+	}
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(outputWriter()) // The user's Enter wasn't echoed either.
+	return string(secret), err
+}
+
+// Actions of the 'token' command.
+const (
+	tokenSet    = "set"
+	tokenClear  = "clear"
+	tokenStatus = "status"
+)
+
+// handleTokenCmd stores, removes or reports on the token file. It returns the process exit code:
+// 0 on success, 1 otherwise. The token itself is never printed.
+func handleTokenCmd(action string) int {
+	path := tokenFile()
+	if path == "" {
+		PrintError("Could not determine the home directory for the token file.")
+		return 1
+	}
+
+	switch action {
+	case tokenSet:
+		token, err := readSecret("GitHub token:")
+		if err != nil {
+			PrintError("Could not read the token: %v", err)
+			return 1
+		}
+		token = strings.TrimSpace(token)
+		if token == "" {
+			PrintError("No token given; nothing saved.")
+			return 1
+		}
+		if err := os.MkdirAll(filepath.Dir(path), configDirPerm); err != nil {
+			PrintError("Could not create '%s': %v", filepath.Dir(path), err)
+			return 1
+		}
+		if err := writeFileAtomic(path, []byte(token+"\n"), configFilePerm); err != nil {
+			PrintError("Could not save the token: %v", err)
+			return 1
+		}
+		PrintSuccess("Token saved to %s.", path)
+		if _, source := resolveTokenSource(""); source != "the token file" {
+			PrintInfo("A token from %s takes precedence over the saved one.", source)
+		}
+	case tokenClear:
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			PrintInfo("No token file at %s. Nothing to clear.", path)
+			return 0
+		}
+		if err != nil {
+			PrintError("Could not remove the token file: %v", err)
+			return 1
+		}
+		PrintSuccess("Token file %s removed.", path)
+	case tokenStatus:
+		if _, source := resolveTokenSource(""); source != "" {
+			PrintMessage("A GitHub token is configured, from %s.", source)
+		} else {
+			PrintMessage("No GitHub token is configured. Use '%s token set' to save one.", os.Args[0])
+		}
+	default:
+		PrintError("Unknown token action '%s'. Supported actions: %s, %s, %s.", action, tokenSet, tokenClear, tokenStatus)
+		return 1
+	}
+	return 0
+}
+
+// readTokenFile returns the trimmed contents of a token file, or "" if it can't be read.
+func readTokenFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
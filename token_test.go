@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupTokenHome points HOME at a temp dir and clears the token environment variables for the duration of the test.
+func setupTokenHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("SHOULDUPDATE_TOKEN", "")
+	return home
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("Failed to create dir for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestResolveToken(t *testing.T) {
+	ghHosts := "github.com:\n    user: someone\n    oauth_token: gh-token\n    git_protocol: https\n"
+
+	t.Run("NoTokenAnywhere", func(t *testing.T) {
+		setupTokenHome(t)
+		if token := resolveToken(""); token != "" {
+			t.Errorf("Expected empty token, got '%s'", token)
+		}
+	})
+
+	t.Run("TokenFile", func(t *testing.T) {
+		home := setupTokenHome(t)
+		writeTestFile(t, filepath.Join(home, ".config", "shouldupdate", "token"), "file-token\n")
+		if token := resolveToken(""); token != "file-token" {
+			t.Errorf("Expected 'file-token', got '%s'", token)
+		}
+	})
+
+	t.Run("GhHostsBeatsTokenFile", func(t *testing.T) {
+		home := setupTokenHome(t)
+		writeTestFile(t, filepath.Join(home, ".config", "shouldupdate", "token"), "file-token")
+		writeTestFile(t, filepath.Join(home, ".config", "gh", "hosts.yml"), ghHosts)
+		if token := resolveToken(""); token != "gh-token" {
+			t.Errorf("Expected 'gh-token', got '%s'", token)
+		}
+	})
+
+	t.Run("GhHostsIgnoresOtherHosts", func(t *testing.T) {
+		home := setupTokenHome(t)
+		writeTestFile(t, filepath.Join(home, ".config", "gh", "hosts.yml"),
+			"github.example.com:\n    oauth_token: enterprise-token\n")
+		if token := resolveToken(""); token != "" {
+			t.Errorf("Expected empty token for non-github.com host, got '%s'", token)
+		}
+	})
+
+	t.Run("EnvBeatsGhHosts", func(t *testing.T) {
+		home := setupTokenHome(t)
+		writeTestFile(t, filepath.Join(home, ".config", "gh", "hosts.yml"), ghHosts)
+		t.Setenv("GITHUB_TOKEN", "env-token")
+		if token := resolveToken(""); token != "env-token" {
+			t.Errorf("Expected 'env-token', got '%s'", token)
+		}
+	})
+
+	t.Run("FlagBeatsEnv", func(t *testing.T) {
+		setupTokenHome(t)
+		t.Setenv("GITHUB_TOKEN", "env-token")
+		if token := resolveToken("flag-token"); token != "flag-token" {
+			t.Errorf("Expected 'flag-token', got '%s'", token)
+		}
+	})
+}
+
+func TestHandleTokenCmd(t *testing.T) {
+	fakeSecret := func(t *testing.T, secret string) {
+		original := readSecret
+		readSecret = func(string) (string, error) { return secret, nil }
+		t.Cleanup(func() { readSecret = original })
+	}
+
+	t.Run("SetStatusClear", func(t *testing.T) {
+		home := setupTokenHome(t)
+		path := filepath.Join(home, ".config", "shouldupdate", "token")
+		fakeSecret(t, "  secret-token\n")
+
+		if code := handleTokenCmd(tokenSet); code != 0 {
+			t.Fatalf("Expected exit code 0 from set, got %d", code)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Expected token file at %s: %v", path, err)
+		}
+		if mode := info.Mode().Perm(); mode != 0600 {
+			t.Errorf("Expected token file mode 0600, got %04o", mode)
+		}
+		if token := resolveToken(""); token != "secret-token" {
+			t.Errorf("Expected resolved token 'secret-token', got '%s'", token)
+		}
+
+		out := stripAnsiCodes(captureOutput(func() { handleTokenCmd(tokenStatus) }))
+		if !strings.Contains(out, "configured, from the token file") {
+			t.Errorf("Expected status to report the token file, got: %s", out)
+		}
+		if strings.Contains(out, "secret-token") {
+			t.Errorf("Expected status not to print the token, got: %s", out)
+		}
+
+		if code := handleTokenCmd(tokenClear); code != 0 {
+			t.Fatalf("Expected exit code 0 from clear, got %d", code)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected token file to be removed, got err %v", err)
+		}
+		out = stripAnsiCodes(captureOutput(func() { handleTokenCmd(tokenStatus) }))
+		if !strings.Contains(out, "No GitHub token is configured") {
+			t.Errorf("Expected status to report no token, got: %s", out)
+		}
+	})
+
+	t.Run("SetRejectsEmptyToken", func(t *testing.T) {
+		home := setupTokenHome(t)
+		fakeSecret(t, "   \n")
+		if code := handleTokenCmd(tokenSet); code != 1 {
+			t.Errorf("Expected exit code 1 for an empty token, got %d", code)
+		}
+		if _, err := os.Stat(filepath.Join(home, ".config", "shouldupdate", "token")); !os.IsNotExist(err) {
+			t.Errorf("Expected no token file to be written, got err %v", err)
+		}
+	})
+
+	t.Run("ClearWithoutFile", func(t *testing.T) {
+		setupTokenHome(t)
+		if code := handleTokenCmd(tokenClear); code != 0 {
+			t.Errorf("Expected exit code 0 when there is nothing to clear, got %d", code)
+		}
+	})
+
+	t.Run("StatusReportsEnvironment", func(t *testing.T) {
+		setupTokenHome(t)
+		t.Setenv("GITHUB_TOKEN", "env-token")
+		out := stripAnsiCodes(captureOutput(func() { handleTokenCmd(tokenStatus) }))
+		if !strings.Contains(out, "from the environment") || strings.Contains(out, "env-token") {
+			t.Errorf("Expected status to name the environment without the token, got: %s", out)
+		}
+	})
+
+	t.Run("UnknownAction", func(t *testing.T) {
+		setupTokenHome(t)
+		if code := handleTokenCmd("show"); code != 1 {
+			t.Errorf("Expected exit code 1 for an unknown action, got %d", code)
+		}
+	})
+}
+
+func TestGitHubTokenSentAsAuthorization(t *testing.T) {
+	originalToken := githubToken
+	githubToken = "secret"
+	defer func() { githubToken = originalToken }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Expected Authorization 'Bearer secret', got '%s'", got)
+		}
+		fmt.Fprintln(w, `{"tag_name": "v1.0.0"}`)
+	}))
+	defer server.Close()
+
+	if _, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// httpTimeout bounds each request as a whole, including its retries and the waits before them,
+// unless the request's context has a deadline of its own (see doRequest).
+const httpTimeout = 30 * time.Second
+
+// httpClient is the client every source makes its requests with. It honors HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY, applies requestThrottle, retries transient failures and reports
+// redirects to renamed repositories instead of following them. configureTransport rebuilds
+// it; tests can replace it.
+var httpClient = newHTTPClient(newTransport(nil))
+
+// newHTTPClient returns a client sending requests through base, wrapped with throttling and retries.
+func newHTTPClient(base http.RoundTripper) *http.Client {
+	return &http.Client{
+		Timeout:       httpTimeout,
+		Transport:     &retryTransport{base: &throttledTransport{base: base}, sleep: sleepContext},
+		CheckRedirect: checkRepoRedirect,
+	}
+}
+
+// requestContext is the context requests are made with, or that narrower ones such as an app's
+// timeout derive from. An interrupt cancels it, aborting all requests in flight. Tests can replace it.
+var requestContext, cancelRequests = context.WithCancel(context.Background())
+
+// doRequest sends req with httpClient. A deadline on req's context, such as an app's timeout,
+// replaces the client's own timeout for the request, so it may be longer as well as shorter.
+// If the context is marked by withBodyRateLimit, the response body is read no faster than -limit-rate.
+func doRequest(req *http.Request) (*http.Response, error) {
+	client := httpClient
+	if _, ok := req.Context().Deadline(); ok && httpClient.Timeout > 0 {
+		unbounded := *httpClient
+		unbounded.Timeout = 0
+		client = &unbounded
+	}
+	resp, err := client.Do(req)
+	if err == nil && limitRate > 0 && req.Context().Value(bodyHeavyKey{}) != nil {
+		resp.Body = newRateLimitedReader(resp.Body, limitRate*1024)
+	}
+	return resp, err
+}
+
+// insecureSkipVerify disables TLS certificate verification for httpClient, set by the global
+// -insecure-skip-verify flag. It is dangerous and only meant for testing against servers with
+// self-signed certificates; it takes effect when httpClient is next built.
+var insecureSkipVerify bool
+
+// newTransport returns a transport that uses the proxy settings from the environment and,
+// if rootCAs is non-nil, trusts only those roots. It is a copy, so http.DefaultTransport is
+// never changed.
+func newTransport(rootCAs *x509.CertPool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if rootCAs != nil || insecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs, InsecureSkipVerify: insecureSkipVerify}
+	}
+	return transport
+}
+
+// configureTransport rebuilds httpClient to also trust the PEM-encoded certificates in
+// caCertFile, e.g. an internal CA used by a corporate proxy. An empty path keeps the defaults.
+func configureTransport(caCertFile string) error {
+	if caCertFile == "" {
+		httpClient = newHTTPClient(newTransport(nil))
+		return nil
+	}
+	pemData, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return fmt.Errorf("could not read CA certificate file '%s': %w", caCertFile, err)
+	}
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM(pemData) {
+		return fmt.Errorf("no PEM certificates found in '%s'", caCertFile)
+	}
+	httpClient = newHTTPClient(newTransport(rootCAs))
+	return nil
+}
+
+// checkRepoRedirect follows redirects as usual, except ones from one repository's API path to
+// a differently named repository's, or to a repository by id as GitHub does for renamed ones:
+// those are returned as-is so the caller can report a RepoMovedError, letting the user learn
+// their tracked name is stale.
+func checkRepoRedirect(req *http.Request, via []*http.Request) error {
+	if from := repoFromAPIPath(via[0].URL.Path); from != "" {
+		if to := repoFromAPIPath(req.URL.Path); to != "" && !strings.EqualFold(from, to) {
+			return http.ErrUseLastResponse
+		}
+		if repoIDFromAPIPath(req.URL.Path) != "" {
+			return http.ErrUseLastResponse
+		}
+	}
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return nil
+}
+
+// throttledTransport waits for requestThrottle before each request.
+type throttledTransport struct {
+	base http.RoundTripper
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestThrottle.Wait(req.URL.Host)
+	return t.base.RoundTrip(req)
+}
+
+// maxRetries is how many times a transiently failing GET is retried.
+const maxRetries = 2
+
+// retryBackoff is the delay before the first retry; it doubles for each further one.
+const retryBackoff = 500 * time.Millisecond
+
+// maxRetryAfter is the longest Retry-After of a 429 response that is waited out before retrying.
+// Longer waits are left to the user; the error then says how long to wait. It is kept well below
+// httpTimeout, which the wait counts against.
+const maxRetryAfter = 15 * time.Second
+
+// retryHeadroom is how much of a request's time must be left after waiting out a Retry-After
+// for the retry itself; if less would be, the wait is left to the user like a long one.
+const retryHeadroom = 10 * time.Second
+
+// retryTransport retries GET requests that fail with a timeout, a dropped connection or
+// a 502, 503 or 504 response, and ones answered with a 429 whose Retry-After is short enough.
+// Requests whose context is done aren't retried, and cancelling it cuts a wait short.
+type retryTransport struct {
+	base  http.RoundTripper
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay := retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if req.Method != http.MethodGet || attempt >= maxRetries || req.Context().Err() != nil {
+			return resp, err
+		}
+		// The client's timeout is a deadline on the request's context, covering every attempt.
+		limit := maxRetryAfter
+		if deadline, ok := req.Context().Deadline(); ok {
+			limit = min(limit, time.Until(deadline)-retryHeadroom)
+		}
+		wait, retry := retryDelay(resp, err, delay, limit)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		delay *= 2
+	}
+}
+
+// sleepContext waits for d, or until ctx is done, in which case it returns ctx's error.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryDelay decides whether a failed attempt is retried and how long to wait first: a 429 is
+// retried after its Retry-After, if that is at most limit, and transient failures after backoff.
+func retryDelay(resp *http.Response, err error, backoff, limit time.Duration) (time.Duration, bool) {
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		wait, ok := retryAfter(resp, time.Now())
+		return wait, ok && wait <= limit
+	}
+	return backoff, isTransient(resp, err)
+}
+
+// retryAfter returns how long resp asks to wait before trying again. Its Retry-After header
+// may give a number of seconds or an HTTP date; a date in the past means no wait.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(date.Sub(now), 0), true
+}
+
+// isTransient reports whether a failed attempt is worth retrying.
+func isTransient(resp *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		return (errors.As(err, &netErr) && netErr.Timeout()) ||
+			errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCustomCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"tag_name": "v1.2.3"}`)
+	}))
+	defer server.Close()
+	defer configureTransport("")
+
+	t.Run("UntrustedByDefault", func(t *testing.T) {
+		if err := configureTransport(""); err != nil {
+			t.Fatalf("Failed to reset transport: %v", err)
+		}
+		if _, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL); err == nil {
+			t.Fatal("Expected a certificate error for the self-signed test server, got nil")
+		}
+	})
+
+	t.Run("TrustedWithCACert", func(t *testing.T) {
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		if err := os.WriteFile(caFile, certPEM, 0600); err != nil {
+			t.Fatalf("Failed to write CA file: %v", err)
+		}
+		if err := configureTransport(caFile); err != nil {
+			t.Fatalf("Failed to configure transport: %v", err)
+		}
+		version, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL)
+		if err != nil || version != "1.2.3" {
+			t.Errorf("Expected 1.2.3 via the trusted CA, got '%s' (err: %v)", version, err)
+		}
+	})
+
+	t.Run("InvalidCACert", func(t *testing.T) {
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		if err := os.WriteFile(caFile, []byte("not a certificate"), 0600); err != nil {
+			t.Fatalf("Failed to write CA file: %v", err)
+		}
+		if err := configureTransport(caFile); err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+			t.Errorf("Expected an error for a file without certificates, got: %v", err)
+		}
+		if err := configureTransport(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+			t.Error("Expected an error for a missing CA file, got nil")
+		}
+	})
+}
+
+// countingTransport is a RoundTripper that answers every request itself and counts them.
+type countingTransport struct {
+	calls int
+	hosts []string
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	t.hosts = append(t.hosts, req.URL.Host)
+	body := `{"tag_name": "v1.0.0", "version": "1.0.0", "versions": {"stable": "1.0.0"}}`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestSourcesShareHTTPClient(t *testing.T) {
+	counter := &countingTransport{}
+	originalClient := httpClient
+	httpClient = newHTTPClient(counter)
+	defer func() { httpClient = originalClient }()
+
+	lookups := []func() (string, error){
+		func() (string, error) { return getLatestVersionGitHubImpl(context.Background(), "owner/repo", "") },
+		func() (string, error) { return gitlabSource{}.LatestVersion(context.Background(), "group/project") },
+		func() (string, error) { return homebrewSource{}.LatestVersion(context.Background(), "ripgrep") },
+		func() (string, error) {
+			return homebrewSource{cask: true}.LatestVersion(context.Background(), "firefox")
+		},
+	}
+	for i, lookup := range lookups {
+		if version, err := lookup(); err != nil || version != "1.0.0" {
+			t.Errorf("Lookup %d: expected 1.0.0 through the injected client, got '%s' (err: %v)", i, version, err)
+		}
+	}
+	if counter.calls != len(lookups) {
+		t.Errorf("Expected all %d lookups to go through the shared client, got %d calls to %v", len(lookups), counter.calls, counter.hosts)
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	var attempts int
+	statuses := []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[attempts]
+		attempts++
+		w.WriteHeader(status)
+		fmt.Fprintln(w, `{"tag_name": "v1.0.0"}`)
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	transport := &retryTransport{base: http.DefaultTransport, sleep: func(ctx context.Context, d time.Duration) error { delays = append(delays, d); return nil }}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || attempts != 3 {
+		t.Errorf("Expected success on the third attempt, got status %d after %d attempts", resp.StatusCode, attempts)
+	}
+	if len(delays) != 2 || delays[0] != retryBackoff || delays[1] != 2*retryBackoff {
+		t.Errorf("Expected doubling backoff delays, got %v", delays)
+	}
+
+	// Errors that aren't transient are returned right away.
+	attempts, statuses = 0, []int{http.StatusNotFound}
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+	if attempts != 1 {
+		t.Errorf("Expected a 404 not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestRetryAfter429(t *testing.T) {
+	var attempts int
+	retryAfterValues := []string{"2", ""}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := retryAfterValues[attempts]
+		attempts++
+		if value == "" {
+			fmt.Fprintln(w, `{"tag_name": "v1.0.0"}`)
+			return
+		}
+		w.Header().Set("Retry-After", value)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport, sleep: func(ctx context.Context, d time.Duration) error { delays = append(delays, d); return nil }}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(delays) != 1 || delays[0] != 2*time.Second {
+		t.Errorf("Expected one retry after the 2s Retry-After, got status %d after delays %v", resp.StatusCode, delays)
+	}
+
+	// A wait longer than maxRetryAfter isn't waited out; the error says how long to wait.
+	attempts, retryAfterValues = 0, []string{"120"}
+	_, err = getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL)
+	if !errors.Is(err, ErrRateLimited) || !strings.Contains(err.Error(), "retry after 2m0s") {
+		t.Errorf("Expected a rate limit error saying to retry after 2m0s, got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected a long Retry-After not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestRetryAfterNearTimeout(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "14")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	// A wait within maxRetryAfter that would leave the retry too little of the client's timeout
+	// isn't waited out either: the error says how long to wait instead of timing out.
+	var delays []time.Duration
+	client := &http.Client{Timeout: 20 * time.Second, Transport: &retryTransport{base: http.DefaultTransport, sleep: func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected the 429 response rather than an error, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || attempts != 1 || len(delays) != 0 {
+		t.Errorf("Expected the 429 to be returned without waiting, got status %d after %d attempts, delays %v", resp.StatusCode, attempts, delays)
+	}
+	if hint := retryAfterHint(resp); hint != "; retry after 14s" {
+		t.Errorf("Expected the error to say to retry after 14s, got %q", hint)
+	}
+
+	// With the full timeout there is room to wait and retry.
+	attempts, delays = 0, nil
+	client.Timeout = httpTimeout
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+	if len(delays) == 0 || delays[0] != 14*time.Second {
+		t.Errorf("Expected the 14s Retry-After to be waited out, got delays %v", delays)
+	}
+}
+
+func TestRetryWaitIsCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport, sleep: sleepContext}}
+	start := time.Now()
+	_, err := client.Do(req)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled wait to fail the request, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected cancelling to cut the 10s wait short, took %v", elapsed)
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"30", 30 * time.Second, true},
+		{"Mon, 01 Jan 2024 12:01:30 GMT", 90 * time.Second, true},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0, true},
+		{"-5", 0, false},
+		{"soon", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{"Retry-After": {tt.value}}}
+		if wait, ok := retryAfter(resp, now); wait != tt.expected || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v; expected %v, %v", tt.value, wait, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"tag_name": "v1.2.3"}`)
+	}))
+	defer server.Close()
+	defer func() {
+		insecureSkipVerify = false
+		configureTransport("")
+	}()
+
+	if _, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL); err == nil {
+		t.Fatal("Expected a certificate error without -insecure-skip-verify, got nil")
+	}
+
+	insecureSkipVerify = true
+	if err := configureTransport(""); err != nil {
+		t.Fatalf("Failed to configure transport: %v", err)
+	}
+	version, err := getLatestVersionGitHubImpl(context.Background(), "owner/repo", server.URL)
+	if err != nil || version != "1.2.3" {
+		t.Errorf("Expected 1.2.3 with verification disabled, got '%s' (err: %v)", version, err)
+	}
+	if config := http.DefaultTransport.(*http.Transport).TLSClientConfig; config != nil && config.InsecureSkipVerify {
+		t.Error("Expected http.DefaultTransport to keep verifying certificates")
+	}
+}
@@ -1,10 +1,240 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
+// output is where regular (non-error) output goes. When nil, output goes to os.Stdout.
+var output io.Writer
+
+// errOutput is where errors and usage messages go. When nil, they go to os.Stderr.
+var errOutput io.Writer
+
+// colorEnabled controls whether ANSI color codes are emitted at all.
+var colorEnabled = true
+
+// Values accepted by the global -color flag.
+const (
+	colorAlways = "always"
+	colorAuto   = "auto"
+	colorNever  = "never"
+)
+
+// colorMode is the mode last applied by setColorMode.
+var colorMode = colorAuto
+
+// stdoutIsTerminal reports whether stdout is an interactive terminal. Tests can override it.
+var stdoutIsTerminal = func() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// setColorMode sets colorEnabled from a -color value. "auto" enables color only when stdout is
+// a terminal and neither NO_COLOR nor SHOULDUPDATE_NO_COLOR is set; "always" and "never" apply regardless.
+func setColorMode(mode string) error {
+	switch mode {
+	case colorAlways:
+		colorEnabled = true
+	case colorNever:
+		colorEnabled = false
+	case colorAuto:
+		colorEnabled = !envConfig().NoColor && stdoutIsTerminal()
+	default:
+		return fmt.Errorf("invalid -color value '%s': use %s, %s or %s", mode, colorAlways, colorAuto, colorNever)
+	}
+	colorMode = mode
+	return nil
+}
+
+// outputWriter returns the writer for regular output. os.Stdout is looked up on each call
+// so that tests which swap it out still capture everything.
+func outputWriter() io.Writer {
+	if output != nil {
+		return output
+	}
+	return os.Stdout
+}
+
+// errorWriter returns the writer for errors and usage messages, looking up os.Stderr on each call.
+func errorWriter() io.Writer {
+	if errOutput != nil {
+		return errOutput
+	}
+	return os.Stderr
+}
+
+// silenceOutput discards all regular, error and log output until the returned function is called.
+func silenceOutput() func() {
+	previousOutput, previousErrOutput, previousLogOutput := output, errOutput, log.Writer()
+	output, errOutput = io.Discard, io.Discard
+	log.SetOutput(io.Discard)
+	return func() {
+		output, errOutput = previousOutput, previousErrOutput
+		log.SetOutput(previousLogOutput)
+	}
+}
+
+// bufferOutput buffers regular output until the returned function flushes it, so long listings
+// aren't written a line at a time.
+func bufferOutput() func() {
+	previousOutput := output
+	buffered := bufio.NewWriter(outputWriter())
+	output = buffered
+	return func() {
+		buffered.Flush()
+		output = previousOutput
+	}
+}
+
+// redirectOutput sends regular output to the file at path, creating parent directories as needed.
+// Color is disabled since the file is not a terminal, unless -color=always was given.
+// The returned function closes the file.
+func redirectOutput(path string) (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("could not create directory for '%s': %w", path, err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create output file '%s': %w", path, err)
+	}
+	output = f
+	if colorMode != colorAlways {
+		colorEnabled = false
+	}
+	return func() error {
+		output = nil
+		return f.Close()
+	}, nil
+}
+
+// defaultPager is used when neither -pager nor PAGER names one. -R keeps colors.
+const defaultPager = "less -R"
+
+// resolvePager returns the pager command to page output through, or "" for none. Paging only
+// happens on a terminal and not when disabled; flagPager wins over PAGER and defaultPager.
+func resolvePager(flagPager string, disabled bool) string {
+	if disabled || !stdoutIsTerminal() {
+		return ""
+	}
+	if pager := strings.TrimSpace(flagPager); pager != "" {
+		return pager
+	}
+	if pager := envConfig().Pager; pager != "" {
+		return pager
+	}
+	return defaultPager
+}
+
+// activePager closes the input of the pager output is currently sent through and waits for it
+// to exit, or is nil when there is none. An interrupt calls it so the process never exits while
+// the pager still holds the terminal.
+var activePager struct {
+	sync.Mutex
+	close func() error
+}
+
+// startPager sends regular output through command, which is split on whitespace and run directly,
+// without a shell. The returned function closes the pager's input and waits for the user to quit it.
+func startPager(command string) (func() error, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return func() error { return nil }, nil
+	}
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("could not start pager '%s': %w", command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start pager '%s': %w", command, err)
+	}
+	output = stdin
+
+	// Both the command and an interrupt may close the pager; only the first waits for it.
+	var once sync.Once
+	var waitErr error
+	closePager := func() error {
+		once.Do(func() {
+			stdin.Close()
+			waitErr = cmd.Wait()
+		})
+		return waitErr
+	}
+	activePager.Lock()
+	activePager.close = closePager
+	activePager.Unlock()
+	return func() error {
+		output = nil
+		activePager.Lock()
+		activePager.close = nil
+		activePager.Unlock()
+		return closePager()
+	}, nil
+}
+
+// pagerActive reports whether output is currently sent through a pager.
+func pagerActive() bool {
+	activePager.Lock()
+	defer activePager.Unlock()
+	return activePager.close != nil
+}
+
+// waitForPager closes the input of the active pager, if any, and waits for the user to quit it.
+func waitForPager() {
+	activePager.Lock()
+	closePager := activePager.close
+	activePager.Unlock()
+	if closePager != nil {
+		closePager()
+	}
+}
+
+// input is where answers to interactive prompts are read from. It is created from os.Stdin
+// on first use; tests can replace it with a reader over scripted input.
+var input *bufio.Reader
+
+// stdinIsTerminal reports whether stdin is an interactive terminal. Tests can override it.
+var stdinIsTerminal = func() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Prompt prints a question and returns the trimmed answer. At end of input it returns "".
+func Prompt(format string, a ...interface{}) string {
+	message := fmt.Sprintf(format, a...)
+	fmt.Fprintf(outputWriter(), "%s%s %s", ansi(colorCyanFg), message, ansi(colorReset))
+	if input == nil {
+		input = bufio.NewReader(os.Stdin)
+	}
+	answer, _ := input.ReadString('\n')
+	return strings.TrimSpace(answer)
+}
+
+// Confirm asks a yes/no question and reports whether the answer was yes. Anything else, including
+// an empty answer or end of input, counts as no.
+func Confirm(format string, a ...interface{}) bool {
+	answer := strings.ToLower(Prompt(format+" [y/N]", a...))
+	return answer == "y" || answer == "yes"
+}
+
+// ansi returns colorCode, or an empty string when color is disabled.
+func ansi(colorCode string) string {
+	if !colorEnabled {
+		return ""
+	}
+	return colorCode
+}
+
 // Gruvbox-inspired 256-Color ANSI Codes
 const (
 	colorReset     = "\033[0m"
@@ -40,50 +270,149 @@ const (
 // Colorize wraps text with a given ANSI color code. It does NOT add a reset code.
 // The reset is expected to be handled by the calling print function at the end of the full line.
 func Colorize(text string, colorCode string) string {
+	if !colorEnabled {
+		return text
+	}
 	return colorCode + text + colorFgDefault // Return to default fg after this specific color
 }
 
-// PrintError prints a formatted error message to os.Stderr in red.
+// versionDiff renders oldVersion and newVersion diff-style: the components they share stay in the
+// default color, and everything from the first differing component on is shown in oldColor and
+// newColor respectively, e.g. "1." + "0.3" against "1." + "1.0" for a minor bump. Versions that
+// aren't both semver, or that are equal by semver precedence, are colored whole.
+func versionDiff(oldVersion, newVersion, oldColor, newColor string) (string, string) {
+	_, okOld := parseSemver(oldVersion)
+	_, okNew := parseSemver(newVersion)
+	if !okOld || !okNew || compareVersions(oldVersion, newVersion) == 0 {
+		return Colorize(oldVersion, oldColor), Colorize(newVersion, newColor)
+	}
+
+	oldParts, newParts := versionSegments(oldVersion), versionSegments(newVersion)
+	shared := 0
+	for shared < len(oldParts) && shared < len(newParts) && oldParts[shared] == newParts[shared] {
+		shared++
+	}
+	render := func(parts []string, color string) string {
+		prefix, rest := strings.Join(parts[:shared], ""), strings.Join(parts[shared:], "")
+		if prefix == "" {
+			return Colorize(rest, color)
+		}
+		if rest == "" {
+			return prefix
+		}
+		return prefix + Colorize(rest, color)
+	}
+	return render(oldParts, oldColor), render(newParts, newColor)
+}
+
+// versionSegments splits a version into its components and the separators between them, so
+// "v1.2.3-rc.1" becomes ["v", "1", ".", "2", ".", "3", "-", "rc", ".", "1"].
+func versionSegments(version string) []string {
+	var segments []string
+	start := 0
+	for i := 1; i <= len(version); i++ {
+		if i == len(version) || isVersionSeparator(version[i]) != isVersionSeparator(version[i-1]) ||
+			(i == 1 && version[0] == 'v') {
+			segments = append(segments, version[start:i])
+			start = i
+		}
+	}
+	return segments
+}
+
+// isVersionSeparator reports whether c separates the components of a version.
+func isVersionSeparator(c byte) bool {
+	return c == '.' || c == '-' || c == '+'
+}
+
+// Keywords accepted by -time-format besides a Go reference layout such as "2006-01-02 15:04".
+const (
+	timeFormatRelative = "relative" // e.g. "3h ago"
+	timeFormatRFC3339  = "rfc3339"  // e.g. "2024-01-02T15:04:05Z"
+)
+
+// formatTimestamp renders t for output under format: relative to now, as RFC 3339, or with
+// format as a Go layout. A zero t, i.e. a time never recorded, is shown as "never".
+func formatTimestamp(t time.Time, format string, now time.Time) string {
+	switch {
+	case t.IsZero():
+		return "never"
+	case format == timeFormatRelative:
+		return relativeTime(now.Sub(t))
+	case format == timeFormatRFC3339:
+		return t.Format(time.RFC3339)
+	}
+	return t.Format(format)
+}
+
+// relativeTime renders how long ago something happened d ago, in its largest whole unit.
+func relativeTime(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+}
+
+// validateTimeFormat checks that format is a keyword or a Go layout with at least one time verb;
+// anything else would print the same text for every timestamp.
+func validateTimeFormat(format string) error {
+	if format == timeFormatRelative || format == timeFormatRFC3339 {
+		return nil
+	}
+	// Every field of this time differs from the reference time's, so every verb changes the text.
+	probe := time.Date(2001, time.February, 3, 4, 5, 6, 123456789, time.FixedZone("XST", 3600))
+	if format == "" || probe.Format(format) == format {
+		return fmt.Errorf("invalid time format %q: use %s, %s or a Go layout such as \"2006-01-02 15:04\"", format, timeFormatRelative, timeFormatRFC3339)
+	}
+	return nil
+}
+
+// PrintError prints a formatted error message to the error writer in red.
 // Prefix: "Error: "
 func PrintError(format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
-	fmt.Fprintf(os.Stderr, "%sError: %s%s\n", colorRedFg, message, colorReset)
+	fmt.Fprintf(errorWriter(), "%sError: %s%s\n", ansi(colorRedFg), message, ansi(colorReset))
 }
 
-// PrintSuccess prints a formatted success message to os.Stdout in green.
+// PrintSuccess prints a formatted success message to the output writer in green.
 // Prefix: "Success: "
 func PrintSuccess(format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
-	fmt.Printf("%sSuccess: %s%s\n", colorGreenFg, message, colorReset)
+	fmt.Fprintf(outputWriter(), "%sSuccess: %s%s\n", ansi(colorGreenFg), message, ansi(colorReset))
 }
 
-// PrintInfo prints a formatted informational message to os.Stdout in yellow.
+// PrintInfo prints a formatted informational message to the output writer in yellow.
 // Prefix: "Info: "
 func PrintInfo(format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
-	fmt.Printf("%sInfo: %s%s\n", colorYellowFg, message, colorReset)
+	fmt.Fprintf(outputWriter(), "%sInfo: %s%s\n", ansi(colorYellowFg), message, ansi(colorReset))
 }
 
-// PrintMessage prints a formatted message to os.Stdout.
+// PrintMessage prints a formatted message to the output writer.
 // It applies colorFgDefault to the base message and ensures a reset at the end.
 // Arguments can be pre-colorized using Colorize.
 func PrintMessage(format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
 	// Ensure the base of the message is in default fg, then reset everything.
-	fmt.Printf("%s%s%s\n", colorFgDefault, message, colorReset)
+	fmt.Fprintf(outputWriter(), "%s%s%s\n", ansi(colorFgDefault), message, ansi(colorReset))
 }
 
-// PrintHeader prints a formatted header message to os.Stdout, bold and in blue.
+// PrintHeader prints a formatted header message to the output writer, bold and in blue.
 // Format: "== <message> =="
 func PrintHeader(format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
 	// Header is bold blue, then reset. The message itself is part of this.
-	fmt.Printf("%s%s== %s ==%s\n", colorBold, colorBlueFg, message, colorReset)
+	fmt.Fprintf(outputWriter(), "%s%s== %s ==%s\n", ansi(colorBold), ansi(colorBlueFg), message, ansi(colorReset))
 }
 
 // PrintUsageMessage prints a general usage line, typically to Stderr. Uses default foreground color.
 func PrintUsageMessage(format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
 	// Usage messages often go to Stderr for consistency with tool output conventions
-	fmt.Fprintf(os.Stderr, "%s%s%s\n", colorFgDefault, message, colorReset)
+	fmt.Fprintf(errorWriter(), "%s%s%s\n", ansi(colorFgDefault), message, ansi(colorReset))
 }
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedirectOutput(t *testing.T) {
+	useTestConfigFile(t)
+	originalGetLatestVersion := getLatestVersion
+	defer func() {
+		getLatestVersion = originalGetLatestVersion
+		colorEnabled = true
+	}()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.1.0", nil
+	}
+	if err := saveConfig(Config{"owner/a": "1.0.0", "owner/b": "1.1.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	commands := map[string]func(){
+		"list":  func() { handleListCmd(listOptions{}) },
+		"check": func() { handleCheckCmd("", checkOptions{}) },
+	}
+	for name, run := range commands {
+		t.Run(name, func(t *testing.T) {
+			colorEnabled = true
+			stdoutOutput := stripAnsiCodes(captureOutput(run))
+
+			outPath := filepath.Join(t.TempDir(), "reports", "nested", name+".txt")
+			closeOutput, err := redirectOutput(outPath)
+			if err != nil {
+				t.Fatalf("Failed to redirect output: %v", err)
+			}
+			leaked := captureOutput(run)
+			if err := closeOutput(); err != nil {
+				t.Fatalf("Failed to close output: %v", err)
+			}
+
+			data, err := os.ReadFile(outPath)
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if leaked != "" {
+				t.Errorf("Expected nothing on stdout while redirected, got: %q", leaked)
+			}
+			if strings.Contains(string(data), "\033[") {
+				t.Errorf("Expected no color codes in file output, got: %q", string(data))
+			}
+			if string(data) != stdoutOutput {
+				t.Errorf("File output differs from stdout output.\nFile  : %q\nStdout: %q", string(data), stdoutOutput)
+			}
+		})
+	}
+}
+
+func TestSetColorMode(t *testing.T) {
+	originalStdoutIsTerminal := stdoutIsTerminal
+	defer func() {
+		stdoutIsTerminal = originalStdoutIsTerminal
+		colorEnabled = true
+		colorMode = colorAuto
+	}()
+
+	tests := []struct {
+		mode     string
+		terminal bool
+		noColor  string
+		expected bool
+	}{
+		{mode: colorAlways, terminal: false, expected: true},
+		{mode: colorAlways, terminal: true, noColor: "1", expected: true},
+		{mode: colorNever, terminal: true, expected: false},
+		{mode: colorAuto, terminal: true, expected: true},
+		{mode: colorAuto, terminal: false, expected: false},
+		{mode: colorAuto, terminal: true, noColor: "1", expected: false},
+	}
+	for _, tt := range tests {
+		stdoutIsTerminal = func() bool { return tt.terminal }
+		t.Setenv("NO_COLOR", tt.noColor)
+		if err := setColorMode(tt.mode); err != nil {
+			t.Fatalf("setColorMode(%q) failed: %v", tt.mode, err)
+		}
+		output := captureOutput(func() { PrintSuccess("done") })
+		if hasColor := strings.Contains(output, "\033["); hasColor != tt.expected {
+			t.Errorf("mode=%s terminal=%v NO_COLOR=%q: expected color %v, got output %q", tt.mode, tt.terminal, tt.noColor, tt.expected, output)
+		}
+	}
+
+	if err := setColorMode("sometimes"); err == nil || !strings.Contains(err.Error(), "invalid -color value 'sometimes'") {
+		t.Errorf("Expected an error for an invalid mode, got: %v", err)
+	}
+
+	t.Run("AlwaysKeepsColorInFiles", func(t *testing.T) {
+		if err := setColorMode(colorAlways); err != nil {
+			t.Fatalf("setColorMode failed: %v", err)
+		}
+		outPath := filepath.Join(t.TempDir(), "out.txt")
+		closeOutput, err := redirectOutput(outPath)
+		if err != nil {
+			t.Fatalf("Failed to redirect output: %v", err)
+		}
+		PrintSuccess("done")
+		closeOutput()
+		data, _ := os.ReadFile(outPath)
+		if !strings.Contains(string(data), "\033[") {
+			t.Errorf("Expected color codes with -color=always, got %q", data)
+		}
+	})
+}
+
+func TestResolvePager(t *testing.T) {
+	originalStdoutIsTerminal := stdoutIsTerminal
+	defer func() { stdoutIsTerminal = originalStdoutIsTerminal }()
+	t.Setenv("SHOULDUPDATE_PAGER", "")
+
+	tests := []struct {
+		name      string
+		terminal  bool
+		flagPager string
+		envPager  string
+		disabled  bool
+		expected  string
+	}{
+		{"Default", true, "", "", false, defaultPager},
+		{"FromEnvironment", true, "", "more", false, "more"},
+		{"FlagWins", true, "most", "more", false, "most"},
+		{"Piped", false, "most", "more", false, ""},
+		{"NoPager", true, "most", "more", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdoutIsTerminal = func() bool { return tt.terminal }
+			t.Setenv("PAGER", tt.envPager)
+			if got := resolvePager(tt.flagPager, tt.disabled); got != tt.expected {
+				t.Errorf("Expected pager %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStartPager(t *testing.T) {
+	useTestConfigFile(t)
+	if err := saveConfig(Config{"owner/a": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	defer func() { colorEnabled = true }()
+	colorEnabled = false
+
+	// A fake pager that upper-cases its input shows the output went through it.
+	var closeErr error
+	paged := captureOutput(func() {
+		closePager, err := startPager("tr a-z A-Z")
+		if err != nil {
+			t.Fatalf("Failed to start pager: %v", err)
+		}
+		handleListCmd(listOptions{})
+		closeErr = closePager()
+	})
+	if closeErr != nil {
+		t.Errorf("Expected the pager to exit cleanly, got %v", closeErr)
+	}
+	if !strings.Contains(paged, "APPLICATION: OWNER/A, VERSION: 1.0.0") {
+		t.Errorf("Expected the list output to be routed through the pager, got: %q", paged)
+	}
+	if output != nil {
+		t.Error("Expected output to be restored after the pager exited")
+	}
+
+	if _, err := startPager("shepherd-no-such-pager"); err == nil {
+		t.Error("Expected an error for a pager that doesn't exist")
+	}
+}
+
+func TestVersionDiff(t *testing.T) {
+	originalColorEnabled := colorEnabled
+	colorEnabled = true
+	defer func() { colorEnabled = originalColorEnabled }()
+	was := func(s string) string { return colorMagentaFg + s + colorFgDefault }
+	now := func(s string) string { return colorCyanFg + s + colorFgDefault }
+
+	tests := []struct {
+		name                     string
+		oldVersion, newVersion   string
+		expectedOld, expectedNew string
+	}{
+		{"Major", "1.2.3", "2.0.0", was("1.2.3"), now("2.0.0")},
+		{"Minor", "1.0.3", "1.1.0", "1." + was("0.3"), "1." + now("1.0")},
+		{"Patch", "1.2.3", "1.2.4", "1.2." + was("3"), "1.2." + now("4")},
+		{"PreRelease", "v1.2.0-rc.1", "v1.2.0-rc.2", "v1.2.0-rc." + was("1"), "v1.2.0-rc." + now("2")},
+		{"ExtraComponent", "1.2", "1.2.1", "1.2", "1.2" + now(".1")},
+		{"Equal", "1.2", "1.2.0", was("1.2"), now("1.2.0")},
+		{"NotSemver", "nightly-1", "nightly-2", was("nightly-1"), now("nightly-2")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOld, gotNew := versionDiff(tt.oldVersion, tt.newVersion, colorMagentaFg, colorCyanFg)
+			if gotOld != tt.expectedOld || gotNew != tt.expectedNew {
+				t.Errorf("versionDiff(%q, %q) = %q, %q; expected %q, %q", tt.oldVersion, tt.newVersion, gotOld, gotNew, tt.expectedOld, tt.expectedNew)
+			}
+		})
+	}
+
+	t.Run("NoColor", func(t *testing.T) {
+		colorEnabled = false
+		defer func() { colorEnabled = true }()
+		if gotOld, gotNew := versionDiff("1.0.3", "1.1.0", colorMagentaFg, colorCyanFg); gotOld != "1.0.3" || gotNew != "1.1.0" {
+			t.Errorf("Expected plain versions without color, got %q, %q", gotOld, gotNew)
+		}
+	})
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	now := time.Date(2024, 3, 10, 18, 30, 0, 0, time.UTC)
+	seeded := time.Date(2024, 3, 8, 14, 5, 9, 0, time.UTC)
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{timeFormatRelative, "2d ago"},
+		{timeFormatRFC3339, "2024-03-08T14:05:09Z"},
+		{"2006-01-02 15:04", "2024-03-08 14:05"},
+		{"02 Jan 06", "08 Mar 24"},
+	}
+	for _, tt := range tests {
+		if got := formatTimestamp(seeded, tt.format, now); got != tt.expected {
+			t.Errorf("formatTimestamp with %q = %q, expected %q", tt.format, got, tt.expected)
+		}
+	}
+
+	for _, tt := range []struct {
+		ago      time.Duration
+		expected string
+	}{
+		{30 * time.Second, "just now"},
+		{5 * time.Minute, "5m ago"},
+		{3*time.Hour + 59*time.Minute, "3h ago"},
+		{49 * time.Hour, "2d ago"},
+	} {
+		if got := formatTimestamp(now.Add(-tt.ago), timeFormatRelative, now); got != tt.expected {
+			t.Errorf("formatTimestamp %v ago = %q, expected %q", tt.ago, got, tt.expected)
+		}
+	}
+	if got := formatTimestamp(time.Time{}, timeFormatRFC3339, now); got != "never" {
+		t.Errorf("Expected a zero time to render as never, got %q", got)
+	}
+}
+
+func TestValidateTimeFormat(t *testing.T) {
+	for _, format := range []string{timeFormatRelative, timeFormatRFC3339, "2006-01-02 15:04", "02 Jan 06", "Mon", "15h", ".000", "MST"} {
+		if err := validateTimeFormat(format); err != nil {
+			t.Errorf("Expected %q to be accepted, got: %v", format, err)
+		}
+	}
+	for _, format := range []string{"", "iso", "yyyy-mm-dd", "relatve"} {
+		if err := validateTimeFormat(format); err == nil {
+			t.Errorf("Expected %q, which has no time verbs, to be rejected", format)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// validationProblem is one thing wrong with a config entry.
+type validationProblem struct {
+	appName string
+	message string
+}
+
+// validateConfig checks every entry of config without making any network requests and returns
+// all problems found, in app name order.
+func validateConfig(config Config) []validationProblem {
+	var problems []validationProblem
+	add := func(appName, format string, a ...interface{}) {
+		problems = append(problems, validationProblem{appName: appName, message: fmt.Sprintf(format, a...)})
+	}
+
+	for _, appName := range sortedAppNames(config) {
+		version := config[appName]
+		opts := appOptions[appName]
+
+		name, identifier := splitSourcePrefix(appName)
+		if source, ok := sources[name]; !ok {
+			add(appName, "unknown source '%s'", name)
+		} else if !source.ValidIdentifier(identifier) {
+			add(appName, "not in '%s' format for %s", source.IdentifierFormat(), source.DisplayName())
+		}
+
+		switch {
+		case version == "":
+			add(appName, "version is empty")
+		case version == latestSentinel:
+			// Follows the latest release; not a version to parse.
+		case compareModeFor(appName) == compareSemver:
+			if _, ok := parseSemver(version); !ok {
+				add(appName, "version '%s' is not a semantic version (set compare_mode = \"exact\" to allow it)", version)
+			}
+		}
+
+		for _, ref := range opts.Sources {
+			name, identifier := splitSourcePrefix(ref)
+			if source, ok := sources[name]; !ok {
+				add(appName, "unknown source '%s' in sources", name)
+			} else if !source.ValidIdentifier(identifier) {
+				add(appName, "sources entry '%s' is not in '%s' format for %s", ref, source.IdentifierFormat(), source.DisplayName())
+			}
+		}
+		if opts.Channel != "" {
+			if name, _ := splitSourcePrefix(appName); name != "github" && len(opts.Sources) == 0 {
+				add(appName, "channel is only supported for GitHub releases")
+			}
+		}
+		if len(opts.Ignore) > 0 {
+			if name, _ := splitSourcePrefix(appName); name != "github" && len(opts.Sources) == 0 {
+				add(appName, "ignore is only supported for GitHub releases")
+			}
+		}
+		switch opts.VersionField {
+		case "", versionFieldTagName:
+		case versionFieldName:
+			if name, _ := splitSourcePrefix(appName); name != "github" && len(opts.Sources) == 0 {
+				add(appName, "version_field is only supported for GitHub releases")
+			}
+		default:
+			add(appName, "unknown version_field '%s' (use \"tag_name\" or \"name\")", opts.VersionField)
+		}
+		if opts.CompareMode != "" && opts.CompareMode != compareSemver && opts.CompareMode != compareExact {
+			add(appName, "unknown compare_mode '%s'", opts.CompareMode)
+		}
+		if opts.CheckEvery != "" {
+			if _, err := parseInterval(opts.CheckEvery); err != nil {
+				add(appName, "invalid check_every: %v (use e.g. \"12h\" or \"7d\")", err)
+			}
+		}
+		if opts.Timeout != "" {
+			if _, err := parseTimeout(opts.Timeout); err != nil {
+				add(appName, "invalid timeout: %v (use e.g. \"10s\" or \"2m\")", err)
+			}
+		}
+		if opts.VersionRegex != "" {
+			if _, err := regexp.Compile(opts.VersionRegex); err != nil {
+				add(appName, "invalid version_regex: %v", err)
+			}
+		}
+	}
+	return problems
+}
+
+// handleValidateCmd validates the configuration offline, reporting every problem at once.
+// It returns exitOK if the configuration is valid and exitCheckFailed otherwise.
+func handleValidateCmd() int {
+	config, err := loadConfig()
+	if err != nil {
+		PrintError("Could not load configuration: %v", err)
+		return exitCheckFailed
+	}
+
+	problems := validateConfig(config)
+	if len(problems) == 0 {
+		PrintSuccess("Configuration is valid (%s).", pluralize(len(config), "application", "applications"))
+		return exitOK
+	}
+	PrintError("Found %s in %s:", pluralize(len(problems), "problem", "problems"), configFile)
+	for _, problem := range problems {
+		PrintMessage("  - %s: %s", Colorize(problem.appName, colorMagentaFg), problem.message)
+	}
+	return exitCheckFailed
+}
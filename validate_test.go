@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHandleValidateCommand(t *testing.T) {
+	originalGetLatestVersion := getLatestVersion
+	defer func() {
+		getLatestVersion = originalGetLatestVersion
+		appOptions = make(map[string]AppOptions)
+	}()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		t.Errorf("validate must not make network requests (looked up %s)", appIdentifier)
+		return "", nil
+	}
+
+	t.Run("ReportsAllProblems", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		content := `"owner/good" = "1.2.3"
+"notarepo" = "1.0.0"
+"nosuch:owner/repo" = "1.0.0"
+"owner/empty" = ""
+"owner/nightly" = "nightly"
+"brew:ripgrep" = "14.1.0"
+"owner/calver" = "2024.03.1.2"
+
+["owner/exact"]
+version = "build-2024"
+compare_mode = "exact"
+timeout = "10s"
+
+["owner/options"]
+version = "1.0.0"
+compare_mode = "fuzzy"
+version_regex = "("
+timeout = "-5s"
+version_field = "title"
+`
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+
+		var exitCode int
+		var output string
+		errOutput := captureStderr(t, func() {
+			output = stripAnsiCodes(captureOutput(func() { exitCode = handleValidateCmd() }))
+		})
+
+		if exitCode != exitCheckFailed {
+			t.Errorf("Expected exit code %d, got %d", exitCheckFailed, exitCode)
+		}
+		if !strings.Contains(errOutput, "Found 8 problems in "+path) {
+			t.Errorf("Expected a problem count. Got: %s", errOutput)
+		}
+		for _, expected := range []string{
+			"  - nosuch:owner/repo: unknown source 'nosuch'\n",
+			"  - notarepo: not in 'owner/repo' format for GitHub\n",
+			"  - owner/empty: version is empty\n",
+			"  - owner/nightly: version 'nightly' is not a semantic version",
+			"  - owner/options: unknown compare_mode 'fuzzy'\n",
+			"  - owner/options: invalid version_regex:",
+			"  - owner/options: invalid timeout: invalid timeout '-5s'",
+			"  - owner/options: unknown version_field 'title'",
+		} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected output to contain %q. Got:\n%s", expected, output)
+			}
+		}
+		for _, valid := range []string{"owner/good", "brew:ripgrep", "owner/exact", "owner/calver"} {
+			if strings.Contains(output, valid) {
+				t.Errorf("Did not expect a problem for %s. Got:\n%s", valid, output)
+			}
+		}
+	})
+
+	t.Run("ValidConfig", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(Config{"owner/a": "1.0.0", "gitlab:group/project": "v2.1"}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		var exitCode int
+		output := stripAnsiCodes(captureOutput(func() { exitCode = handleValidateCmd() }))
+		if exitCode != exitOK || !strings.Contains(output, "Configuration is valid (2 applications).") {
+			t.Errorf("Expected a valid config (exit %d). Got exit %d and:\n%s", exitOK, exitCode, output)
+		}
+	})
+}
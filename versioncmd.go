@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// defaultVersionRegex finds the first version-looking string in a command's output,
+// e.g. "1.2.3" in "mytool version v1.2.3 (linux/amd64)".
+const defaultVersionRegex = `v?(\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?)`
+
+// runCommand runs a command and returns its combined stdout and stderr.
+// Tests can override it to avoid executing anything.
+var runCommand = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// installedVersion runs appName's version_command and extracts the installed version from its
+// output. It returns "" if the app has no version_command. The command is split on whitespace
+// and run directly, without a shell.
+func installedVersion(appName string) (string, error) {
+	opts := appOptions[appName]
+	fields := strings.Fields(opts.VersionCommand)
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	pattern := opts.VersionRegex
+	if pattern == "" {
+		pattern = defaultVersionRegex
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid version_regex for %s: %w", appName, err)
+	}
+
+	out, err := runCommand(fields[0], fields[1:]...)
+	if err != nil {
+		return "", fmt.Errorf("version command '%s' failed: %w", opts.VersionCommand, err)
+	}
+	match := re.FindStringSubmatch(string(out))
+	switch {
+	case match == nil:
+		return "", fmt.Errorf("no version found in the output of '%s'", opts.VersionCommand)
+	case len(match) > 1:
+		return match[1], nil
+	}
+	return match[0], nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// useFakeCommands replaces runCommand with a lookup of canned outputs by command line.
+// It returns a pointer to the list of command lines that were run.
+func useFakeCommands(t *testing.T, outputs map[string]string) *[]string {
+	t.Helper()
+	var ran []string
+	originalRunCommand := runCommand
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		commandLine := strings.Join(append([]string{name}, args...), " ")
+		ran = append(ran, commandLine)
+		out, ok := outputs[commandLine]
+		if !ok {
+			return nil, errors.New("executable file not found")
+		}
+		return []byte(out), nil
+	}
+	t.Cleanup(func() { runCommand = originalRunCommand })
+	return &ran
+}
+
+func TestInstalledVersion(t *testing.T) {
+	useFakeCommands(t, map[string]string{
+		"mytool --version": "mytool version v1.4.2 (linux/amd64)\n",
+		"other -V":         "other 2.0 build 17\n",
+	})
+	defer func() { appOptions = make(map[string]AppOptions) }()
+	appOptions = map[string]AppOptions{
+		"owner/mytool":  {VersionCommand: "mytool --version"},
+		"owner/other":   {VersionCommand: "other -V", VersionRegex: `build (\d+)`},
+		"owner/missing": {VersionCommand: "missing --version"},
+		"owner/badre":   {VersionCommand: "mytool --version", VersionRegex: `(`},
+	}
+
+	tests := []struct {
+		appName   string
+		expected  string
+		expectErr string
+	}{
+		{appName: "owner/mytool", expected: "1.4.2"},
+		{appName: "owner/other", expected: "17"},
+		{appName: "owner/plain", expected: ""},
+		{appName: "owner/missing", expectErr: "version command 'missing --version' failed"},
+		{appName: "owner/badre", expectErr: "invalid version_regex"},
+	}
+	for _, tt := range tests {
+		version, err := installedVersion(tt.appName)
+		if tt.expectErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("installedVersion(%q): expected error containing %q, got %v", tt.appName, tt.expectErr, err)
+			}
+			continue
+		}
+		if err != nil || version != tt.expected {
+			t.Errorf("installedVersion(%q) = %q, %v; expected %q", tt.appName, version, err, tt.expected)
+		}
+	}
+}
+
+func TestCheckRunVersionCommands(t *testing.T) {
+	path := useTestConfigFile(t)
+	ran := useFakeCommands(t, map[string]string{"mytool --version": "mytool 1.1.0\n"})
+	originalGetLatestVersion := getLatestVersion
+	defer func() {
+		getLatestVersion = originalGetLatestVersion
+		appOptions = make(map[string]AppOptions)
+	}()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "1.1.0", nil
+	}
+
+	content := "[\"owner/mytool\"]\nversion = \"1.0.0\"\nversion_command = \"mytool --version\"\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("owner/mytool", checkOptions{}) }))
+		if !strings.Contains(output, "Current: 1.0.0, Latest: 1.1.0 (Update Available!)") {
+			t.Errorf("Expected the recorded version to be used. Got:\n%s", output)
+		}
+		if len(*ran) != 0 {
+			t.Errorf("Expected no commands to run without the opt-in flag, ran %v", *ran)
+		}
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		output := stripAnsiCodes(captureOutput(func() { handleCheckCmd("owner/mytool", checkOptions{runVersionCommands: true}) }))
+		if !strings.Contains(output, "Current: 1.1.0, Latest: 1.1.0 (Up to date)") {
+			t.Errorf("Expected the installed version to be used. Got:\n%s", output)
+		}
+	})
+
+	t.Run("OptionsSurviveSave", func(t *testing.T) {
+		cfg, err := loadConfig()
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		cfg["owner/mytool"] = "1.1.0"
+		if err := saveConfig(cfg); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		if _, err := loadConfig(); err != nil {
+			t.Fatalf("Failed to reload config: %v", err)
+		}
+		if appOptions["owner/mytool"].VersionCommand != "mytool --version" {
+			t.Errorf("Expected version_command to be preserved, got %+v", appOptions)
+		}
+	})
+}
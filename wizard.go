@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+)
+
+// firstRunWizardPending is set by main for the commands that read the config, so the wizard is
+// offered at most once per run: by the first loadConfig to find no config file, which comes after
+// the command has set up its token and transport, or earlier by commands that page their output.
+var firstRunWizardPending bool
+
+// maybeRunFirstRunWizard offers to add a first application when no config file exists yet, if
+// the wizard is pending. It only runs when stdin is a terminal, so scripts and pipes are never
+// blocked by a prompt.
+func maybeRunFirstRunWizard() {
+	if !firstRunWizardPending {
+		return
+	}
+	firstRunWizardPending = false
+	if _, err := os.Stat(configFile); !os.IsNotExist(err) || !stdinIsTerminal() {
+		return
+	}
+	if githubToken == "" {
+		githubToken = resolveToken("")
+	}
+	runFirstRunWizard()
+}
+
+// runFirstRunWizard interactively adds a first application. Every question can be skipped with Enter.
+func runFirstRunWizard() {
+	PrintHeader("Welcome to shepherd")
+	PrintMessage("No configuration found at %s.", configFile)
+	appName := Prompt("Track a first application? Enter it as owner/repo (or press Enter to skip):")
+	if appName == "" {
+		PrintInfo("Skipped. Use the 'add' command to add applications at any time.")
+		return
+	}
+
+	appVersion := Prompt("Which version do you have? (press Enter to use the latest release):")
+	if appVersion == "" {
+		latestVersion, err := lookupLatestVersion(requestContext, appName)
+		if err != nil {
+			PrintError("Could not look up the latest version of '%s': %v", appName, err)
+			return
+		}
+		appVersion = latestVersion
+	}
+	handleAddCmd(appName, appVersion, addOptions{})
+}
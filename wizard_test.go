@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+)
+
+// useScriptedInput feeds answers to Prompt and pretends stdin is (or isn't) a terminal.
+func useScriptedInput(t *testing.T, answers string, terminal bool) {
+	t.Helper()
+	originalInput, originalIsTerminal := input, stdinIsTerminal
+	input = bufio.NewReader(strings.NewReader(answers))
+	stdinIsTerminal = func() bool { return terminal }
+	t.Cleanup(func() { input, stdinIsTerminal = originalInput, originalIsTerminal })
+}
+
+// usePendingWizard marks the first-run wizard pending, as main does for commands that read the config.
+func usePendingWizard(t *testing.T) {
+	t.Helper()
+	firstRunWizardPending = true
+	t.Cleanup(func() { firstRunWizardPending = false })
+}
+
+func TestFirstRunWizard(t *testing.T) {
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "3.1.4", nil
+	}
+
+	t.Run("AddsWithGivenVersion", func(t *testing.T) {
+		useTestConfigFile(t)
+		usePendingWizard(t)
+		useScriptedInput(t, "owner/repo\n1.0.0\n", true)
+		output := stripAnsiCodes(captureOutput(maybeRunFirstRunWizard))
+		if !strings.Contains(output, "Welcome to shepherd") {
+			t.Errorf("Expected welcome header. Got: %s", output)
+		}
+		cfg, _ := loadConfig()
+		if cfg["owner/repo"] != "1.0.0" {
+			t.Errorf("Expected owner/repo at 1.0.0, got %v", cfg)
+		}
+	})
+
+	t.Run("AddsAtLatestWhenVersionSkipped", func(t *testing.T) {
+		useTestConfigFile(t)
+		usePendingWizard(t)
+		useScriptedInput(t, "owner/repo\n\n", true)
+		captureOutput(maybeRunFirstRunWizard)
+		cfg, _ := loadConfig()
+		if cfg["owner/repo"] != "3.1.4" {
+			t.Errorf("Expected owner/repo at latest 3.1.4, got %v", cfg)
+		}
+	})
+
+	t.Run("Skippable", func(t *testing.T) {
+		path := useTestConfigFile(t)
+		usePendingWizard(t)
+		useScriptedInput(t, "\n", true)
+		output := stripAnsiCodes(captureOutput(maybeRunFirstRunWizard))
+		if !strings.Contains(output, "Skipped.") {
+			t.Errorf("Expected skip message. Got: %s", output)
+		}
+		if fileExists(path) {
+			t.Errorf("Expected no config file to be created when skipping")
+		}
+	})
+
+	t.Run("NotRunWhenPiped", func(t *testing.T) {
+		useTestConfigFile(t)
+		usePendingWizard(t)
+		useScriptedInput(t, "owner/repo\n1.0.0\n", false)
+		if output := captureOutput(maybeRunFirstRunWizard); output != "" {
+			t.Errorf("Expected no prompt when stdin is not a terminal, got: %q", output)
+		}
+	})
+
+	t.Run("NotRunWhenConfigExists", func(t *testing.T) {
+		useTestConfigFile(t)
+		if err := saveConfig(Config{}); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		usePendingWizard(t)
+		useScriptedInput(t, "owner/repo\n1.0.0\n", true)
+		if output := captureOutput(maybeRunFirstRunWizard); output != "" {
+			t.Errorf("Expected no prompt when a config exists, got: %q", output)
+		}
+	})
+}
+
+func TestFirstRunWizardOnConfigRead(t *testing.T) {
+	originalGetLatestVersion := getLatestVersion
+	defer func() { getLatestVersion = originalGetLatestVersion }()
+	getLatestVersion = func(ctx context.Context, appIdentifier string, apiBaseURL string) (string, error) {
+		return "3.1.4", nil
+	}
+
+	t.Run("Status", func(t *testing.T) {
+		useTestConfigFile(t)
+		usePendingWizard(t)
+		useScriptedInput(t, "owner/repo\n1.0.0\n", true)
+		output := stripAnsiCodes(captureOutput(func() { handleStatusCmd(statusOptions{noFetch: true, timeFormat: timeFormatRelative}) }))
+		if !strings.Contains(output, "Welcome to shepherd") || !strings.Contains(output, "owner/repo") {
+			t.Errorf("Expected 'status' to offer the wizard and then show the added app. Got: %s", output)
+		}
+		if firstRunWizardPending {
+			t.Error("Expected the wizard to be offered only once")
+		}
+	})
+
+	t.Run("OnlyOnce", func(t *testing.T) {
+		useTestConfigFile(t)
+		usePendingWizard(t)
+		useScriptedInput(t, "\n", true)
+		captureOutput(func() { loadConfig() })
+		if output := captureOutput(func() { loadConfig() }); output != "" {
+			t.Errorf("Expected the wizard not to be offered again, got: %q", output)
+		}
+	})
+
+	t.Run("NotPending", func(t *testing.T) {
+		useTestConfigFile(t)
+		useScriptedInput(t, "owner/repo\n1.0.0\n", true)
+		if output := captureOutput(func() { loadConfig() }); output != "" {
+			t.Errorf("Expected commands that don't read the config not to offer the wizard, got: %q", output)
+		}
+	})
+}